@@ -0,0 +1,130 @@
+// Package main - URL 列表批量下载
+// 支持从文件或标准输入读取 URL 列表，按 URL 类型自动选择下载方式
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// readURLList 按行读取 URL 列表，跳过空行与 # 开头的注释行
+func readURLList(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(utils.L("读取URL列表失败: %w", "failed to read URL list: %w"), err)
+	}
+	return urls, nil
+}
+
+// downloadByURL 根据 URL 类型选择对应的下载逻辑（与遗留的智能下载判断保持一致）
+func downloadByURL(ctx context.Context, client *core.Client, url string, opts *DownloadOpts) error {
+	if utils.IsBareToken(url) {
+		url = utils.TokenToURL(url, opts.tokenType)
+	}
+	url = utils.NormalizeShareURL(url)
+	switch {
+	case strings.Contains(url, "/drive/folder/"):
+		return downloadDocuments(ctx, client, url, opts)
+	case strings.Contains(url, "/wiki/space/"), strings.Contains(url, "/wiki/settings/"):
+		return downloadWiki(ctx, client, url, opts)
+	default:
+		return downloadSingleDocument(ctx, client, url, opts)
+	}
+}
+
+// downloadURLList 依次下载列表中的每个 URL，单个失败不会中断整体，结束后统一汇总
+func downloadURLList(ctx context.Context, client *core.Client, urls []string, opts *DownloadOpts) error {
+	type failure struct {
+		url string
+		err error
+	}
+	var failures []failure
+	succeeded := 0
+
+	for i, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Printf(utils.L("📥 [%d/%d] %s\n", "📥 [%d/%d] %s\n"), i+1, len(urls), url)
+		localOpts := *opts
+		// 每个 URL 单独统计，汇总只反映该 URL 的结果
+		dlStats = &DownloadStats{}
+		if err := downloadByURL(ctx, client, url, &localOpts); err != nil {
+			fmt.Printf(utils.L("❌ 下载失败: %v\n", "❌ Download failed: %v\n"), err)
+			failures = append(failures, failure{url: url, err: err})
+			continue
+		}
+		succeeded++
+	}
+
+	fmt.Println()
+	fmt.Printf(utils.L("🎉 完成！共 %d 个URL，成功 %d 个，失败 %d 个\n", "🎉 Done! %d URLs, %d succeeded, %d failed\n"), len(urls), succeeded, len(failures))
+	if len(failures) == 0 {
+		return nil
+	}
+	fmt.Println(utils.L("失败列表：", "Failures:"))
+	for _, f := range failures {
+		fmt.Printf("- %s  (%v)\n", f.url, f.err)
+	}
+	return fmt.Errorf(utils.L("%d 个URL下载失败", "%d URLs failed to download"), len(failures))
+}
+
+// handleURLListDownload 从 reader 读取 URL 列表并批量下载
+func handleURLListDownload(cliCtx *cli.Context, r io.Reader) error {
+	urls, err := readURLList(r)
+	if err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return cli.Exit(utils.L("错误: 未读取到任何URL", "Error: no URL was read"), 1)
+	}
+
+	opts, config, err := createCommonOpts(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	dlConfig = *config
+	client := newClient(config)
+	ctx, stop := newSignalContext()
+	defer stop()
+
+	if err := verifyCredentials(ctx, client); err != nil {
+		return err
+	}
+	return finishDownload(ctx, client, downloadURLList(ctx, client, urls, opts))
+}
+
+// handleStdinDownload 处理 `feishu2md batch -`：从标准输入读取 URL 列表
+func handleStdinDownload(cliCtx *cli.Context) error {
+	return handleURLListDownload(cliCtx, os.Stdin)
+}
+
+// handleBatchFileDownload 处理 `feishu2md batch-file <文件>`：从文本文件读取 URL 列表，- 表示标准输入
+func handleBatchFileDownload(cliCtx *cli.Context, path string) error {
+	if path == "-" {
+		return handleStdinDownload(cliCtx)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf(utils.L("错误: 无法打开URL列表文件: %v", "Error: cannot open URL list file: %v"), err), 1)
+	}
+	defer f.Close()
+	return handleURLListDownload(cliCtx, f)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadURLListSkipsBlankAndComments(t *testing.T) {
+	input := "https://x.feishu.cn/docx/a\n\n# 注释\n  https://x.feishu.cn/docx/b  \n"
+	urls, err := readURLList(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://x.feishu.cn/docx/a", "https://x.feishu.cn/docx/b"}
+	if strings.Join(urls, ",") != strings.Join(want, ",") {
+		t.Errorf("readURLList() = %v, want %v", urls, want)
+	}
+}
+
+func TestDownloadURLListContinuesAfterFailure(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxGood", "好文档", "正文内容")
+
+	// 第一个 URL 对应的文档不存在（接口返回错误码），不应中断后续下载
+	urls := []string{
+		"https://x.feishu.cn/docx/doxMissing",
+		"https://x.feishu.cn/docx/doxGood",
+	}
+	err := downloadURLList(context.Background(), feishu.client(), urls, &DownloadOpts{outputDir: dir})
+	if err == nil || !strings.HasPrefix(err.Error(), "1 ") {
+		t.Fatalf("downloadURLList() error = %v, want 1 failed URL", err)
+	}
+
+	data, rerr := os.ReadFile(filepath.Join(dir, "好文档.md"))
+	if rerr != nil {
+		t.Fatalf("good document was not written: %v", rerr)
+	}
+	if !strings.Contains(string(data), "正文内容") {
+		t.Errorf("good document content = %q", data)
+	}
+}
+
+func TestBatchReadsURLsFromStdin(t *testing.T) {
+	dir := setupDownload(t)
+	setCredentialEnv(t)
+	t.Setenv("OUTPUT_DIR", dir)
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxOne", "文档一", "正文一")
+	feishu.addDocx("doxTwo", "文档二", "正文二")
+
+	// 模拟 `cat urls.txt | feishu2md batch -`
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = orig; r.Close() })
+	go func() {
+		fmt.Fprint(w, "# 注释\nhttps://x.feishu.cn/docx/doxOne\n\nhttps://x.feishu.cn/docx/doxTwo\n")
+		w.Close()
+	}()
+
+	if err := feishu.runApp(t, "batch", "-"); err != nil {
+		t.Fatalf("batch - error = %v", err)
+	}
+	for _, name := range []string{"文档一.md", "文档二.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was not downloaded from the stdin URL list: %v", name, err)
+		}
+	}
+}
@@ -5,10 +5,13 @@ package main
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha1"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -21,6 +24,7 @@ import (
 	"github.com/chyroc/lark"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
 )
 
 // DownloadOpts 包含下载操作的选项
@@ -34,8 +38,17 @@ type DownloadOpts struct {
 	relDir        string   // 相对根输出目录的路径（仅 wiki-tree 用于日志排序）
 	tags          []string // 标签列表（从路径所有层级推导）
 	category      string   // 分类（单个，从路径指定层级推导）
+	categories    []string // 分类层级（路径全部层级，hexo 风格输出为嵌套分类）
 	categoryLevel int      // 分类层级: 正数从外向内(1=第一层), 负数从内向外(-1=最后一层)
+	tagMaxLevels  int      // tags 最多取几层目录: 正数取前 n 层, 负数取后 n 层, 0 不限制
 	cleanOutput   bool     // wiki-tree：同步前清空输出目录，再按最新树生成，避免旧文件残留
+	resume        bool     // wiki-tree：从上次中断处继续，跳过进度文件中已完成的文档
+	includeSelf   bool     // wiki-tree：同时下载根节点自身（docx）到输出目录
+	flat          bool     // wiki-tree：所有文档输出到同一目录，不按层级建子目录
+	checkpoint    int      // wiki-tree：每完成多少篇保存一次进度与缓存检查点，0 表示只在结束时保存
+
+	fileNames *FileNameRegistry // 非 nil 时在整个批次内去重文件名（flat 布局使用）
+	tokenType string            // 输入为裸 token 时的文档类型：docx 或 wiki
 }
 
 // calculateMD5 计算字符串的MD5哈希值
@@ -51,6 +64,16 @@ func fileExists(filepath string) bool {
 	return !os.IsNotExist(err)
 }
 
+// syncFileModTime 将文件的访问/修改时间设置为文档的修改时间；时间未知时保持不变
+func syncFileModTime(path string, modTime time.Time) {
+	if modTime.IsZero() {
+		return
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		fmt.Printf(utils.L("⚠️  设置文件修改时间失败 %s: %v\n", "⚠️  Failed to set file mtime %s: %v\n"), path, err)
+	}
+}
+
 // shouldSkipFile 检查是否应该跳过文件下载（基于内容对比）
 func shouldSkipFile(outputPath, content string, skipDuplicate bool) bool {
 	if !skipDuplicate {
@@ -78,13 +101,37 @@ func shouldSkipFile(outputPath, content string, skipDuplicate bool) bool {
 // dlConfig 保存当前下载操作的配置
 var dlConfig core.Config
 
-// DownloadStats 用于跨文档统计下载/缓存命中等信息（主要用于 wiki-tree 汇总）
+// defaultImgConcurrency 单文档内图片下载的默认并发度
+const defaultImgConcurrency = 16
+
+// defaultDocConcurrency 文档下载的默认并发度，folder、wiki、wiki-tree 共用
+const defaultDocConcurrency = 10
+
+// docConcurrency 返回文档下载并发度：--doc-concurrency 大于 0 时使用该值，否则使用默认值
+func docConcurrency() int {
+	if n := dlConfig.Output.DocConcurrency; n > 0 {
+		return n
+	}
+	return defaultDocConcurrency
+}
+
+// imgConcurrency 返回单文档内图片下载并发度，与文档并发相互独立
+func imgConcurrency() int {
+	if n := dlConfig.Output.ImgConcurrency; n > 0 {
+		return n
+	}
+	return defaultImgConcurrency
+}
+
+// DownloadStats 用于跨文档统计下载/缓存命中等信息，下载结束时输出汇总
 type DownloadStats struct {
 	mu          sync.Mutex
 	totalDocs   int
 	docsNew     int
 	totalImages int
 	imagesNew   int
+	imgFailed   int // 下载失败的图片数，含权限不足
+	imgDenied   int // 其中因权限不足（403）失败的图片数
 }
 
 func (s *DownloadStats) SetTotalDocs(n int) {
@@ -92,6 +139,11 @@ func (s *DownloadStats) SetTotalDocs(n int) {
 	s.totalDocs = n
 	s.mu.Unlock()
 }
+func (s *DownloadStats) AddTotalDocs(n int) {
+	s.mu.Lock()
+	s.totalDocs += n
+	s.mu.Unlock()
+}
 func (s *DownloadStats) AddDocNew() {
 	s.mu.Lock()
 	s.docsNew++
@@ -103,14 +155,27 @@ func (s *DownloadStats) AddImages(encountered, newlyDownloaded int) {
 	s.imagesNew += newlyDownloaded
 	s.mu.Unlock()
 }
+func (s *DownloadStats) AddImageFailure(denied bool) {
+	s.mu.Lock()
+	s.imgFailed++
+	if denied {
+		s.imgDenied++
+	}
+	s.mu.Unlock()
+}
+func (s *DownloadStats) ImageFailures() (failed, denied int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.imgFailed, s.imgDenied
+}
 func (s *DownloadStats) Snapshot() (totalDocs, docsNew, totalImages, imagesNew int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return s.totalDocs, s.docsNew, s.totalImages, s.imagesNew
 }
 
-// dlStats 在 wiki-tree 模式下初始化用于统计；其他模式保持 nil
-var dlStats *DownloadStats
+// dlStats 本次下载的统计，由 createCommonOpts 为每个命令重新初始化
+var dlStats = &DownloadStats{}
 
 // DocLog 记录单篇文档的处理情况
 type DocLog struct {
@@ -123,16 +188,37 @@ type DocLog struct {
 }
 
 type LogCollector struct {
-	mu   sync.Mutex
-	logs []DocLog
+	mu     sync.Mutex
+	logs   []DocLog
+	stream bool // 为 true 时每条日志完成即输出、不在内存中保留（大知识库使用）
 }
 
 func (lc *LogCollector) Add(l DocLog) {
 	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.stream {
+		fmt.Println(formatDocLog(l))
+		return
+	}
 	lc.logs = append(lc.logs, l)
+}
+
+// SetStream 切换为流式输出模式
+func (lc *LogCollector) SetStream(stream bool) {
+	lc.mu.Lock()
+	lc.stream = stream
 	lc.mu.Unlock()
 }
 
+// Drain 取出并清空已收集的日志
+func (lc *LogCollector) Drain() []DocLog {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	logs := lc.logs
+	lc.logs = nil
+	return logs
+}
+
 func (lc *LogCollector) SortedByPath() []DocLog {
 	lc.mu.Lock()
 	defer lc.mu.Unlock()
@@ -145,11 +231,39 @@ func (lc *LogCollector) SortedByPath() []DocLog {
 
 var logCollector = &LogCollector{}
 
-// deriveTagsFromPath 根据 tagMode 从相对路径推导标签
-// tagMode="last": 只取最后一层目录作为 tag（默认行为）
-// tagMode="all": 取路径的所有层级目录作为 tags
-// deriveTagsFromPath 从相对路径推导标签（取所有层级目录）
-func deriveTagsFromPath(relPath string) []string {
+// formatDocLog 将单篇文档的处理情况格式化为一行汇总
+func formatDocLog(l DocLog) string {
+	status := utils.L("缓存", "cached")
+	if l.DocNew {
+		status = utils.L("新增", "new")
+	} else if l.Skipped {
+		status = utils.L("跳过", "skipped")
+	}
+	if l.Reason != "" {
+		status += " (" + l.Reason + ")"
+	}
+	line := fmt.Sprintf("- %s  [%s]", l.Path, status)
+	if l.ImgCache > 0 || l.ImgNew > 0 {
+		line += fmt.Sprintf(utils.L("  | 图片: +%d / 命中%d", "  | images: +%d / hit %d"), l.ImgNew, l.ImgCache)
+	}
+	return line
+}
+
+// permalinkHashLen permalink 中短 hash 的长度（十六进制字符数）
+const permalinkHashLen = 8
+
+// derivePermalink 根据 docToken 生成稳定的短 permalink，如 /p/1a2b3c4d/
+// 只依赖 docToken，文档改名或移动目录后 permalink 保持不变
+func derivePermalink(docToken string) string {
+	sum := sha1.Sum([]byte(docToken))
+	return "/p/" + fmt.Sprintf("%x", sum)[:permalinkHashLen] + "/"
+}
+
+// deriveTagsFromPath 从相对路径推导标签（取所有层级目录），再按 maxLevels 限制层数：
+// maxLevels > 0: 取外层的前 n 层
+// maxLevels < 0: 取内层的后 n 层
+// maxLevels = 0 或层级不超过 n 时保留全部
+func deriveTagsFromPath(relPath string, maxLevels int) []string {
 	cleanPath := filepath.Clean(relPath)
 	if cleanPath == "." || cleanPath == string(os.PathSeparator) || cleanPath == "" {
 		return nil
@@ -163,6 +277,11 @@ func deriveTagsFromPath(relPath string) []string {
 			tags = append(tags, part)
 		}
 	}
+	if maxLevels > 0 && len(tags) > maxLevels {
+		tags = tags[:maxLevels]
+	} else if maxLevels < 0 && len(tags) > -maxLevels {
+		tags = tags[len(tags)+maxLevels:]
+	}
 	return tags
 }
 
@@ -213,9 +332,34 @@ func deriveCategoryFromPath(relPath string, level int) string {
 	return dirs[index]
 }
 
+// unsupportedDocTypeError 文档类型不是新版文档或电子表格，如知识库节点在枚举后变为思维笔记、多维表格
+type unsupportedDocTypeError struct {
+	docType string
+}
+
+func (e *unsupportedDocTypeError) Error() string {
+	return fmt.Sprintf(utils.L("不支持的文档类型 %q，仅支持新版文档（docx）与电子表格", "unsupported document type %q, only docx documents and spreadsheets are supported"), e.docType)
+}
+
+// skipUnsupportedDoc 文档类型不受支持时记录为跳过并返回 true，批量下载据此继续处理其余文档
+func skipUnsupportedDoc(path string, err error) bool {
+	var unsupported *unsupportedDocTypeError
+	if !errors.As(err, &unsupported) {
+		return false
+	}
+	logCollector.Add(DocLog{Path: path, Skipped: true, Reason: unsupported.Error()})
+	return true
+}
+
 // downloadDocument 下载单个飞书文档并转换为Markdown
 // 它处理文档验证、内容检索、图片处理和文件输出
 func downloadDocument(ctx context.Context, client *core.Client, url string, opts *DownloadOpts) error {
+	url = utils.NormalizeShareURL(url)
+	// 已被中断则不再开始新的文档
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// 验证URL并提取文档类型和令牌
 	docType, docToken, err := utils.ValidateDocumentURL(url)
 	if err != nil {
@@ -227,9 +371,12 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 	if docType == "wiki" {
 		node, err := client.GetWikiNodeInfo(ctx, docToken)
 		if err != nil {
-			err = fmt.Errorf("GetWikiNodeInfo err: %v for %v", err, url)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// 权限不足等错误交给调用方决定跳过、记录失败还是报错
+			return fmt.Errorf("GetWikiNodeInfo err: %w for %v", err, url)
 		}
-		utils.CheckErr(err)
 		docType = node.ObjType
 		docToken = node.ObjToken
 
@@ -237,7 +384,12 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 		if opts.spaceID != "" {
 			childNodes, err := client.GetChildNodes(ctx, opts.spaceID, node.NodeToken)
 			if err == nil && len(childNodes) > 0 {
-				fmt.Printf("⏭️  跳过有子节点的文档: %s\n", node.Title)
+				if dlConfig.Output.DryRun {
+					dirPath := filepath.Join(opts.outputDir, utils.SanitizeFileName(node.Title))
+					dryRunCollector.Add(reportPath(dirPath), docType, dryRunHasChildren)
+					return nil
+				}
+				fmt.Printf(utils.L("⏭️  跳过有子节点的文档: %s\n", "⏭️  Skipping document with children: %s\n"), node.Title)
 				return nil
 			}
 		}
@@ -247,26 +399,108 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 			`不再支持飞书文档。` +
 				`请参考Readme/Release获取v1_support信息。`)
 	}
+	if docType == "sheet" || docType == "sheets" {
+		return downloadSheet(ctx, client, docToken, opts)
+	}
+	if docType != "docx" {
+		return &unsupportedDocTypeError{docType: docType}
+	}
 
 	// 处理下载：先快速获取文档元信息（包含 RevisionID），用于命中跳过
 	meta, err := client.GetDocxDocumentMeta(ctx, docToken)
-	utils.CheckErr(err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("GetDocxDocumentMeta err: %w for %v", err, url)
+	}
 
 	// 如果开启跳过重复，并且本地存在同名 md 文件，同时可读取历史 RevisionID，且一致，则直接跳过
 	// 仅在使用标题作为文件名时，文件名依赖 meta.Title；否则用 token
-	mdName := fmt.Sprintf("%s.md", docToken)
-	if dlConfig.Output.TitleAsFilename {
-		mdName = fmt.Sprintf("%s.md", utils.SanitizeFileName(meta.Title))
-	}
+	// --html 导出完整 HTML 页面，其余情况输出 Markdown
+	mdName := outputFileName(meta.Title, docToken, opts)
 	outputPath := filepath.Join(opts.outputDir, mdName)
 
+	// 预览模式只比对版本，不拉取内容也不写盘
+	if dlConfig.Output.DryRun {
+		dryRunCollector.Add(reportPath(outputPath), "docx", classifyDryRun(outputPath, docToken, meta.RevisionID, opts))
+		return nil
+	}
+
+	// 版本未变时直接跳过，无需拉取内容与图片
+	if opts.skipDuplicate && !opts.forceDownload && revisionCache.Unchanged(outputPath, docToken, meta.RevisionID) {
+		if dlConfig.Output.SitemapBaseURL != "" {
+			sitemapCollector.Add(filepath.Join(opts.relDir, mdName), time.Time{})
+		}
+		return nil
+	}
+
+	// 增量下载：阈值之前未改动的文档直接跳过
+	if unchangedSince(ctx, client, docToken, "docx", mdName, opts) {
+		return nil
+	}
+
 	// 未命中快速跳过，拉取块内容
 	docx, blocks, err := client.GetDocxContent(ctx, docToken)
-	utils.CheckErr(err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("GetDocxContent err: %w for %v", err, url)
+	}
+
+	if dlConfig.Output.ReportPath != "" {
+		reportCollector.Add(reportEntry{
+			Path:     reportPath(outputPath),
+			DocToken: docToken,
+			Title:    meta.Title,
+			DocStats: core.CountDocxStats(docx, blocks),
+		})
+	}
+
+	// 空壳文档（仅标题、无正文）不生成 md；其子文档仍会按层级建目录
+	if dlConfig.Output.SkipEmpty && core.IsEmptyDocx(docx, blocks) {
+		pathForLog := mdName
+		if opts.relDir != "" {
+			pathForLog = filepath.Join(opts.relDir, mdName)
+		}
+		logCollector.Add(DocLog{Path: pathForLog, Skipped: true, Reason: utils.L("空文档", "empty")})
+		return nil
+	}
 
 	parser := core.NewParser(dlConfig.Output)
 
-	markdown := parser.ParseDocxContent(docx, blocks)
+	var markdown string
+	if err := recoverAsError(func() { markdown = parser.ParseDocxContent(docx, blocks) }); err != nil {
+		return degradeToJSON(docToken, mdName, opts, docx, blocks, err)
+	}
+
+	// 构建 frontmatter（MDX/YAML），HTML 导出时元信息改为写入 <head>
+	// 先于图片处理获取，图片按月归档依赖文档修改时间
+	fm := buildFrontmatter(ctx, client, docToken, "docx", meta.Title, opts)
+
+	// 图片按月归档：本地目录与图床路径都追加 YYYY/MM
+	imageDir := dlConfig.Output.ImageDir
+	keyPrefix := ""
+	if dlConfig.Output.ImageByMonth {
+		keyPrefix = imageMonthDir(fm.updatedAt)
+		imageDir = filepath.Join(imageDir, filepath.FromSlash(keyPrefix))
+	}
+	// 按文档划分图床目录：本文档的图片上传到 <docToken>/ 下，处理完即清理派生的临时配置
+	// 按月归档的派生配置由同月文档共用，结束时随缓存一并清理
+	if dlConfig.PicGo.DocKeyPrefix {
+		keyPrefix = path.Join(keyPrefix, docToken)
+		defer picgo.ReleaseKeyPrefix(keyPrefix)
+	}
+	uploadCtx := ctx
+	if keyPrefix != "" {
+		uploadCtx = picgo.WithKeyPrefix(ctx, keyPrefix)
+	}
+
+	// 外链图片本地化需在飞书图片替换之前进行，避免把图床 URL 当作外链再次下载
+	if dlConfig.Output.DownloadExternalImages && !dlConfig.Output.SkipImgDownload {
+		markdown = localizeExternalImages(uploadCtx, client, markdown, opts.outputDir, imageDir)
+	}
 
 	if !dlConfig.Output.SkipImgDownload && len(parser.ImgTokens) > 0 {
 		// 对图片 token 去重，避免重复下载
@@ -284,17 +518,21 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 		picgoEnabled := dlConfig.PicGo.Enabled && picgo.IsAvailable()
 
 		// 控制单文档内图片下载并发度
-		maxImgConcurrency := 16
+		maxImgConcurrency := imgConcurrency()
 		type result struct {
 			token, link string
-			fromCache   bool // 是否从缓存获取
-			needUpload  bool // 是否需要上传到 PicGo
+			latex       string // 公式图片识别出的 LaTeX，非空时替换整张图片
+			fromCache   bool   // 是否从缓存获取
+			needUpload  bool   // 是否需要上传到 PicGo
 			err         error
 		}
+		// 启用图床时边下边传：每张图片下载完成即投递上传，而不是全部下载完再批量上传
+		var uploader *picgo.Pipeline
+		if picgoEnabled {
+			uploader = picgo.NewPipeline(uploadCtx)
+		}
 		jobs := make(chan string)
 		results := make(chan result, len(uniqueTokens))
-		outImgDir := filepath.Join(opts.outputDir, dlConfig.Output.ImageDir)
-
 		worker := func() {
 			for token := range jobs {
 				// 1. 检查 PicGo 缓存
@@ -306,14 +544,21 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 				}
 
 				// 2. 从飞书下载图片
-				localLink, err := client.DownloadImage(ctx, token, outImgDir)
+				localLink, err := client.DownloadImage(ctx, token, opts.outputDir, imageDir)
 				if err != nil {
 					results <- result{token: token, link: "", fromCache: false, needUpload: false, err: err}
 					continue
 				}
 
-				// 3. 下载成功，如果启用了 PicGo，标记需要上传
+				// 3. 疑似公式的图片先尝试识别为 LaTeX，成功则不再保留或上传图片
+				if latex := recognizeFormulaImage(ctx, parser, token, filepath.Join(opts.outputDir, localLink)); latex != "" {
+					results <- result{token: token, link: localLink, latex: latex}
+					continue
+				}
+
+				// 4. 下载成功，如果启用了 PicGo，立即投递上传并标记
 				if picgoEnabled {
+					uploader.Submit(filepath.Join(opts.outputDir, localLink))
 					results <- result{token: token, link: localLink, fromCache: false, needUpload: true, err: nil}
 				} else {
 					// 未启用 PicGo，使用本地路径
@@ -332,41 +577,59 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 		// 收集结果
 		successCount := 0
 		cacheHitCount := 0
+		newCount := 0 // 本次从飞书下载（未命中图床缓存）的图片数，与是否上传图床无关
 		tokenToLink := make(map[string]string, len(uniqueTokens))
 		needUploadImages := make(map[string]string) // token -> localLink
+		tokenToLatex := make(map[string]string)
+		digests := make(map[string]imageDigest) // 图片清单用，须在上传后删除本地文件之前计算
 
 		for i := 0; i < len(uniqueTokens); i++ {
 			r := <-results
 			if r.err != nil {
-				fmt.Printf("⚠️  图片下载失败: %v\n", r.err)
+				// 权限不足的图片每张原因相同，不逐张输出，结束时汇总为一条提示
+				denied := errors.Is(r.err, core.ErrImageForbidden)
+				if !denied {
+					fmt.Printf(utils.L("⚠️  图片下载失败: %v\n", "⚠️  Image download failed: %v\n"), r.err)
+				}
+				dlStats.AddImageFailure(denied)
 				continue
 			}
-			tokenToLink[r.token] = r.link
 			successCount++
+			if r.latex != "" {
+				tokenToLatex[r.token] = r.latex
+				continue
+			}
+			tokenToLink[r.token] = r.link
+			if dlConfig.Output.ImagesManifest && !r.fromCache {
+				digests[r.token] = digestImageFile(filepath.Join(opts.outputDir, r.link))
+			}
 
 			if r.fromCache {
 				cacheHitCount++
-			} else if r.needUpload {
+				continue
+			}
+			newCount++
+			if r.needUpload {
 				needUploadImages[r.token] = r.link
 			}
 		}
 
+		// 收齐全部下载结果时所有图片均已投递，等待仍在进行的上传完成
+		var picgoURLs map[string]string
+		if uploader != nil {
+			picgoURLs = uploader.Wait()
+		}
+
 		// 处理需要上传的图片
 		if successCount > 0 {
 			if picgoEnabled && len(needUploadImages) > 0 {
-				// 收集需要上传的图片路径
-				localPaths := make([]string, 0, len(needUploadImages))
 				tokenByPath := make(map[string]string, len(needUploadImages))
 				for token, link := range needUploadImages {
-					fullPath := filepath.Join(opts.outputDir, link)
-					localPaths = append(localPaths, fullPath)
-					tokenByPath[fullPath] = token
+					tokenByPath[filepath.Join(opts.outputDir, link)] = token
 				}
 
-				// 批量上传到 PicGo（内部已处理缓存保存）
-				picgoURLs := picgo.BatchUpload(ctx, localPaths)
-
 				// 替换 tokenToLink 中的链接为 PicGo URL，并删除已上传的本地文件
+				// 重试后仍上传失败的图片保留本地文件，tokenToLink 中维持本地相对路径
 				for fullPath, picgoURL := range picgoURLs {
 					token := tokenByPath[fullPath]
 					tokenToLink[token] = picgoURL
@@ -375,105 +638,72 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 					os.Remove(fullPath)
 				}
 
-				// 尝试删除空的图片目录
-				imgDir := filepath.Join(opts.outputDir, dlConfig.Output.ImageDir)
-				if entries, err := os.ReadDir(imgDir); err == nil && len(entries) == 0 {
-					os.Remove(imgDir)
-				}
+				// 尝试删除空的图片目录（含按月归档的子目录）
+				removeEmptyImageDirs(opts.outputDir, imageDir)
 			}
 
-			// 替换 markdown 中的 token 为最终链接
-			for token, link := range tokenToLink {
-				markdown = strings.ReplaceAll(markdown, token, link)
+			// 识别为公式的图片整体替换为行间公式
+			for token, latex := range tokenToLatex {
+				markdown = strings.ReplaceAll(markdown, fmt.Sprintf("![](%s)", core.ImagePlaceholder(token)), core.FormulaMarkdown(latex))
+			}
+			// 替换 markdown 中的图片占位为最终链接
+			markdown = core.ReplaceImagePlaceholders(markdown, tokenToLink)
+			if dlConfig.Output.ImagesManifest {
+				imageManifest.AddDoc(outputPath, docToken, opts.outputDir, tokenToLink, digests)
 			}
 
-			if dlStats != nil {
-				downloaded := len(needUploadImages)
-				dlStats.AddImages(len(uniqueTokens), downloaded)
-				pathForLog := mdName
-				if opts.relDir != "" {
-					pathForLog = filepath.Join(opts.relDir, mdName)
-				}
-				logCollector.Add(DocLog{Path: pathForLog, ImgCache: cacheHitCount, ImgNew: downloaded})
+			// HTML 模式下为仍在本地的图片生成多尺寸缩略图，补充 srcset 属性
+			if dlConfig.Output.UseHTMLTags && len(dlConfig.Output.SrcsetWidths) > 0 {
+				markdown = addSrcset(markdown, tokenToLink, opts.outputDir)
+			}
+
+			dlStats.AddImages(len(uniqueTokens), newCount)
+			pathForLog := mdName
+			if opts.relDir != "" {
+				pathForLog = filepath.Join(opts.relDir, mdName)
 			}
+			logCollector.Add(DocLog{Path: pathForLog, ImgCache: cacheHitCount, ImgNew: newCount})
 		}
 	}
+	// 未下载或下载失败的图片还原为原始 token
+	markdown = core.ReplaceImagePlaceholders(markdown, nil)
+
+	// 评论章节追加在正文末尾，随正文一起格式化
+	if dlConfig.Output.WithComments {
+		markdown += docCommentsSection(ctx, client, docToken)
+	}
 
 	// Format the markdown document
-	engine := lute.New(func(l *lute.Lute) {
-		l.RenderOptions.AutoSpace = true
-	})
-	result := engine.FormatStr("md", markdown)
-
-	// 构建 frontmatter（MDX/YAML）
-	// 标题
-	fmTitle := meta.Title
-	// 获取时间元数据
-	var fmDate, fmUpdated string
-	if createdAt, updatedAt, terr := client.GetDocxTimes(ctx, docToken); terr == nil {
-		// 固定东八区 +08:00
-		loc, _ := time.LoadLocation("Asia/Shanghai")
-		if createdAt != nil {
-			fmDate = createdAt.In(loc).Format("2006-01-02T15:04:05-07:00")
-		}
-		if updatedAt != nil {
-			fmUpdated = updatedAt.In(loc).Format("2006-01-02T15:04:05-07:00")
-		}
+	engine := newLuteEngine()
+	var result string
+	if err := recoverAsError(func() { result = engine.FormatStr("md", markdown) }); err != nil {
+		return degradeToJSON(docToken, mdName, opts, docx, blocks, err)
 	}
-	// 兜底：若时间缺失，使用当前时间
-	if fmDate == "" || fmUpdated == "" {
-		now := time.Now().In(time.FixedZone("CST-8", 8*3600))
-		if fmDate == "" {
-			fmDate = now.Format("2006-01-02T15:04:05-07:00")
-		}
-		if fmUpdated == "" {
-			fmUpdated = now.Format("2006-01-02T15:04:05-07:00")
-		}
+	result = core.CollapseBlankLines(result)
+	if dlConfig.Output.LintFix {
+		result = core.LintFixMarkdown(result)
 	}
-	// YAML 转义标题中的冒号等
-	escapeYAML := func(s string) string {
-		// 简单处理：若包含特殊字符，则使用双引号并转义
-		special := ":-#{}[],&*?|\"<>=!%@`) \\" // 包含引号、反斜线与常见特殊字符
-		if strings.ContainsAny(s, special) {
-			// 转义双引号与反斜线
-			s = strings.ReplaceAll(s, "\\", "\\\\")
-			s = strings.ReplaceAll(s, "\"", "\\\"")
-			return "\"" + s + "\""
-		}
-		return s
+	// 按顶层分割线拆分章节：第一章写入原文件，其余章节写入 <文件名>-2.md 等文件
+	var chapters []string
+	if dlConfig.Output.SplitOnDivider {
+		chapters = core.SplitPages(result)
+		result, chapters = chapters[0], chapters[1:]
 	}
-	var fmBuilder strings.Builder
-	fmBuilder.WriteString("---\n")
-	fmBuilder.WriteString("title: " + escapeYAML(fmTitle) + "\n")
-	fmBuilder.WriteString("date: " + fmDate + "\n")
-	fmBuilder.WriteString("updated: " + fmUpdated + "\n")
 
-	// categories: 使用提供的 category，或取 tags 第一个，或使用默认分类
-	fmCategory := opts.category
-	if fmCategory == "" && len(opts.tags) > 0 {
-		fmCategory = opts.tags[0] // 使用第一个 tag 作为 category
-	}
-	if fmCategory == "" {
-		fmCategory = "未分类" // 默认分类
-	}
-	fmBuilder.WriteString("categories: " + escapeYAML(fmCategory) + "\n")
+	result = fm.apply(engine, result)
+	docUpdatedAt := fm.updatedAt
 
-	// tags: 输出标签列表
-	if len(opts.tags) > 0 {
-		fmBuilder.WriteString("tags:\n")
-		for _, tag := range opts.tags {
-			if strings.TrimSpace(tag) == "" {
-				continue
+	// 用户自定义后处理钩子
+	if dlConfig.Output.PostProcessCmd != "" {
+		processed, err := runPostProcess(ctx, dlConfig.Output.PostProcessCmd, result, docToken, outputPath)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
-			fmBuilder.WriteString("  - " + escapeYAML(tag) + "\n")
+			return degradeToJSON(docToken, mdName, opts, docx, blocks, fmt.Errorf("%s: %w", mdName, err))
 		}
+		result = processed
 	}
-	// id: 使用 docToken 作为唯一标识
-	fmBuilder.WriteString("id: " + escapeYAML(docToken) + "\n")
-	fmBuilder.WriteString("---\n\n")
-
-	// 合并 frontmatter 与正文
-	result = fmBuilder.String() + result
 
 	// 处理输出目录和名称
 	if _, err := os.Stat(opts.outputDir); os.IsNotExist(err) {
@@ -485,53 +715,248 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 	if opts.dumpJSON {
 		jsonName := fmt.Sprintf("%s.json", docToken)
 		jsonOutputPath := filepath.Join(opts.outputDir, jsonName)
-		data := struct {
-			Document *lark.DocxDocument `json:"document"`
-			Blocks   []*lark.DocxBlock  `json:"blocks"`
-		}{
-			Document: docx,
-			Blocks:   blocks,
-		}
-		pdata := utils.PrettyPrint(data)
+		pdata := utils.PrettyPrint(docxDump{Document: docx, Blocks: blocks})
 
 		// 检查JSON文件是否需要跳过
 		if !opts.forceDownload && shouldSkipFile(jsonOutputPath, pdata, opts.skipDuplicate) {
-			fmt.Printf("⏭️  跳过重复JSON: %s\n", jsonName)
+			fmt.Printf(utils.L("⏭️  跳过重复JSON: %s\n", "⏭️  Skipping duplicate JSON: %s\n"), jsonName)
 		} else {
-			if err = os.WriteFile(jsonOutputPath, []byte(pdata), 0o644); err != nil {
+			if err = utils.WriteFileAtomic(jsonOutputPath, []byte(pdata), 0o644); err != nil {
 				return err
 			}
-			fmt.Printf("📄 JSON响应已转储到 %s\n", jsonOutputPath)
+			fmt.Printf(utils.L("📄 JSON响应已转储到 %s\n", "📄 JSON response dumped to %s\n"), jsonOutputPath)
 		}
 	}
 
+	// 记录站点地图条目（跳过写入的文档同样需要出现在站点地图中）
+	if dlConfig.Output.SitemapBaseURL != "" {
+		sitemapCollector.Add(filepath.Join(opts.relDir, mdName), docUpdatedAt)
+	}
+
+	if err := writeChapters(ctx, engine, fm, chapters, outputPath, docToken, opts); err != nil {
+		return err
+	}
+
 	// 写入markdown文件
 
 	// 检查是否需要跳过重复文件
 	if !opts.forceDownload && shouldSkipFile(outputPath, result, opts.skipDuplicate) {
 		// 静默跳过，不输出日志
+		syncFileModTime(outputPath, docUpdatedAt)
+		revisionCache.Set(outputPath, docToken, meta.RevisionID)
 		return nil
 	}
 
-	if err = os.WriteFile(outputPath, []byte(result), 0o644); err != nil {
+	if err = utils.WriteFileAtomic(outputPath, []byte(result), 0o644); err != nil {
 		return err
 	}
+	// 让本地文件的修改时间反映飞书文档的修改时间
+	syncFileModTime(outputPath, docUpdatedAt)
+	revisionCache.Set(outputPath, docToken, meta.RevisionID)
 	// 静默完成，不输出日志（在最后统计输出）
-	if dlStats != nil {
-		dlStats.AddDocNew()
-		// 记录文档新增日志（图片统计在前面 AddImages 已做累加）
-		pathForLog := mdName
-		if opts.relDir != "" {
-			pathForLog = filepath.Join(opts.relDir, mdName)
+	dlStats.AddDocNew()
+	// 记录文档新增日志（图片统计在前面 AddImages 已做累加）
+	pathForLog := mdName
+	if opts.relDir != "" {
+		pathForLog = filepath.Join(opts.relDir, mdName)
+	}
+	logCollector.Add(DocLog{Path: pathForLog, DocNew: true})
+
+	return nil
+}
+
+// writeChapters 把拆分出的后续章节依次写入 <文件名>-2.md、<文件名>-3.md …，每章带同样的元信息
+func writeChapters(ctx context.Context, engine *lute.Lute, fm docFrontmatter, chapters []string, outputPath, docToken string, opts *DownloadOpts) error {
+	ext := filepath.Ext(outputPath)
+	stem := strings.TrimSuffix(outputPath, ext)
+	for i, body := range chapters {
+		chapterPath := fmt.Sprintf("%s-%d%s", stem, i+2, ext)
+		content := fm.apply(engine, body)
+		if dlConfig.Output.PostProcessCmd != "" {
+			processed, err := runPostProcess(ctx, dlConfig.Output.PostProcessCmd, content, docToken, chapterPath)
+			if err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(chapterPath), err)
+			}
+			content = processed
+		}
+		if !opts.forceDownload && shouldSkipFile(chapterPath, content, opts.skipDuplicate) {
+			continue
+		}
+		if err := utils.WriteFileAtomic(chapterPath, []byte(content), 0o644); err != nil {
+			return err
 		}
-		logCollector.Add(DocLog{Path: pathForLog, DocNew: true})
+		syncFileModTime(chapterPath, fm.updatedAt)
 	}
+	return nil
+}
+
+// newLuteEngine 按输出配置中的格式化选项创建 lute 引擎
+func newLuteEngine() *lute.Lute {
+	return lute.New(func(l *lute.Lute) {
+		l.RenderOptions.AutoSpace = dlConfig.Output.AutoSpace
+		l.RenderOptions.FixTermTypo = dlConfig.Output.FixTermTypo
+		if l.RenderOptions.FixTermTypo {
+			// 术语字典默认为空，需显式载入内置字典
+			l.PutTerms(nil)
+		}
+	})
+}
+
+// outputFileName 按文件名策略生成输出文件名：--html 时为 .html，否则为 .md
+func outputFileName(title, docToken string, opts *DownloadOpts) string {
+	ext := ".md"
+	if dlConfig.Output.UseHTMLTags {
+		ext = ".html"
+	}
+	name := docToken + ext
+	if dlConfig.Output.StripTitleEmoji {
+		title = utils.StripLeadingEmoji(title)
+	}
+	if dlConfig.Output.SlugFilename {
+		// slug 为空（如纯 emoji 标题）时回退到 token
+		if slug := utils.Slugify(title); slug != "" {
+			name = slug + ext
+		}
+	} else if dlConfig.Output.TitleAsFilename {
+		name = utils.SanitizeFileName(title) + ext
+	}
+	if opts.fileNames != nil {
+		name = opts.fileNames.Reserve(name, docToken)
+	} else {
+		name = dirFileNames.ReserveInDir(opts.outputDir, name, docToken)
+	}
+	return name
+}
+
+// plannedDoc 枚举到的一篇待下载文档
+type plannedDoc struct {
+	title string
+	token string // 文档（而非知识库节点）的 token，与下载时分配文件名所用的一致
+	opts  *DownloadOpts
+}
 
+// reserveFileNames 按 token 排序后为同一目录下枚举到的文档预先分配文件名，
+// 使重名文档的 -1、-2 后缀不受并发下载完成顺序的影响；下载时标题未变则分配到相同的文件名
+func reserveFileNames(docs []plannedDoc) {
+	sort.Slice(docs, func(i, j int) bool { return docs[i].token < docs[j].token })
+	for _, d := range docs {
+		outputFileName(d.title, d.token, d.opts)
+	}
+}
+
+// failedDirName 转换失败的文档降级保存原始 JSON 的目录（位于输出根目录下）
+const failedDirName = "failed"
+
+// docxDump 文档原始 JSON 的转储结构，--json 与失败降级共用
+type docxDump struct {
+	Document *lark.DocxDocument `json:"document"`
+	Blocks   []*lark.DocxBlock  `json:"blocks"`
+}
+
+// recognizeFormulaImage 启用公式识别时把疑似公式的图片转为 LaTeX，成功后删除本地图片；
+// 未启用、不是候选、识别失败或服务判定不是公式时返回空字符串，保留图片
+func recognizeFormulaImage(ctx context.Context, parser *core.Parser, token, localPath string) string {
+	endpoint := dlConfig.Output.FormulaOCRURL
+	if endpoint == "" || !parser.FormulaImgTokens[token] {
+		return ""
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return ""
+	}
+	latex, err := core.RecognizeFormula(ctx, endpoint, data)
+	if err != nil {
+		fmt.Printf(utils.L("⚠️  公式图片识别失败，保留图片: %v\n", "⚠️  Formula image recognition failed, keeping the image: %v\n"), err)
+		return ""
+	}
+	if latex != "" {
+		os.Remove(localPath)
+	}
+	return latex
+}
+
+// imageMonthDir 返回图片按月归档的子目录 YYYY/MM（输出时区），修改时间未知时按当前时间
+func imageMonthDir(updatedAt time.Time) string {
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	return updatedAt.In(outputLocation).Format("2006/01")
+}
+
+// removeEmptyImageDirs 图片全部上传图床后，自 imageDir 起逐级删除空目录，
+// 直到配置的图片根目录为止
+func removeEmptyImageDirs(outputDir, imageDir string) {
+	root := filepath.Join(outputDir, dlConfig.Output.ImageDir)
+	dir := filepath.Join(outputDir, imageDir)
+	for {
+		if entries, err := os.ReadDir(dir); err != nil || len(entries) > 0 {
+			return
+		}
+		os.Remove(dir)
+		if dir == root || !strings.HasPrefix(dir, root) {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// recoverAsError 执行 fn，并把其中的 panic 转为 error 返回
+func recoverAsError(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// degradeToJSON Markdown 转换失败时把原始块 JSON 保存到 failed/ 目录，便于之后修复重跑
+// 保存成功视为已降级处理，不中断其余文档的下载；保存也失败时才返回错误
+func degradeToJSON(docToken, mdName string, opts *DownloadOpts, docx *lark.DocxDocument, blocks []*lark.DocxBlock, cause error) error {
+	failedDir := filepath.Join(dlConfig.Output.OutputDir, failedDirName)
+	if err := os.MkdirAll(failedDir, 0o755); err != nil {
+		return fmt.Errorf(utils.L("%s 转换失败: %v；且无法创建 %s: %v", "%s conversion failed: %v; and could not create %s: %v"), mdName, cause, failedDir, err)
+	}
+	jsonPath := filepath.Join(failedDir, docToken+".json")
+	if err := utils.WriteFileAtomic(jsonPath, []byte(utils.PrettyPrint(docxDump{Document: docx, Blocks: blocks})), 0o644); err != nil {
+		return fmt.Errorf(utils.L("%s 转换失败: %v；且保存原始 JSON 失败: %v", "%s conversion failed: %v; and saving raw JSON failed: %v"), mdName, cause, err)
+	}
+
+	pathForLog := mdName
+	if opts.relDir != "" {
+		pathForLog = filepath.Join(opts.relDir, mdName)
+	}
+	logCollector.Add(DocLog{Path: pathForLog, Skipped: true, Reason: utils.L("转换失败，已保存 JSON", "conversion failed, JSON saved")})
+	fmt.Printf(utils.L("⚠️  %s 转换失败（%v），原始 JSON 已保存到 %s\n", "⚠️  %s conversion failed (%v), raw JSON saved to %s\n"), mdName, cause, jsonPath)
 	return nil
 }
 
+// addSrcset 为 <img> 引用的本地图片生成缩略图，并把 srcset 属性写入对应标签
+// 已上传图床的图片（链接不是相对路径）保持不变
+func addSrcset(markdown string, tokenToLink map[string]string, docDir string) string {
+	for _, link := range tokenToLink {
+		if !strings.HasPrefix(link, "./") {
+			continue
+		}
+		srcset, err := core.GenerateSrcset(docDir, link, dlConfig.Output.SrcsetWidths)
+		if err != nil {
+			fmt.Printf(utils.L("⚠️  生成缩略图失败: %v\n", "⚠️  Failed to generate thumbnails: %v\n"), err)
+			continue
+		}
+		if srcset == "" {
+			continue
+		}
+		markdown = strings.ReplaceAll(markdown,
+			fmt.Sprintf("<img src=\"%s\" />", link),
+			fmt.Sprintf("<img src=\"%s\" srcset=\"%s\" />", link, srcset))
+	}
+	return markdown
+}
+
 // downloadDocuments 下载文件夹中的所有文档
 func downloadDocuments(ctx context.Context, client *core.Client, url string, opts *DownloadOpts) error {
+	startTime := time.Now()
 	// 验证要下载的URL
 	folderToken, err := utils.ValidateFolderURL(url)
 	if err != nil {
@@ -539,15 +964,32 @@ func downloadDocuments(ctx context.Context, client *core.Client, url string, opt
 	}
 	// 移除冗余的令牌输出
 
-	// 错误通道和等待组
-	errChan := make(chan error)
-	wg := sync.WaitGroup{}
+	// 大文件夹一次性并发全部文档会打满飞书限流，按 --doc-concurrency 限制
+	g, gctx, cancel := newDownloadGroup(ctx, docConcurrency())
+	defer cancel()
+
+	// 单篇文档失败不中断其余下载，全部结束后统一报告
+	failures := &FailureCollector{}
+
+	// 已访问的文件夹 token：快捷方式可能指向祖先文件夹形成环，重复出现时不再进入
+	// processFolder 的递归是串行的，无需加锁
+	visited := make(map[string]bool)
 
 	// 递归遍历文件夹并下载文档
 	var processFolder func(ctx context.Context, folderPath, folderToken string) error
 	processFolder = func(ctx context.Context, folderPath, folderToken string) error {
+		if visited[folderToken] {
+			fmt.Printf(utils.L("⏭️  跳过已访问的文件夹（快捷方式成环）: %s\n", "⏭️  Skipping already visited folder (shortcut cycle): %s\n"), folderPath)
+			return nil
+		}
+		visited[folderToken] = true
+
 		files, err := client.GetDriveFolderFileList(ctx, nil, &folderToken)
 		if err != nil {
+			// 无权限的子文件夹记录后跳过；根文件夹无权限时没有可导出的内容，直接报错
+			if folderPath != opts.outputDir && permissionCollector.Add(reportPath(folderPath), folderToken, err) {
+				return nil
+			}
 			return err
 		}
 		localOpts := DownloadOpts{
@@ -558,61 +1000,94 @@ func downloadDocuments(ctx context.Context, client *core.Client, url string, opt
 			spaceID:       opts.spaceID,
 			nodeToken:     opts.nodeToken,
 		}
+		var planned []plannedDoc
 		for _, file := range files {
-			switch file.Type {
+			if file.Type == "shortcut" && file.ShortcutInfo != nil {
+				if t := file.ShortcutInfo.TargetType; t == "docx" || t == "sheet" {
+					planned = append(planned, plannedDoc{file.Name, file.ShortcutInfo.TargetToken, &localOpts})
+				}
+			} else if file.Type == "docx" || file.Type == "sheet" {
+				planned = append(planned, plannedDoc{file.Name, file.Token, &localOpts})
+			}
+		}
+		reserveFileNames(planned)
+		for _, file := range files {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// 快捷方式按其指向的原文件处理
+			fileType, fileToken, fileURL := file.Type, file.Token, file.URL
+			if fileType == "shortcut" && file.ShortcutInfo != nil {
+				fileType, fileToken = file.ShortcutInfo.TargetType, file.ShortcutInfo.TargetToken
+				fileURL = utils.TokenToURL(fileToken, fileType)
+			}
+			switch fileType {
 			case "folder":
 				_folderPath := filepath.Join(folderPath, file.Name)
-				if err := processFolder(ctx, _folderPath, file.Token); err != nil {
+				if err := processFolder(ctx, _folderPath, fileToken); err != nil {
 					return err
 				}
-			case "docx":
-				// 并发下载文档
-				wg.Add(1)
-				go func(_url string) {
-					if err := downloadDocument(ctx, client, _url, &localOpts); err != nil {
-						errChan <- err
+			case "docx", "sheet":
+				// 并发下载文档与电子表格，无权限的记录后跳过，其他失败记录后继续下载其余文档
+				_url, _path, _token := fileURL, reportPath(filepath.Join(folderPath, file.Name)), fileToken
+				dlStats.AddTotalDocs(1)
+				g.Go(func() error {
+					err := downloadDocument(ctx, client, _url, &localOpts)
+					if err == nil || permissionCollector.Add(_path, _token, err) || skipUnsupportedDoc(_path, err) {
+						return nil
+					}
+					// 中断时直接返回，取消其余下载
+					if ctx.Err() != nil {
+						return err
 					}
-					wg.Done()
-				}(file.URL)
+					failures.Add(_path, err)
+					return nil
+				})
 			}
 		}
 		return nil
 	}
-	if err := processFolder(ctx, opts.outputDir, folderToken); err != nil {
-		return err
-	}
+	// 处理结果按完成顺序即时输出
+	logCollector.SetStream(true)
+	defer logCollector.SetStream(false)
+	fmt.Println(utils.L("📦 处理结果：", "📦 Results:"))
 
-	// Wait for all the downloads to finish
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
-	for err := range errChan {
+	walkErr := processFolder(gctx, opts.outputDir, folderToken)
+	if err := waitDownloadGroup(g, cancel, walkErr); err != nil {
 		return err
 	}
-	return nil
+	// 与 wiki-tree 一致：没有可下载的文档时不输出汇总
+	if totalDocs, _, _, _ := dlStats.Snapshot(); totalDocs == 0 {
+		fmt.Println(utils.L("📭 文件夹中没有可下载的文档", "📭 No downloadable documents in the folder"))
+		return nil
+	}
+	printDownloadSummary(time.Since(startTime))
+	printFailures(failures)
+	return failures.Err()
 }
 
 // downloadWiki 下载知识库中的所有文档
 func downloadWiki(ctx context.Context, client *core.Client, url string, opts *DownloadOpts) error {
+	startTime := time.Now()
 	prefixURL, spaceID, err := utils.ValidateWikiURL(url)
 	if err != nil {
 		return err
 	}
 
-	folderPath, err := client.GetWikiName(ctx, spaceID)
+	wikiName, err := client.GetWikiName(ctx, spaceID)
 	if err != nil {
 		return err
 	}
-	if folderPath == "" {
+	if wikiName == "" {
 		return fmt.Errorf("failed to GetWikiName")
 	}
 
-	errChan := make(chan error)
+	// 知识库以其名称为目录保存在输出目录（--output / OUTPUT_DIR）下
+	folderPath := filepath.Join(opts.outputDir, utils.SanitizeFileName(wikiName))
+	rootPath := folderPath
 
-	var maxConcurrency = 10 // 设置最大并发级别
-	wg := sync.WaitGroup{}
-	semaphore := make(chan struct{}, maxConcurrency) // 创建具有最大并发级别的信号量
+	g, gctx, cancel := newDownloadGroup(ctx, docConcurrency())
+	defer cancel()
 
 	var downloadWikiNode func(ctx context.Context,
 		client *core.Client,
@@ -629,15 +1104,30 @@ func downloadWiki(ctx context.Context, client *core.Client, url string, opts *Do
 		if err != nil {
 			return err
 		}
+		var planned []plannedDoc
 		for _, n := range nodes {
+			if n.ObjType == "docx" || n.ObjType == "sheet" {
+				planned = append(planned, plannedDoc{n.Title, n.ObjToken, &DownloadOpts{outputDir: folderPath}})
+			}
+		}
+		reserveFileNames(planned)
+		for _, n := range nodes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			if n.HasChild {
-				_folderPath := filepath.Join(folderPath, n.Title)
+				// 标题中的路径分隔符等字符会逃出输出目录，同名的兄弟节点分配到不同目录
+				_folderPath := dirFileNames.ReserveDir(folderPath, utils.SanitizeFileName(n.Title), n.NodeToken)
 				if err := downloadWikiNode(ctx, client,
 					spaceID, _folderPath, &n.NodeToken); err != nil {
-					return err
+					// 更深层的无权限节点已在其所在层跳过，这里的权限错误只可能来自 n 本身
+					if !permissionCollector.Add(_folderPath, n.NodeToken, err) {
+						return err
+					}
 				}
 			}
-			if n.ObjType == "docx" {
+			if n.ObjType == "docx" || n.ObjType == "sheet" {
+				relDir, _ := filepath.Rel(rootPath, folderPath)
 				wikiOpts := DownloadOpts{
 					outputDir:     folderPath,
 					dumpJSON:      opts.dumpJSON,
@@ -645,39 +1135,71 @@ func downloadWiki(ctx context.Context, client *core.Client, url string, opts *Do
 					forceDownload: opts.forceDownload,
 					spaceID:       spaceID,
 					nodeToken:     n.NodeToken,
+					relDir:        relDir,
 				}
-				wg.Add(1)
-				semaphore <- struct{}{}
-				go func(_url string) {
-					if err := downloadDocument(ctx, client, _url, &wikiOpts); err != nil {
-						errChan <- err
+				_url, _path, _token := prefixURL+"/wiki/"+n.NodeToken, filepath.Join(folderPath, n.Title), n.NodeToken
+				dlStats.AddTotalDocs(1)
+				g.Go(func() error {
+					err := downloadDocument(ctx, client, _url, &wikiOpts)
+					if err != nil && (permissionCollector.Add(_path, _token, err) || skipUnsupportedDoc(_path, err)) {
+						return nil
 					}
-					wg.Done()
-					<-semaphore
-				}(prefixURL + "/wiki/" + n.NodeToken)
+					return err
+				})
 			}
 		}
 		return nil
 	}
 
-	if err = downloadWikiNode(ctx, client, spaceID, folderPath, nil); err != nil {
+	// 处理结果按完成顺序即时输出
+	logCollector.SetStream(true)
+	defer logCollector.SetStream(false)
+	fmt.Println(utils.L("📦 处理结果：", "📦 Results:"))
+
+	walkErr := downloadWikiNode(gctx, client, spaceID, folderPath, nil)
+	if err := waitDownloadGroup(g, cancel, walkErr); err != nil {
 		return err
 	}
 
-	// Wait for all the downloads to finish
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
-	for err := range errChan {
-		return err
+	if err := writeSitemap(rootPath, dlConfig.Output.SitemapBaseURL); err != nil {
+		return fmt.Errorf(utils.L("生成站点地图失败: %w", "failed to generate sitemap: %w"), err)
 	}
+	printDownloadSummary(time.Since(startTime))
 	return nil
 }
 
+// newDownloadGroup 创建文档下载任务组：limit > 0 时限制同时进行的下载数（Go 在已满时阻塞），
+// 任一任务返回错误即取消 gctx，使其余任务与枚举尽快结束；cancel 供枚举出错时主动取消
+func newDownloadGroup(ctx context.Context, limit int) (g *errgroup.Group, gctx context.Context, cancel context.CancelFunc) {
+	ctx, cancel = context.WithCancel(ctx)
+	g, gctx = errgroup.WithContext(ctx)
+	if limit > 0 {
+		g.SetLimit(limit)
+	}
+	return g, gctx, cancel
+}
+
+// waitDownloadGroup 等待任务组中的下载全部结束，返回最能说明失败原因的错误：
+// 枚举自身出错（而非被取消）时取消其余下载并返回枚举错误，否则返回第一个失败任务的错误
+func waitDownloadGroup(g *errgroup.Group, cancel context.CancelFunc, walkErr error) error {
+	if walkErr != nil && !errors.Is(walkErr, context.Canceled) {
+		cancel()
+		g.Wait()
+		return walkErr
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return walkErr
+}
+
+// errStopWalk 用于在派发下载时提前结束子节点遍历
+var errStopWalk = errors.New("stop walking child nodes")
+
 // downloadWikiChildren 下载指定知识库文档下的所有子文档
 func downloadWikiChildren(ctx context.Context, client *core.Client, url string, opts *DownloadOpts) error {
 	startTime := time.Now()
+	url = utils.NormalizeShareURL(url)
 
 	// 优先使用配置中的spaceID，然后使用环境变量
 	spaceID := opts.spaceID
@@ -697,10 +1219,14 @@ func downloadWikiChildren(ctx context.Context, client *core.Client, url string,
 	}
 
 	if spaceID == "" {
-		return fmt.Errorf("无法获取知识库spaceID。请通过以下方式提供:\n" +
-			"  1. 环境变量: FEISHU_SPACE_ID (在 .env 文件中配置)\n" +
-			"  2. 使用知识库设置页面URL\n\n" +
-			"提示: 运行 'feishu2md init' 创建配置文件模板")
+		return errors.New(utils.L("无法获取知识库spaceID。请通过以下方式提供:\n"+
+			"  1. 环境变量: FEISHU_SPACE_ID (在 .env 文件中配置)\n"+
+			"  2. 使用知识库设置页面URL\n\n"+
+			"提示: 运行 'feishu2md init' 创建配置文件模板",
+			"Unable to determine the wiki spaceID. Provide it via:\n"+
+				"  1. Environment variable: FEISHU_SPACE_ID (in the .env file)\n"+
+				"  2. A wiki settings page URL\n\n"+
+				"Tip: run 'feishu2md init' to create a config template"))
 	}
 
 	// 如果还没有获取URL前缀，则从URL中提取
@@ -717,102 +1243,150 @@ func downloadWikiChildren(ctx context.Context, client *core.Client, url string,
 	}
 
 	// 如果是wiki类型，需要获取实际的文档信息
+	var rootObjType string
 	if docType == "wiki" {
 		node, err := client.GetWikiNodeInfo(ctx, nodeToken)
 		if err != nil {
 			return fmt.Errorf("GetWikiNodeInfo err: %v for %v", err, url)
 		}
 		nodeToken = node.NodeToken
+		rootObjType = node.ObjType
 	}
 
-	fmt.Printf("🔍 正在获取子文档...\n")
+	fmt.Print(utils.L("🔍 正在获取子文档...\n", "🔍 Fetching child documents...\n"))
 
 	// 可选：先清空输出目录，再按最新树生成，避免重命名/删除导致的旧文件残留
-	if opts.cleanOutput && opts.outputDir != "" {
+	// 续传时保留输出目录，否则进度文件与已下载的文档都会被清掉
+	if dlConfig.Output.DryRun {
+		// 预览模式不清空、不创建输出目录
+	} else if opts.cleanOutput && opts.resume {
+		fmt.Println(utils.L("⚠️  --resume 模式下忽略 --clean-output", "⚠️  --clean-output is ignored with --resume"))
+	} else if opts.cleanOutput && opts.outputDir != "" {
 		if _, err := os.Stat(opts.outputDir); err == nil {
 			if err := os.RemoveAll(opts.outputDir); err != nil {
-				return fmt.Errorf("清空输出目录失败: %w", err)
+				return fmt.Errorf(utils.L("清空输出目录失败: %w", "failed to clean output directory: %w"), err)
 			}
-			fmt.Printf("🧹 已清空输出目录: %s\n", opts.outputDir)
+			fmt.Printf(utils.L("🧹 已清空输出目录: %s\n", "🧹 Cleaned output directory: %s\n"), opts.outputDir)
 		}
 	}
-	if err := os.MkdirAll(opts.outputDir, 0o755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
+	if !dlConfig.Output.DryRun {
+		if err := os.MkdirAll(opts.outputDir, 0o755); err != nil {
+			return fmt.Errorf(utils.L("创建输出目录失败: %w", "failed to create output directory: %w"), err)
+		}
 	}
 
-	// 获取所有子节点
-	allNodes, err := client.GetAllChildNodes(ctx, spaceID, nodeToken)
+	progress, err := openProgress(opts.outputDir, nodeToken, opts.resume)
 	if err != nil {
-		return fmt.Errorf("获取子节点失败: %v", err)
-	}
-
-	if len(allNodes) == 0 {
-		fmt.Println("📭 未找到任何子文档")
-		return nil
+		return err
 	}
-
-	fmt.Printf("📚 找到 %d 个子文档\n", len(allNodes))
-	dlStats = &DownloadStats{}
-	dlStats.SetTotalDocs(len(allNodes))
-
-	// 创建目录结构映射：nodeToken -> 相对路径
-	pathMap := make(map[string]string)
-
-	// 首先为根节点建立路径
-	pathMap[nodeToken] = "."
-
-	// 递归构建路径映射
-	var buildPaths func(parentToken, parentPath string)
-	buildPaths = func(parentToken, parentPath string) {
-		for _, node := range allNodes {
-			if node.ParentToken == parentToken {
-				// 构建当前节点的路径
-				nodePath := filepath.Join(parentPath, utils.SanitizeFileName(node.Name))
-				pathMap[node.NodeToken] = nodePath
-
-				// 如果有子节点，递归处理
-				if node.HasChild {
-					buildPaths(node.NodeToken, nodePath)
-				}
+	finished := false
+	defer func() { progress.Close(finished) }()
+	if !dlConfig.Output.DryRun {
+		progress.SetCheckpoint(opts.checkpoint, func() { saveCheckpoint(progress.Completed()) })
+	}
+	if n := progress.Completed(); n > 0 {
+		fmt.Printf(utils.L("⏩ 从第 %d 篇继续（已完成 %d 篇）\n", "⏩ Resuming from document %d (%d already done)\n"), n+1, n)
+	}
+
+	// flat 布局下所有文档共用一个目录，需要跨目录去重文件名
+	var fileNames *FileNameRegistry
+	if opts.flat {
+		fileNames = NewFileNameRegistry()
+	}
+
+	// 可选：根节点自身也是文档时，下载到输出目录根部
+	if opts.includeSelf && (rootObjType == "docx" || rootObjType == "sheet") {
+		dlStats.AddTotalDocs(1)
+		if !progress.IsDone(nodeToken) {
+			rootOpts := DownloadOpts{
+				outputDir:     opts.outputDir,
+				dumpJSON:      opts.dumpJSON,
+				skipDuplicate: opts.skipDuplicate,
+				forceDownload: opts.forceDownload,
+				nodeToken:     nodeToken,
+				relDir:        ".",
+				categoryLevel: opts.categoryLevel,
+				fileNames:     fileNames,
+			}
+			// 不传 spaceID：根节点必然有子节点，传入会被当作目录节点跳过
+			if err := downloadDocument(ctx, client, prefixURL+"/wiki/"+nodeToken, &rootOpts); err != nil {
+				return fmt.Errorf(utils.L("下载根文档失败: %v", "failed to download root document: %v"), err)
 			}
+			progress.MarkDone(nodeToken)
 		}
+	} else if opts.includeSelf {
+		fmt.Println(utils.L("⚠️  根节点不是 docx 文档或电子表格，--include-self 不生效", "⚠️  Root node is not a docx document or spreadsheet, --include-self has no effect"))
 	}
 
-	buildPaths(nodeToken, ".")
+	// 目录结构映射：有子节点的 nodeToken -> 相对路径
+	// 只记录目录节点，内存占用与目录数而非文档数相关；同名兄弟节点经 dirNames 分配到不同目录
+	pathMap := map[string]string{nodeToken: "."}
+	dirNames := NewFileNameRegistry()
 
 	// 并发下载控制
-	// 提高并发度到20：限流器(100次/分钟+5次/秒)会自动控制API调用速率
-	// 20个并发文档 × 平均3次API调用/文档 = 约60次并发API调用
-	// 限流器会将其平滑到安全范围内
-	var maxConcurrency = 20
-	errChan := make(chan error, len(allNodes))
-	wg := sync.WaitGroup{}
-	semaphore := make(chan struct{}, maxConcurrency)
-
-	// 下载所有文档类型的节点
-	for _, node := range allNodes {
-		if node.Type == "docx" {
-			wg.Add(1)
-			semaphore <- struct{}{}
-
-			go func(n *core.Document) {
-				defer func() {
-					wg.Done()
-					<-semaphore
-				}()
-
-				// 确定文档的输出目录
-				nodePath := pathMap[n.ParentToken]
-				if nodePath == "" {
-					nodePath = "." // 默认到当前目录
-				}
+	// 并发度由 --doc-concurrency 控制（默认 10），限流器(100次/分钟+5次/秒)会自动控制API调用速率
+	// 任一文档失败即取消其余下载，并不再派发新任务
+	g, gctx, cancel := newDownloadGroup(ctx, docConcurrency())
+	defer cancel()
+
+	// 处理结果按完成顺序即时输出，不在内存中累积
+	logCollector.SetStream(true)
+	defer logCollector.SetStream(false)
+	fmt.Println(utils.L("📦 处理结果：", "📦 Results:"))
+
+	// 边枚举边下载：每拿到一批子节点就派发下载，并发已满时阻塞枚举，形成背压
+	foundNodes := 0
+	walkErr := client.WalkChildNodes(gctx, spaceID, nodeToken, func(batch []*core.Document) error {
+		foundNodes += len(batch)
+		dlStats.AddTotalDocs(len(batch))
+
+		// 同一批为同一父节点的直接子节点，先为其中的文档预分配文件名再派发下载
+		var planned []plannedDoc
+		for _, node := range batch {
+			if (node.Type != "docx" && node.Type != "sheet") || progress.IsDone(node.NodeToken) {
+				continue
+			}
+			relDir := pathMap[node.ParentToken]
+			if relDir == "" || opts.flat {
+				relDir = "."
+			}
+			planned = append(planned, plannedDoc{node.Name, node.Token, &DownloadOpts{outputDir: filepath.Join(opts.outputDir, relDir), fileNames: fileNames}})
+		}
+		reserveFileNames(planned)
+
+		for _, node := range batch {
+			// 收到中断或已有下载失败后不再派发新任务，只等待进行中的下载完成
+			if gctx.Err() != nil {
+				return errStopWalk
+			}
+
+			// 确定文档的输出目录
+			nodePath := pathMap[node.ParentToken]
+			if nodePath == "" {
+				nodePath = "." // 默认到当前目录
+			}
+			if node.HasChild {
+				pathMap[node.NodeToken] = dirNames.ReserveDir(nodePath, utils.SanitizeFileName(node.Name), node.NodeToken)
+			}
 
-				fullOutputDir := filepath.Join(opts.outputDir, nodePath)
+			if (node.Type != "docx" && node.Type != "sheet") || progress.IsDone(node.NodeToken) {
+				continue
+			}
 
-				// 创建输出目录
-				if err := os.MkdirAll(fullOutputDir, 0o755); err != nil {
-					errChan <- fmt.Errorf("创建目录失败 %s: %v", fullOutputDir, err)
-					return
+			n, nodePath := node, nodePath
+			g.Go(func() error {
+				// flat 布局：层级仅用于推导 tags/categories，文件统一写到输出目录根部
+				relDir := nodePath
+				if opts.flat {
+					relDir = "."
+				}
+				fullOutputDir := filepath.Join(opts.outputDir, relDir)
+
+				// 创建输出目录（预览模式不创建）
+				if !dlConfig.Output.DryRun {
+					if err := os.MkdirAll(fullOutputDir, 0o755); err != nil {
+						return fmt.Errorf(utils.L("创建目录失败 %s: %v", "failed to create directory %s: %v"), fullOutputDir, err)
+					}
 				}
 
 				// 构建文档URL并下载
@@ -824,64 +1398,117 @@ func downloadWikiChildren(ctx context.Context, client *core.Client, url string,
 					forceDownload: opts.forceDownload,
 					spaceID:       spaceID,
 					nodeToken:     n.NodeToken,
-					relDir:        nodePath,
+					relDir:        relDir,
 					categoryLevel: opts.categoryLevel,
-					tags:          deriveTagsFromPath(nodePath),
+					tags:          deriveTagsFromPath(nodePath, opts.tagMaxLevels),
 					category:      deriveCategoryFromPath(nodePath, opts.categoryLevel),
+					categories:    deriveTagsFromPath(nodePath, 0),
+					fileNames:     fileNames,
 				}
 
 				// 移除冗余的下载路径输出
-				if err := downloadDocument(ctx, client, docURL, &localOpts); err != nil {
-					errChan <- fmt.Errorf("下载文档失败 %s: %v", n.Name, err)
+				if err := downloadDocument(gctx, client, docURL, &localOpts); err != nil {
+					docPath := filepath.Join(nodePath, n.Name)
+					if permissionCollector.Add(docPath, n.NodeToken, err) || skipUnsupportedDoc(docPath, err) {
+						return nil
+					}
+					return fmt.Errorf(utils.L("下载文档失败 %s: %w", "failed to download document %s: %w"), n.Name, err)
 				}
-			}(node)
+				progress.MarkDone(n.NodeToken)
+				return nil
+			})
 		}
-	}
+		return nil
+	}, func(node *core.Document, err error) {
+		// 无权限列出子节点的目录：记录后跳过整棵子树
+		permissionCollector.Add(pathMap[node.NodeToken], node.NodeToken, err)
+	})
 
+	if walkErr == errStopWalk {
+		walkErr = nil
+	} else if walkErr != nil {
+		walkErr = fmt.Errorf(utils.L("获取子节点失败: %w", "failed to fetch child nodes: %w"), walkErr)
+	}
 	// 等待所有下载完成
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
+	if err := waitDownloadGroup(g, cancel, walkErr); err != nil {
+		return err
+	}
+	if foundNodes == 0 {
+		fmt.Println(utils.L("📭 未找到任何子文档", "📭 No child documents found"))
+		return nil
+	}
+	// 中断时保留进度文件，供下次 --resume 使用
+	finished = ctx.Err() == nil
 
-	// 检查是否有错误
-	for err := range errChan {
-		if err != nil {
-			return err
-		}
+	if err := writeSitemap(opts.outputDir, dlConfig.Output.SitemapBaseURL); err != nil {
+		return fmt.Errorf(utils.L("生成站点地图失败: %w", "failed to generate sitemap: %w"), err)
 	}
 
-	// 计算总耗时
-	elapsed := time.Since(startTime)
+	printDownloadSummary(time.Since(startTime))
+	return nil
+}
 
-	// 统计汇总输出（整洁格式）
-	fmt.Println()
-	fmt.Println("📦 处理结果：")
-	for _, l := range logCollector.SortedByPath() {
-		status := "缓存"
-		if l.DocNew {
-			status = "新增"
-		} else if l.Skipped {
-			status = "跳过"
-		}
-		if l.Reason != "" {
-			status += " (" + l.Reason + ")"
-		}
-		fmt.Printf("- %s  [%s]", l.Path, status)
-		if l.ImgCache > 0 || l.ImgNew > 0 {
-			fmt.Printf("  | 图片: +%d / 命中%d", l.ImgNew, l.ImgCache)
-		}
-		fmt.Println()
+// printDownloadSummary 输出批量下载的汇总；预览模式的汇总在结束时统一输出，这里不重复
+func printDownloadSummary(elapsed time.Duration) {
+	if dlConfig.Output.DryRun {
+		return
 	}
-
-	// 汇总
 	totalDocs, docsNew, totalImages, imagesNew := dlStats.Snapshot()
 	changes := docsNew + imagesNew
 	if changes == 0 {
-		fmt.Printf("🎉 完成！共 %d 个文档、%d 张图片，全部已缓存、无更新。耗时: %.2fs\n", totalDocs, totalImages, elapsed.Seconds())
+		fmt.Printf(utils.L("🎉 完成！共 %d 个文档、%d 张图片，全部已缓存、无更新。耗时: %.2fs\n", "🎉 Done! %d documents, %d images, all cached with no updates. Elapsed: %.2fs\n"), totalDocs, totalImages, elapsed.Seconds())
 	} else {
-		fmt.Printf("🎉 完成！共 %d 个文档、%d 张图片，其中新增文档 %d、新增图片 %d，共 %d 处变更。耗时: %.2fs\n", totalDocs, totalImages, docsNew, imagesNew, changes, elapsed.Seconds())
+		fmt.Printf(utils.L("🎉 完成！共 %d 个文档、%d 张图片，其中新增文档 %d、新增图片 %d，共 %d 处变更。耗时: %.2fs\n", "🎉 Done! %d documents, %d images, %d new documents, %d new images, %d changes. Elapsed: %.2fs\n"), totalDocs, totalImages, docsNew, imagesNew, changes, elapsed.Seconds())
 	}
+	printImageFailures()
+}
+
+// printImageFailures 输出图片失败数，权限不足的图片合并为一条提示；没有失败时不输出
+func printImageFailures() {
+	failed, denied := dlStats.ImageFailures()
+	if failed == 0 {
+		return
+	}
+	fmt.Printf(utils.L("⚠️  图片失败 %d 张，Markdown 中保留图片 token\n", "⚠️  %d images failed, their tokens are kept in the Markdown\n"), failed)
+	if denied > 0 {
+		fmt.Printf(utils.L("🔒 其中 %d 张因权限不足（403）无法下载，请检查飞书应用是否开通 drive:media:download 权限，或将应用添加为文档协作者\n",
+			"🔒 %d of them could not be downloaded due to insufficient permissions (403); check that the app has the drive:media:download scope or is a collaborator on the document\n"), denied)
+	}
+}
+
+// downloadSingleDocument 下载单个文档，结束时把该文档的处理情况合并为一行输出
+func downloadSingleDocument(ctx context.Context, client *core.Client, url string, opts *DownloadOpts) error {
+	startTime := time.Now()
+	logCollector.Drain()
+	if err := downloadDocument(ctx, client, url, opts); err != nil {
+		return err
+	}
+	if dlConfig.Output.DryRun {
+		return nil
+	}
+
+	// 图片统计与新增文档分别记录为两条日志，这里合并
+	var merged DocLog
+	for _, l := range logCollector.Drain() {
+		if merged.Path == "" {
+			merged.Path = l.Path
+		}
+		merged.Skipped = merged.Skipped || l.Skipped
+		merged.DocNew = merged.DocNew || l.DocNew
+		if l.Reason != "" {
+			merged.Reason = l.Reason
+		}
+		merged.ImgCache += l.ImgCache
+		merged.ImgNew += l.ImgNew
+	}
+	elapsed := time.Since(startTime).Seconds()
+	if merged.Path == "" {
+		// 版本未变或内容相同而跳过时不产生日志
+		fmt.Printf(utils.L("🎉 完成！文档已是最新，无更新。耗时: %.2fs\n", "🎉 Done! Document is up to date, no updates. Elapsed: %.2fs\n"), elapsed)
+		return nil
+	}
+	fmt.Printf(utils.L("🎉 完成！%s  耗时: %.2fs\n", "🎉 Done! %s  Elapsed: %.2fs\n"), strings.TrimPrefix(formatDocLog(merged), "- "), elapsed)
+	printImageFailures()
 	return nil
 }
 
@@ -891,40 +1518,246 @@ func createCommonOpts(cliCtx *cli.Context) (*DownloadOpts, *core.Config, error)
 	configPath := cliCtx.String("config")
 	if configPath != "" {
 		if err := core.LoadEnvFileIfExists(configPath); err != nil {
-			return nil, nil, fmt.Errorf("加载配置文件失败: %w", err)
+			return nil, nil, fmt.Errorf(utils.L("加载配置文件失败: %w", "failed to load config file: %w"), err)
 		}
 	}
 
 	// 提取CLI标志
+	appId := cliCtx.String("app-id")
+	appSecret := cliCtx.String("app-secret")
 	spaceId := os.Getenv("FEISHU_SPACE_ID")
 	titleAsFilename := cliCtx.Bool("title-name")
 	useHTML := cliCtx.Bool("html")
 	skipImages := cliCtx.Bool("no-img")
 	noBodyTitle := cliCtx.Bool("no-body-title")
+	stripCodeLineNumbers := cliCtx.Bool("strip-code-line-numbers")
+	stripWatermark := cliCtx.Bool("strip-watermark")
 	skipDuplicate := cliCtx.Bool("skip-same")
 	forceDownload := cliCtx.Bool("force")
 	dumpJSON := cliCtx.Bool("json")
 	categoryLevel := cliCtx.Int("category-level")
 
 	// 加载配置
-	config, err := core.LoadConfig("", "")
+	config, err := core.LoadConfig(appId, appSecret)
 	if err != nil {
 		return nil, nil, err
 	}
+	// 命令行指定的输出目录优先级最高
+	if output := cliCtx.String("output"); output != "" {
+		config.Output.OutputDir = output
+	}
 
-	// 验证凭据
-	if config.Feishu.AppId == "" || config.Feishu.AppSecret == "" {
-		return nil, nil, cli.Exit("需要应用ID和应用密钥。请通过以下方式设置:\n"+
-			"  1. 环境变量: FEISHU_APP_ID 和 FEISHU_APP_SECRET\n"+
-			"  2. 配置文件: 使用 --config 指定配置文件路径\n"+
-			"  3. 运行 'feishu2md init' 创建配置文件模板", 1)
+	// 验证凭据：配置了 user_access_token 时可不提供应用凭据
+	if config.Feishu.UserAccessToken == "" && (config.Feishu.AppId == "" || config.Feishu.AppSecret == "") {
+		return nil, nil, cli.Exit(utils.L("需要应用ID和应用密钥。请通过以下方式设置:\n"+
+			"  1. 命令行参数: --app-id 和 --app-secret\n"+
+			"  2. 环境变量: FEISHU_APP_ID 和 FEISHU_APP_SECRET（或以用户身份访问: FEISHU_USER_ACCESS_TOKEN）\n"+
+			"  3. 配置文件: 使用 --config 指定配置文件路径\n"+
+			"  4. 运行 'feishu2md init' 创建配置文件模板",
+			"App ID and App Secret are required. Set them via:\n"+
+				"  1. Flags: --app-id and --app-secret\n"+
+				"  2. Environment variables: FEISHU_APP_ID and FEISHU_APP_SECRET (or FEISHU_USER_ACCESS_TOKEN to act as a user)\n"+
+				"  3. Config file: pass its path with --config\n"+
+				"  4. Run 'feishu2md init' to create a config template"), 1)
 	}
 
+	// 配置图床主备切换
+	picgo.SetBackupConfigs(config.PicGo.BackupConfigs)
+
+	// 图床 URL 协议：命令行优先于环境变量
+	if cliCtx.IsSet("imgbed-scheme") {
+		config.PicGo.URLScheme = cliCtx.String("imgbed-scheme")
+	}
+	switch config.PicGo.URLScheme {
+	case "", "https", "http", "//":
+	default:
+		return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: --imgbed-scheme 仅支持 https、http 或 //，当前为 %q", "Error: --imgbed-scheme must be https, http or //, got %q"), config.PicGo.URLScheme), 1)
+	}
+	picgo.SetURLScheme(config.PicGo.URLScheme)
+	if cliCtx.IsSet("imgbed-doc-prefix") {
+		config.PicGo.DocKeyPrefix = cliCtx.Bool("imgbed-doc-prefix")
+	}
+	if cliCtx.IsSet("imgbed-cache-ttl") {
+		config.PicGo.CacheTTL = cliCtx.Duration("imgbed-cache-ttl")
+	}
+	if config.PicGo.CacheTTL < 0 {
+		return nil, nil, cli.Exit(utils.L("错误: --imgbed-cache-ttl 不能为负数", "Error: --imgbed-cache-ttl must not be negative"), 1)
+	}
+	picgo.SetCacheTTL(config.PicGo.CacheTTL)
+	if cliCtx.IsSet("imgbed-retries") {
+		config.PicGo.UploadRetries = cliCtx.Int("imgbed-retries")
+	}
+	if config.PicGo.UploadRetries < 0 {
+		return nil, nil, cli.Exit(utils.L("错误: --imgbed-retries 不能为负数", "Error: --imgbed-retries must not be negative"), 1)
+	}
+	picgo.SetUploadRetries(config.PicGo.UploadRetries)
+	if cliCtx.IsSet("imgbed-concurrency") {
+		config.PicGo.Concurrency = cliCtx.Int("imgbed-concurrency")
+	}
+	if config.PicGo.Concurrency < 0 {
+		return nil, nil, cli.Exit(utils.L("错误: --imgbed-concurrency 不能为负数", "Error: --imgbed-concurrency must not be negative"), 1)
+	}
+	picgo.SetUploadConcurrency(config.PicGo.Concurrency)
+
 	// 使用CLI标志覆盖配置
 	config.Output.TitleAsFilename = titleAsFilename
+	if cliCtx.IsSet("filename") {
+		switch mode := cliCtx.String("filename"); mode {
+		case "title":
+			config.Output.TitleAsFilename = true
+		case "token":
+			config.Output.TitleAsFilename = false
+		case "slug":
+			config.Output.SlugFilename = true
+		default:
+			return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: 不支持的 --filename 取值 %q（可选 title、token、slug）", "Error: unsupported --filename value %q (title, token or slug)"), mode), 1)
+		}
+	}
+	config.Output.StripTitleEmoji = cliCtx.Bool("strip-title-emoji")
 	config.Output.UseHTMLTags = useHTML
 	config.Output.SkipImgDownload = skipImages
+	config.Output.StripExif = cliCtx.Bool("strip-exif")
+	if cliCtx.IsSet("image-quality") {
+		config.Output.ImageQuality = cliCtx.Int("image-quality")
+	}
+	if config.Output.ImageQuality < 1 || config.Output.ImageQuality > 100 {
+		return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: --image-quality 必须在 1-100 之间，当前为 %d", "Error: --image-quality must be between 1 and 100, got %d"), config.Output.ImageQuality), 1)
+	}
+	if cliCtx.IsSet("image-format") {
+		config.Output.ImageFormat = cliCtx.String("image-format")
+	}
+	switch config.Output.ImageFormat {
+	case "", core.ImageFormatKeep, core.ImageFormatPNG, core.ImageFormatJPEG, core.ImageFormatWebP:
+	default:
+		return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: --image-format 仅支持 keep、png、jpeg 或 webp，当前为 %q", "Error: --image-format must be keep, png, jpeg or webp, got %q"), config.Output.ImageFormat), 1)
+	}
+	config.Output.DownloadExternalImages = cliCtx.Bool("download-external-img")
+	config.Output.ReportAPIStats = cliCtx.Bool("api-stats")
+	config.Output.Permalink = cliCtx.Bool("permalink")
+	config.Output.Author = cliCtx.Bool("author")
+	config.Output.Draft = cliCtx.Bool("draft")
+	config.Output.WithComments = cliCtx.Bool("with-comments")
+	config.Output.SplitOnDivider = cliCtx.Bool("split-divider")
+	if tz := cliCtx.String("timezone"); tz != "" {
+		config.Output.Timezone = tz
+	}
+	outputLocation = loadOutputLocation(config.Output.Timezone)
+	core.SetDisplayLocation(outputLocation)
+	if value := cliCtx.String("since"); value != "" {
+		since, err := parseSince(value, time.Now())
+		if err != nil {
+			return nil, nil, cli.Exit(utils.L("错误: ", "Error: ")+err.Error(), 1)
+		}
+		config.Output.Since = since
+	}
+	config.Output.UTCDates = cliCtx.Bool("utc-dates")
+	config.Output.SkipFrontmatter = cliCtx.Bool("no-frontmatter")
+	config.Output.DryRun = cliCtx.Bool("dry-run")
+	config.Output.SkipEmpty = cliCtx.Bool("skip-empty")
+	config.Output.ReportPath = cliCtx.String("report")
+	config.Output.ImagesManifest = cliCtx.Bool("images-manifest")
+	if path := cliCtx.String("shared-rate-limit"); path != "" {
+		config.Output.SharedRateLimitFile = path
+	}
+	if cliCtx.IsSet("image-dir") {
+		config.Output.ImageDir = cliCtx.String("image-dir")
+	}
+	if cliCtx.IsSet("max-filename-bytes") {
+		config.Output.MaxFileNameBytes = cliCtx.Int("max-filename-bytes")
+	}
+	if config.Output.MaxFileNameBytes <= 0 {
+		return nil, nil, cli.Exit(utils.L("错误: --max-filename-bytes 必须为正整数", "Error: --max-filename-bytes must be a positive integer"), 1)
+	}
+	utils.SetMaxFileNameBytes(config.Output.MaxFileNameBytes)
+	if cliCtx.IsSet("image-by-month") {
+		config.Output.ImageByMonth = cliCtx.Bool("image-by-month")
+	}
+	// 图片目录会写进 Markdown 链接，分隔符统一后再转回本机格式，保证磁盘路径与链接一致
+	config.Output.ImageDir = filepath.FromSlash(utils.ToPosixPath(config.Output.ImageDir))
+	if config.Output.ImageDir == "" || filepath.IsAbs(config.Output.ImageDir) {
+		return nil, nil, cli.Exit(utils.L("错误: 图片目录需为相对于文档目录的路径，如 img 或 assets/img", "Error: the image directory must be relative to the document directory, e.g. img or assets/img"), 1)
+	}
+	config.Output.Gallery = cliCtx.Bool("gallery")
+	if srcset := cliCtx.String("srcset"); srcset != "" {
+		widths, err := core.ParseSrcsetWidths(srcset)
+		if err != nil {
+			return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: --srcset %v", "Error: --srcset %v"), err), 1)
+		}
+		config.Output.SrcsetWidths = widths
+	}
+	config.Output.DocConcurrency = cliCtx.Int("doc-concurrency")
+	config.Output.ImgConcurrency = cliCtx.Int("img-concurrency")
+	if config.Output.DocConcurrency < 0 || config.Output.ImgConcurrency < 0 {
+		return nil, nil, cli.Exit(utils.L("错误: --doc-concurrency 与 --img-concurrency 不能为负数", "Error: --doc-concurrency and --img-concurrency must not be negative"), 1)
+	}
 	config.Output.NoBodyTitle = noBodyTitle
+	config.Output.StripCodeLineNumbers = stripCodeLineNumbers
+	config.Output.FixHeadingLevels = cliCtx.Bool("fix-heading-levels")
+	config.Output.LintFix = cliCtx.Bool("lint-fix")
+	switch style := cliCtx.String("callout-style"); style {
+	case core.CalloutStyleAlert, core.CalloutStyleQuote:
+		config.Output.CalloutStyle = style
+	default:
+		return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: --callout-style 仅支持 alert 或 quote，当前为 %q", "Error: --callout-style must be alert or quote, got %q"), style), 1)
+	}
+	config.Output.CellMaxWidth = cliCtx.Int("cell-max-width")
+	if config.Output.CellMaxWidth < 0 {
+		return nil, nil, cli.Exit(utils.L("错误: --cell-max-width 不能为负数", "Error: --cell-max-width must not be negative"), 1)
+	}
+	config.Output.StripWatermark = stripWatermark
+	if endpoint := cliCtx.String("formula-ocr"); endpoint != "" {
+		config.Output.FormulaOCRURL = endpoint
+	}
+	if postProcessCmd := cliCtx.String("post-process"); postProcessCmd != "" {
+		config.Output.PostProcessCmd = postProcessCmd
+	}
+	if sitemapBaseURL := cliCtx.String("sitemap-base-url"); sitemapBaseURL != "" {
+		config.Output.SitemapBaseURL = sitemapBaseURL
+	}
+	if path := cliCtx.String("frontmatter-template"); path != "" {
+		config.Output.FrontmatterTemplate = path
+	}
+	frontmatterTemplate = loadFrontmatterTemplate(config.Output.FrontmatterTemplate)
+	if cliCtx.IsSet("frontmatter-flavor") {
+		config.Output.FrontmatterFlavor = cliCtx.String("frontmatter-flavor")
+	}
+	switch config.Output.FrontmatterFlavor {
+	case "", frontmatterFlavorDefault, frontmatterFlavorHexo:
+	default:
+		return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: --frontmatter-flavor 仅支持 default 或 hexo，当前为 %q", "Error: --frontmatter-flavor must be default or hexo, got %q"), config.Output.FrontmatterFlavor), 1)
+	}
+	if cliCtx.IsSet("default-category") {
+		config.Output.DefaultCategory = cliCtx.String("default-category")
+	}
+	draftPattern = nil
+	if config.Output.Draft {
+		pattern := config.Output.DraftPattern
+		if pattern == "" {
+			pattern = core.DefaultDraftPattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf(utils.L("DRAFT_PATTERN 不是合法的正则表达式: %w", "DRAFT_PATTERN is not a valid regular expression: %w"), err)
+		}
+		draftPattern = re
+	}
+	if stripWatermark && config.Output.WatermarkPattern != "" {
+		if _, err := regexp.Compile(config.Output.WatermarkPattern); err != nil {
+			return nil, nil, fmt.Errorf(utils.L("WATERMARK_PATTERN 不是合法的正则表达式: %w", "WATERMARK_PATTERN is not a valid regular expression: %w"), err)
+		}
+	}
+
+	// 启用图床时提前校验配置，避免下载到第一张图片才发现图床不可用
+	if config.PicGo.Enabled && !config.Output.SkipImgDownload {
+		if err := picgo.Validate(); err != nil {
+			return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: %v\n\n提示: 修正图床配置，或在 .env 中设置 PICGO_ENABLED=false 关闭图床上传", "Error: %v\n\nTip: fix the image host config, or set PICGO_ENABLED=false in .env to disable uploads"), err), 1)
+		}
+	}
+
+	tokenType := cliCtx.String("token-type")
+	if tokenType != "docx" && tokenType != "wiki" && tokenType != "sheet" {
+		return nil, nil, cli.Exit(fmt.Sprintf(utils.L("错误: --token-type 仅支持 docx、wiki 或 sheet，当前为 %q", "Error: --token-type must be docx, wiki or sheet, got %q"), tokenType), 1)
+	}
 
 	// 创建下载选项
 	opts := &DownloadOpts{
@@ -935,23 +1768,84 @@ func createCommonOpts(cliCtx *cli.Context) (*DownloadOpts, *core.Config, error)
 		spaceID:       spaceId,
 		nodeToken:     "",
 		categoryLevel: categoryLevel,
+		tokenType:     tokenType,
 	}
 
+	// 每个命令都从零开始统计，结束时输出汇总
+	dlStats = &DownloadStats{}
+	logCollector = &LogCollector{}
+	dirFileNames = NewFileNameRegistry()
+
 	return opts, config, nil
 }
 
+// imageHitCache 全局图片命中缓存，与文档版本缓存一起存放在 .feishu2md/ 下
+var imageHitCache = core.NewImageCache(filepath.Join(".feishu2md", "image-cache.json"))
+
+// extraClientOptions 追加在 newClient 默认选项之后的客户端选项，测试中用于指向模拟的飞书开放平台
+var extraClientOptions []core.ClientOption
+
+// newClient 根据配置创建飞书客户端，并应用图片处理等客户端选项
+func newClient(config *core.Config) *core.Client {
+	clientOpts := []core.ClientOption{core.WithRateLimit(config.RateLimit)}
+	if config.Feishu.UserAccessToken != "" {
+		clientOpts = append(clientOpts, core.WithUserAccessToken(config.Feishu.UserAccessToken))
+	}
+	clientOpts = append(clientOpts, extraClientOptions...)
+	client := core.NewClient(config.Feishu.AppId, config.Feishu.AppSecret, clientOpts...)
+	client.SetImageOptions(core.ImageOptions{
+		StripExif: config.Output.StripExif,
+		Quality:   config.Output.ImageQuality,
+		Format:    config.Output.ImageFormat,
+		OnUnverified: func(srcName string) {
+			fmt.Printf(utils.L("⚠️  无法识别图片格式，按原扩展名保存: %s\n", "⚠️  Unrecognized image format, saved with its original extension: %s\n"), srcName)
+		},
+	})
+	client.SetImageCache(imageHitCache)
+	if config.Output.SharedRateLimitFile != "" {
+		client.SetSharedRateLimit(config.Output.SharedRateLimitFile)
+	}
+	return client
+}
+
+// verifyCredentials 在开始下载前校验应用凭据，区分凭据错误与网络不可达给出提示
+func verifyCredentials(ctx context.Context, client *core.Client) error {
+	err := client.VerifyCredentials(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, core.ErrInvalidCredentials):
+		return cli.Exit(fmt.Sprintf(utils.L(
+			"❌ 飞书凭据校验失败，请检查 FEISHU_APP_ID / FEISHU_APP_SECRET（或 --app-id / --app-secret）是否正确\n   %v",
+			"❌ Feishu credential check failed, please verify FEISHU_APP_ID / FEISHU_APP_SECRET (or --app-id / --app-secret)\n   %v"), err), 1)
+	case errors.Is(err, core.ErrNetworkUnreachable):
+		return cli.Exit(fmt.Sprintf(utils.L(
+			"❌ 无法连接飞书开放平台或服务暂不可用，请检查网络或代理设置后稍后重试\n   %v",
+			"❌ Cannot reach the Feishu open platform or it is temporarily unavailable, please check your network or proxy settings and retry later\n   %v"), err), 1)
+	default:
+		return err
+	}
+}
+
 // handleDocumentDownload 处理单个文档下载
 func handleDocumentDownload(cliCtx *cli.Context, url string) error {
 	opts, config, err := createCommonOpts(cliCtx)
 	if err != nil {
 		return err
 	}
+	if utils.IsBareToken(url) {
+		url = utils.TokenToURL(url, opts.tokenType)
+	}
 
 	dlConfig = *config
-	client := core.NewClient(config.Feishu.AppId, config.Feishu.AppSecret)
-	ctx := context.Background()
+	client := newClient(config)
+	ctx, stop := newSignalContext()
+	defer stop()
 
-	return downloadDocument(ctx, client, url, opts)
+	if err := verifyCredentials(ctx, client); err != nil {
+		return err
+	}
+	return finishDownload(ctx, client, downloadSingleDocument(ctx, client, url, opts))
 }
 
 // handleFolderDownload 处理文件夹批量下载
@@ -960,12 +1854,19 @@ func handleFolderDownload(cliCtx *cli.Context, url string) error {
 	if err != nil {
 		return err
 	}
+	if utils.IsBareToken(url) {
+		url = utils.TokenToURL(url, "folder")
+	}
 
 	dlConfig = *config
-	client := core.NewClient(config.Feishu.AppId, config.Feishu.AppSecret)
-	ctx := context.Background()
+	client := newClient(config)
+	ctx, stop := newSignalContext()
+	defer stop()
 
-	return downloadDocuments(ctx, client, url, opts)
+	if err := verifyCredentials(ctx, client); err != nil {
+		return err
+	}
+	return finishDownload(ctx, client, downloadDocuments(ctx, client, url, opts))
 }
 
 // handleWikiDownload 处理知识库完整下载
@@ -976,10 +1877,14 @@ func handleWikiDownload(cliCtx *cli.Context, url string) error {
 	}
 
 	dlConfig = *config
-	client := core.NewClient(config.Feishu.AppId, config.Feishu.AppSecret)
-	ctx := context.Background()
+	client := newClient(config)
+	ctx, stop := newSignalContext()
+	defer stop()
 
-	return downloadWiki(ctx, client, url, opts)
+	if err := verifyCredentials(ctx, client); err != nil {
+		return err
+	}
+	return finishDownload(ctx, client, downloadWiki(ctx, client, url, opts))
 }
 
 // handleWikiTreeCommand 处理知识库子文档下载命令
@@ -988,7 +1893,7 @@ func handleWikiTreeCommand(cliCtx *cli.Context) error {
 	configPath := cliCtx.String("config")
 	if configPath != "" {
 		if err := core.LoadEnvFileIfExists(configPath); err != nil {
-			return fmt.Errorf("加载配置文件失败: %w", err)
+			return fmt.Errorf(utils.L("加载配置文件失败: %w", "failed to load config file: %w"), err)
 		}
 	}
 
@@ -1001,10 +1906,14 @@ func handleWikiTreeCommand(cliCtx *cli.Context) error {
 	}
 
 	if url == "" {
-		return cli.Exit("错误: 请指定知识库文档URL\n\n"+
+		return cli.Exit(utils.L("错误: 请指定知识库文档URL\n\n"+
 			"方式一: feishu2md wiki-tree <URL>\n"+
 			"方式二: 在配置文件中设置 FEISHU_FOLDER_TOKEN\n\n"+
-			"提示: 还需要在配置文件中设置 FEISHU_SPACE_ID", 1)
+			"提示: 还需要在配置文件中设置 FEISHU_SPACE_ID",
+			"Error: please specify a wiki document URL\n\n"+
+				"Option 1: feishu2md wiki-tree <URL>\n"+
+				"Option 2: set FEISHU_FOLDER_TOKEN in the config file\n\n"+
+				"Tip: FEISHU_SPACE_ID must also be set in the config file"), 1)
 	}
 
 	return handleWikiTreeDownload(cliCtx, url)
@@ -1017,24 +1926,37 @@ func handleWikiTreeDownload(cliCtx *cli.Context, url string) error {
 		return err
 	}
 	opts.cleanOutput = cliCtx.Bool("clean-output")
+	opts.resume = cliCtx.Bool("resume")
+	opts.includeSelf = cliCtx.Bool("include-self")
+	opts.flat = cliCtx.Bool("flat")
+	opts.tagMaxLevels = cliCtx.Int("tag-max-levels")
+	opts.checkpoint = cliCtx.Int("checkpoint-every")
+	if opts.checkpoint < 0 {
+		return cli.Exit(utils.L("错误: --checkpoint-every 不能为负数", "Error: --checkpoint-every must not be negative"), 1)
+	}
 
 	dlConfig = *config
-	client := core.NewClient(config.Feishu.AppId, config.Feishu.AppSecret)
-	ctx := context.Background()
+	client := newClient(config)
+	ctx, stop := newSignalContext()
+	defer stop()
 
-	return downloadWikiChildren(ctx, client, url, opts)
+	if err := verifyCredentials(ctx, client); err != nil {
+		return err
+	}
+	return finishDownload(ctx, client, downloadWikiChildren(ctx, client, url, opts))
 }
 
 // handleLegacyDownload 处理遗留的智能下载命令（保持向后兼容）
 func handleLegacyDownload(cliCtx *cli.Context, url string) error {
-	fmt.Println("⚠️  使用了已废弃的命令，建议使用具体的子命令:")
-	fmt.Println("  - feishu2md document <url>  # 下载单个文档")
-	fmt.Println("  - feishu2md folder <url>    # 下载文件夹")
-	fmt.Println("  - feishu2md wiki <url>      # 下载知识库")
-	fmt.Println("  - feishu2md wiki-tree <url> # 下载子文档")
+	fmt.Println(utils.L("⚠️  使用了已废弃的命令，建议使用具体的子命令:", "⚠️  This command is deprecated, use a specific subcommand instead:"))
+	fmt.Println(utils.L("  - feishu2md document <url>  # 下载单个文档", "  - feishu2md document <url>  # download a single document"))
+	fmt.Println(utils.L("  - feishu2md folder <url>    # 下载文件夹", "  - feishu2md folder <url>    # download a folder"))
+	fmt.Println(utils.L("  - feishu2md wiki <url>      # 下载知识库", "  - feishu2md wiki <url>      # download a wiki space"))
+	fmt.Println(utils.L("  - feishu2md wiki-tree <url> # 下载子文档", "  - feishu2md wiki-tree <url> # download child documents"))
 	fmt.Println()
 
 	// 自动检测URL类型并使用相应的处理函数
+	url = utils.NormalizeShareURL(url)
 	if strings.Contains(url, "/drive/folder/") {
 		return handleFolderDownload(cliCtx, url)
 	}
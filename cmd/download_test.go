@@ -0,0 +1,602 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/picgo"
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+func TestDownloadDocumentUnsupportedWikiObjType(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	// 枚举时是文档，下载时节点已变为思维笔记
+	feishu.addWikiNodes("spc1", "", wikiNode{Token: "wikNode", ObjToken: "bmnMind", ObjType: "mindnote", Title: "脑图"})
+
+	err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/wikNode", &DownloadOpts{outputDir: dir})
+	var unsupported *unsupportedDocTypeError
+	if !errors.As(err, &unsupported) || unsupported.docType != "mindnote" {
+		t.Fatalf("downloadDocument() error = %v, want unsupportedDocTypeError for mindnote", err)
+	}
+
+	if !skipUnsupportedDoc("知识库/脑图", err) {
+		t.Fatal("skipUnsupportedDoc() = false, want true")
+	}
+	logs := logCollector.Drain()
+	if len(logs) != 1 || !logs[0].Skipped || logs[0].Path != "知识库/脑图" {
+		t.Errorf("logs = %+v, want one skipped entry", logs)
+	}
+	if skipUnsupportedDoc("x", errors.New("other")) {
+		t.Error("skipUnsupportedDoc(other error) = true, want false")
+	}
+}
+
+func TestDownloadURLListSkipsChangedObjType(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	feishu.addWikiNodes("spc1", "", wikiNode{Token: "wikNode", ObjToken: "bmnMind", ObjType: "mindnote", Title: "脑图"})
+	feishu.addDocx("doxGood", "好文档", "正文内容")
+
+	urls := []string{"https://x.feishu.cn/wiki/wikNode", "https://x.feishu.cn/docx/doxGood"}
+	if err := downloadURLList(context.Background(), feishu.client(), urls, &DownloadOpts{outputDir: dir}); err == nil {
+		t.Fatal("downloadURLList() error = nil, want the mind note reported")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "好文档.md")); err != nil {
+		t.Errorf("document after the unsupported node was not downloaded: %v", err)
+	}
+}
+
+func TestCredentialFlagsOverrideEnv(t *testing.T) {
+	setupDownload(t)
+	setCredentialEnv(t)
+
+	_, config, err := createCommonOpts(newCLIContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Feishu.AppId != "cli_test" || config.Feishu.AppSecret != "secret" {
+		t.Errorf("without flags: credentials = %q/%q, want values from env", config.Feishu.AppId, config.Feishu.AppSecret)
+	}
+
+	_, config, err = createCommonOpts(newCLIContext(t, "--app-id", "cli_flag", "--app-secret", "flag-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Feishu.AppId != "cli_flag" || config.Feishu.AppSecret != "flag-secret" {
+		t.Errorf("with flags: credentials = %q/%q, want cli_flag/flag-secret", config.Feishu.AppId, config.Feishu.AppSecret)
+	}
+
+	// 只传其中一个标志时，另一个仍取环境变量
+	_, config, err = createCommonOpts(newCLIContext(t, "--app-id", "cli_flag"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Feishu.AppId != "cli_flag" || config.Feishu.AppSecret != "secret" {
+		t.Errorf("with --app-id only: credentials = %q/%q, want cli_flag/secret", config.Feishu.AppId, config.Feishu.AppSecret)
+	}
+
+	// 环境变量缺失时标志即可提供完整凭据
+	t.Setenv("FEISHU_APP_ID", "")
+	t.Setenv("FEISHU_APP_SECRET", "")
+	if _, _, err := createCommonOpts(newCLIContext(t, "--app-id", "cli_flag", "--app-secret", "flag-secret")); err != nil {
+		t.Errorf("flags without env: %v", err)
+	}
+}
+
+func TestOutputFlagOverridesEnv(t *testing.T) {
+	setupDownload(t)
+	setCredentialEnv(t)
+	t.Setenv("OUTPUT_DIR", "from-env")
+
+	opts, config, err := createCommonOpts(newCLIContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.outputDir != "from-env" || config.Output.OutputDir != "from-env" {
+		t.Errorf("without --output: outputDir = %q, config = %q, want from-env", opts.outputDir, config.Output.OutputDir)
+	}
+
+	opts, config, err = createCommonOpts(newCLIContext(t, "--output", "from-cli"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.outputDir != "from-cli" || config.Output.OutputDir != "from-cli" {
+		t.Errorf("with --output: outputDir = %q, config = %q, want from-cli", opts.outputDir, config.Output.OutputDir)
+	}
+}
+
+func TestDownloadWikiWritesUnderOutputDir(t *testing.T) {
+	dir := setupDownload(t)
+	out := filepath.Join(dir, "out")
+	feishu := newFakeFeishu(t)
+	feishu.addWikiSpace("spc1", "团队/知识库")
+	feishu.addWikiNodes("spc1", "", wikiNode{Token: "wikA", ObjToken: "doxA", ObjType: "docx", Title: "入门"})
+	feishu.addDocx("doxA", "入门", "正文内容")
+
+	if err := downloadWiki(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/settings/spc1", &DownloadOpts{outputDir: out}); err != nil {
+		t.Fatal(err)
+	}
+	// 知识库名称中的路径分隔符被替换，不会多出一层目录
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		t.Fatalf("output dir entries = %v, want a single wiki directory", entries)
+	}
+	if _, err := os.Stat(filepath.Join(out, entries[0].Name(), "入门.md")); err != nil {
+		t.Errorf("wiki document not written under the output dir: %v", err)
+	}
+}
+
+func TestFilenameErrorLocalized(t *testing.T) {
+	setupDownload(t)
+	setCredentialEnv(t)
+	t.Cleanup(func() { utils.SetLang("zh") })
+
+	for lang, want := range map[string]string{
+		"zh": `错误: 不支持的 --filename 取值 "bogus"`,
+		"en": `Error: unsupported --filename value "bogus"`,
+	} {
+		if err := utils.SetLang(lang); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := createCommonOpts(newCLIContext(t, "--filename", "bogus"))
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("lang %s: createCommonOpts() error = %v, want prefix %q", lang, err, want)
+		}
+	}
+}
+
+func TestDocConcurrencyDefault(t *testing.T) {
+	setupDownload(t)
+	setCredentialEnv(t)
+
+	_, config, err := createCommonOpts(newCLIContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Output.DocConcurrency != defaultDocConcurrency {
+		t.Errorf("default --doc-concurrency = %d, want %d", config.Output.DocConcurrency, defaultDocConcurrency)
+	}
+	_, config, err = createCommonOpts(newCLIContext(t, "--doc-concurrency", "3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Output.DocConcurrency != 3 {
+		t.Errorf("--doc-concurrency 3: DocConcurrency = %d, want 3", config.Output.DocConcurrency)
+	}
+}
+
+func TestDownloadWikiRespectsDocConcurrency(t *testing.T) {
+	const limit, docs = 2, 6
+	dir := setupDownload(t)
+	dlConfig.Output.DocConcurrency = limit
+	feishu := newFakeFeishu(t)
+
+	var nodes []wikiNode
+	for i := 0; i < docs; i++ {
+		n := wikiNode{Token: fmt.Sprintf("wik%d", i), ObjToken: fmt.Sprintf("dox%d", i), ObjType: "docx", Title: fmt.Sprintf("文档%d", i)}
+		nodes = append(nodes, n)
+		feishu.addDocx(n.ObjToken, n.Title, "正文内容")
+	}
+	feishu.addWikiSpace("spc1", "知识库")
+	feishu.addWikiNodes("spc1", "", nodes...)
+
+	// 每篇文档只请求一次块列表：统计同时进行中的块列表请求即为同时下载的文档数
+	var inFlight, peak int32
+	feishu.hook = func(r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/blocks") {
+			return
+		}
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := downloadWiki(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/settings/spc1", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&peak); got > limit {
+		t.Errorf("peak concurrent document downloads = %d, want <= %d", got, limit)
+	}
+	if _, docsNew, _, _ := dlStats.Snapshot(); docsNew != docs {
+		t.Errorf("downloaded documents = %d, want %d", docsNew, docs)
+	}
+}
+
+func TestDownloadCountsNewImagesWithoutPicgo(t *testing.T) {
+	dir := setupDownload(t)
+	dlConfig.Output.SkipImgDownload = false
+	feishu := newFakeFeishu(t)
+	feishu.addDocxBlocks("doxImg", "带图文档", imageBlock("imgA"), imageBlock("imgB"))
+	feishu.addImage("imgA", testPNG(t))
+	feishu.addImage("imgB", testPNG(t))
+
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxImg", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	imgNew := 0
+	for _, l := range logCollector.Drain() {
+		imgNew += l.ImgNew
+	}
+	if imgNew != 2 {
+		t.Errorf("logged ImgNew = %d, want 2", imgNew)
+	}
+	if _, _, totalImages, imagesNew := dlStats.Snapshot(); totalImages != 2 || imagesNew != 2 {
+		t.Errorf("stats images = %d, new = %d, want 2 and 2", totalImages, imagesNew)
+	}
+}
+
+func TestDownloadWikiSanitizesNodeDirs(t *testing.T) {
+	dir := setupDownload(t)
+	out := filepath.Join(dir, "out")
+	feishu := newFakeFeishu(t)
+	feishu.addWikiSpace("spc1", "知识库")
+	// 两个同名且标题含路径分隔符的目录节点
+	feishu.addWikiNodes("spc1", "",
+		wikiNode{Token: "wikP1", ObjToken: "bas1", ObjType: "bitable", Title: "../逃逸", HasChild: true},
+		wikiNode{Token: "wikP2", ObjToken: "bas2", ObjType: "bitable", Title: "../逃逸", HasChild: true})
+	feishu.addWikiNodes("spc1", "wikP1", wikiNode{Token: "wikA", ObjToken: "doxA", ObjType: "docx", Title: "甲"})
+	feishu.addWikiNodes("spc1", "wikP2", wikiNode{Token: "wikB", ObjToken: "doxB", ObjType: "docx", Title: "乙"})
+	feishu.addDocx("doxA", "甲", "正文甲")
+	feishu.addDocx("doxB", "乙", "正文乙")
+
+	if err := downloadWiki(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/settings/spc1", &DownloadOpts{outputDir: out}); err != nil {
+		t.Fatal(err)
+	}
+	wikiDir := filepath.Join(out, "知识库")
+	var docs []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".md") {
+			docs = append(docs, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("documents = %v, want 2", docs)
+	}
+	parents := map[string]bool{}
+	for _, doc := range docs {
+		rel, err := filepath.Rel(wikiDir, doc)
+		if err != nil || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || strings.Count(rel, string(filepath.Separator)) != 1 {
+			t.Errorf("document %s is not one level under %s", doc, wikiDir)
+		}
+		parents[filepath.Dir(doc)] = true
+	}
+	if len(parents) != 2 {
+		t.Errorf("same-named sibling nodes share a directory: %v", docs)
+	}
+}
+
+func TestColorNeverKeepsDocumentContent(t *testing.T) {
+	dir := setupDownload(t)
+	setCredentialEnv(t)
+	t.Setenv("OUTPUT_DIR", dir)
+	t.Cleanup(func() { utils.SetColor("always") })
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxEmoji", "发布说明", "🎉 新版本已发布 ✅")
+
+	var runErr error
+	stdout := captureStdout(t, func() {
+		runErr = feishu.runApp(t, "--color", "never", "document", "https://x.feishu.cn/docx/doxEmoji")
+	})
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+	if !strings.Contains(stdout, "发布说明.md") {
+		t.Errorf("output does not mention the document:\n%s", stdout)
+	}
+	if stripped := utils.StripDecorations(stdout); stripped != stdout {
+		t.Errorf("--color never output still has emoji or color codes:\n%s", stdout)
+	}
+	// 终端装饰开关只影响日志，文档内容原样保留
+	data, err := os.ReadFile(filepath.Join(dir, "发布说明.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "🎉 新版本已发布 ✅") {
+		t.Errorf("document content changed under --color never:\n%s", data)
+	}
+}
+
+func TestDownloadKeepsLocalImagesWhenUploadFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake picgo script requires a POSIX shell")
+	}
+	dir := setupDownload(t)
+	dlConfig.Output.SkipImgDownload = false
+	dlConfig.PicGo.Enabled = true
+	// 假 picgo：每次上传都失败
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "picgo"), []byte("#!/bin/sh\necho 'upload failed'\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	picgo.SetUploadRetries(0)
+	t.Cleanup(func() { picgo.SetUploadRetries(picgo.DefaultUploadRetries) })
+
+	feishu := newFakeFeishu(t)
+	feishu.addDocxBlocks("doxImg", "带图文档", imageBlock("imgA"))
+	feishu.addImage("imgA", testPNG(t))
+
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxImg", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "带图文档.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(md), "](./img/imgA.png)") {
+		t.Errorf("markdown does not keep the local image link:\n%s", md)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "img", "imgA.png")); err != nil {
+		t.Errorf("local image was removed after a failed upload: %v", err)
+	}
+}
+
+func TestDownloadFolderStopsAtShortcutCycle(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	// fldRoot/子文件夹 中的快捷方式又指向 fldRoot
+	feishu.routes["GET /open-apis/drive/v1/files?folder_token=fldRoot"] = `{"code":0,"data":{"has_more":false,"files":[
+		{"token":"fldSub","name":"子文件夹","type":"folder"},
+		{"token":"doxTop","name":"顶层","type":"docx","url":"https://x.feishu.cn/docx/doxTop"}]}}`
+	feishu.routes["GET /open-apis/drive/v1/files?folder_token=fldSub"] = `{"code":0,"data":{"has_more":false,"files":[
+		{"token":"shcBack","name":"回到根目录","type":"shortcut","shortcut_info":{"target_type":"folder","target_token":"fldRoot"}}]}}`
+	feishu.addDocx("doxTop", "顶层", "正文")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var err error
+	stdout := captureStdout(t, func() {
+		err = downloadDocuments(ctx, feishu.client(), "https://x.feishu.cn/drive/folder/fldRoot", &DownloadOpts{outputDir: dir})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, utils.L("快捷方式成环", "shortcut cycle")) {
+		t.Errorf("cycle was not reported:\n%s", stdout)
+	}
+	if totalDocs, _, _, _ := dlStats.Snapshot(); totalDocs != 1 {
+		t.Errorf("total docs = %d, want 1", totalDocs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "子文件夹", "回到根目录")); !os.IsNotExist(err) {
+		t.Errorf("cycle was followed into a nested directory: %v", err)
+	}
+}
+
+func TestDownloadGroupLimitsInFlight(t *testing.T) {
+	const limit = 3
+	g, _, cancel := newDownloadGroup(context.Background(), limit)
+	defer cancel()
+	var inFlight, peak atomic.Int32
+	for i := 0; i < 12; i++ {
+		g.Go(func() error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := waitDownloadGroup(g, cancel, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := peak.Load(); got > limit {
+		t.Errorf("peak in-flight = %d, want <= %d", got, limit)
+	}
+}
+
+func TestDownloadGroupFirstErrorCancelsSiblings(t *testing.T) {
+	g, gctx, cancel := newDownloadGroup(context.Background(), 4)
+	defer cancel()
+	errFirst := errors.New("first failure")
+	var canceled atomic.Int32
+	for i := 0; i < 3; i++ {
+		g.Go(func() error {
+			select {
+			case <-gctx.Done():
+				canceled.Add(1)
+				return gctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		})
+	}
+	g.Go(func() error { return errFirst })
+
+	start := time.Now()
+	if err := waitDownloadGroup(g, cancel, nil); !errors.Is(err, errFirst) {
+		t.Errorf("waitDownloadGroup() = %v, want %v", err, errFirst)
+	}
+	if canceled.Load() != 3 || time.Since(start) > 2*time.Second {
+		t.Errorf("siblings canceled = %d after %v, want all 3 promptly", canceled.Load(), time.Since(start))
+	}
+}
+
+func TestDownloadGroupWalkErrorCancelsDownloads(t *testing.T) {
+	g, gctx, cancel := newDownloadGroup(context.Background(), 2)
+	defer cancel()
+	g.Go(func() error {
+		<-gctx.Done()
+		return gctx.Err()
+	})
+	walkErr := errors.New("list children failed")
+	if err := waitDownloadGroup(g, cancel, walkErr); err != walkErr {
+		t.Errorf("waitDownloadGroup() = %v, want the walk error", err)
+	}
+}
+
+func TestDownloadSetsFileModTime(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxTime", "时间文档", "正文")
+
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxTime", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	mdPath := filepath.Join(dir, "时间文档.md")
+	info, err := os.Stat(mdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟服务返回的最近修改时间
+	if want := time.Unix(1704067200, 0); !info.ModTime().Equal(want) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), want)
+	}
+
+	// 修改时间未知时保持文件原有的 mtime
+	syncFileModTime(mdPath, time.Time{})
+	if again, _ := os.Stat(mdPath); !again.ModTime().Equal(info.ModTime()) {
+		t.Errorf("zero time changed mtime to %v", again.ModTime())
+	}
+}
+
+func TestPermalinkStableAcrossRenameAndMove(t *testing.T) {
+	if got := derivePermalink("doxcnAbc123"); got != "/p/15e5f2b9/" {
+		t.Errorf("derivePermalink() = %q, want /p/15e5f2b9/", got)
+	}
+	if derivePermalink("doxcnAbc123") == derivePermalink("doxcnAbc124") {
+		t.Error("different documents share a permalink")
+	}
+
+	// 同一文档改名并移动到其他目录后，frontmatter 中的 permalink 不变
+	var permalinks []string
+	for _, tc := range []struct{ title, subdir string }{{"旧标题", "甲"}, {"新标题", "乙/丙"}} {
+		dir := setupDownload(t)
+		dlConfig.Output.Permalink = true
+		feishu := newFakeFeishu(t)
+		feishu.addDocx("doxcnAbc123", tc.title, "正文")
+		out := filepath.Join(dir, filepath.FromSlash(tc.subdir))
+		if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxcnAbc123", &DownloadOpts{outputDir: out, relDir: tc.subdir}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(out, tc.title+".md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "permalink: ") {
+				permalinks = append(permalinks, line)
+			}
+		}
+	}
+	if len(permalinks) != 2 || permalinks[0] != "permalink: /p/15e5f2b9/" || permalinks[1] != permalinks[0] {
+		t.Errorf("permalinks = %q, want the same /p/15e5f2b9/ twice", permalinks)
+	}
+}
+
+func TestFlatLayoutDedupesAcrossDirs(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	feishu.addWikiNodes("spc1", "", wikiNode{Token: "wikRoot", ObjToken: "doxRoot", ObjType: "docx", Title: "根", HasChild: true})
+	feishu.addWikiNodes("spc1", "wikRoot",
+		wikiNode{Token: "wikD1", ObjToken: "bas1", ObjType: "bitable", Title: "甲组", HasChild: true},
+		wikiNode{Token: "wikD2", ObjToken: "bas2", ObjType: "bitable", Title: "乙组", HasChild: true})
+	// 两个目录下各有一篇同名文档
+	feishu.addWikiNodes("spc1", "wikD1", wikiNode{Token: "wikA", ObjToken: "doxA", ObjType: "docx", Title: "周报"})
+	feishu.addWikiNodes("spc1", "wikD2", wikiNode{Token: "wikB", ObjToken: "doxB", ObjType: "docx", Title: "周报"})
+	feishu.addDocx("doxA", "周报", "甲组正文")
+	feishu.addDocx("doxB", "周报", "乙组正文")
+
+	opts := &DownloadOpts{outputDir: dir, spaceID: "spc1", flat: true}
+	if err := downloadWikiChildren(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/wikRoot", opts); err != nil {
+		t.Fatal(err)
+	}
+	// 后出现的同名文档追加 token 前缀区分
+	for name, body := range map[string]string{"周报.md": "甲组正文", "周报-doxB.md": "乙组正文"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !strings.Contains(string(data), body) {
+			t.Errorf("%s = %q, %v, want it to hold %s", name, data, err, body)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("flat layout created directory %s", e.Name())
+		}
+	}
+}
+
+func TestDeriveTagsFromPathMaxLevels(t *testing.T) {
+	path := filepath.Join("产品", "设计", "规范", "组件")
+	tests := []struct {
+		maxLevels int
+		want      string
+	}{
+		{0, "产品,设计,规范,组件"},
+		{2, "产品,设计"},
+		{-2, "规范,组件"},
+		{4, "产品,设计,规范,组件"},
+		{10, "产品,设计,规范,组件"},
+		{-10, "产品,设计,规范,组件"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(deriveTagsFromPath(path, tt.maxLevels), ","); got != tt.want {
+			t.Errorf("deriveTagsFromPath(%d) = %q, want %q", tt.maxLevels, got, tt.want)
+		}
+	}
+	if got := deriveTagsFromPath(".", 2); got != nil {
+		t.Errorf("deriveTagsFromPath(root) = %v, want nil", got)
+	}
+}
+
+func TestLuteAutoSpace(t *testing.T) {
+	setupDownload(t)
+	const src = "使用Go语言编写，支持100个并发\n"
+
+	dlConfig.Output.AutoSpace = true
+	if got := newLuteEngine().FormatStr("md", src); got != "使用 Go 语言编写，支持 100 个并发\n" {
+		t.Errorf("AutoSpace on: %q", got)
+	}
+	dlConfig.Output.AutoSpace = false
+	if got := newLuteEngine().FormatStr("md", src); got != src {
+		t.Errorf("AutoSpace off changed spacing: %q, want %q", got, src)
+	}
+}
+
+func TestStripTitleEmojiKeepsFrontmatterTitle(t *testing.T) {
+	dir := setupDownload(t)
+	dlConfig.Output.StripTitleEmoji = true
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxEmoji", "🚀 发布计划", "正文")
+
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxEmoji", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "发布计划.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "title: "+escapeYAML("🚀 发布计划")+"\n") {
+		t.Errorf("frontmatter lost the original title:\n%s", data)
+	}
+}
@@ -0,0 +1,101 @@
+// Package main - 变更预览
+// --dry-run 时只遍历节点并比对文档版本，按目录树列出每篇文档的目标路径、类型与处理方式，
+// 不拉取正文与图片，也不写入任何文件
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// dryRunKind 预览中文档的变更类型
+type dryRunKind int
+
+const (
+	dryRunNew         dryRunKind = iota // 本地不存在，将新增
+	dryRunModified                      // 本地已存在且版本有变化（或无版本记录），将重新生成
+	dryRunSkipped                       // 版本未变，将跳过
+	dryRunHasChildren                   // 有子节点的知识库文档，只作为目录，不生成文件
+)
+
+// dryRunMarks 各变更类型在预览树中的标记
+var dryRunMarks = map[dryRunKind]string{
+	dryRunNew:      "+",
+	dryRunModified: "~",
+	dryRunSkipped:  "=",
+}
+
+// dryRunEntry 预览中的一篇文档
+type dryRunEntry struct {
+	Path    string // 相对输出目录的目标路径
+	DocType string // docx 或 sheet
+	Kind    dryRunKind
+}
+
+// classifyDryRun 按与实际下载相同的规则判断文档将如何处理
+func classifyDryRun(outputPath, docToken string, revisionID int64, opts *DownloadOpts) dryRunKind {
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return dryRunNew
+	}
+	if opts.skipDuplicate && !opts.forceDownload && revisionCache.Unchanged(outputPath, docToken, revisionID) {
+		return dryRunSkipped
+	}
+	return dryRunModified
+}
+
+// DryRunCollector 并发安全地收集预览结果
+type DryRunCollector struct {
+	mu      sync.Mutex
+	entries []dryRunEntry
+}
+
+func (c *DryRunCollector) Add(relPath, docType string, kind dryRunKind) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, dryRunEntry{Path: relPath, DocType: docType, Kind: kind})
+}
+
+var dryRunCollector = &DryRunCollector{}
+
+// printDryRun 输出预览：按目录树缩进列出每篇文档，最后汇总各类数量
+func printDryRun() {
+	dryRunCollector.mu.Lock()
+	entries := make([]dryRunEntry, len(dryRunCollector.entries))
+	copy(entries, dryRunCollector.entries)
+	dryRunCollector.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	counts := make(map[dryRunKind]int)
+	fmt.Println()
+	fmt.Println(utils.L("🔍 变更预览（未写入任何文件）：", "🔍 Dry run (nothing written):"))
+	var printedDirs []string // 上一篇文档所在目录的各级名称，用于只输出新出现的目录
+	for _, e := range entries {
+		counts[e.Kind]++
+		parts := strings.Split(e.Path, "/")
+		dirs, name := parts[:len(parts)-1], parts[len(parts)-1]
+		common := 0
+		for common < len(dirs) && common < len(printedDirs) && dirs[common] == printedDirs[common] {
+			common++
+		}
+		for depth := common; depth < len(dirs); depth++ {
+			fmt.Printf("%s%s/\n", strings.Repeat("  ", depth+1), dirs[depth])
+		}
+		printedDirs = dirs
+		indent := strings.Repeat("  ", len(dirs)+1)
+		if e.Kind == dryRunHasChildren {
+			// 有子节点的文档即其子文档所在目录，目录行带上类型与说明
+			fmt.Printf(utils.L("%s%s/ [%s]（有子节点，跳过）\n", "%s%s/ [%s] (has children, skipped)\n"), indent, name, e.DocType)
+			printedDirs = append(dirs[:len(dirs):len(dirs)], name)
+			continue
+		}
+		fmt.Printf("%s%s %s [%s]\n", indent, dryRunMarks[e.Kind], name, e.DocType)
+	}
+	fmt.Printf(utils.L("新增 %d、修改 %d、跳过 %d、有子节点 %d（+ 新增 ~ 修改 = 未变）\n",
+		"%d new, %d modified, %d skipped, %d with children (+ new ~ modified = unchanged)\n"),
+		counts[dryRunNew], counts[dryRunModified], counts[dryRunSkipped], counts[dryRunHasChildren])
+}
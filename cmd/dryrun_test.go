@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDryRunClassifiesWithoutWriting(t *testing.T) {
+	dir := setupDownload(t)
+	dlConfig.Output.DryRun = true
+	dryRunCollector = &DryRunCollector{}
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxNew", "新文档", "正文")
+	feishu.addDocx("doxMod", "改过的文档", "正文")
+	feishu.addDocx("doxSame", "未变文档", "正文")
+
+	// 改过的文档只有旧文件、没有版本记录；未变文档的版本与远端一致（revision_id 均为 1）
+	for _, name := range []string{"改过的文档.md", "未变文档.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("旧内容"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	revisionCache.Set(filepath.Join(dir, "未变文档.md"), "doxSame", 1)
+	before := listFiles(t, dir)
+
+	var contentFetches atomic.Int32
+	feishu.hook = func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/blocks") {
+			contentFetches.Add(1)
+		}
+	}
+	opts := &DownloadOpts{outputDir: dir, skipDuplicate: true}
+	for _, token := range []string{"doxNew", "doxMod", "doxSame"} {
+		if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/"+token, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]dryRunKind{"新文档.md": dryRunNew, "改过的文档.md": dryRunModified, "未变文档.md": dryRunSkipped}
+	if len(dryRunCollector.entries) != len(want) {
+		t.Fatalf("entries = %+v, want %d", dryRunCollector.entries, len(want))
+	}
+	for _, e := range dryRunCollector.entries {
+		if kind, ok := want[filepath.Base(e.Path)]; !ok || e.Kind != kind || e.DocType != "docx" {
+			t.Errorf("entry %+v, want kind %v", e, kind)
+		}
+	}
+	if n := contentFetches.Load(); n != 0 {
+		t.Errorf("dry run fetched document content %d times, want 0", n)
+	}
+	if after := listFiles(t, dir); strings.Join(after, "\n") != strings.Join(before, "\n") {
+		t.Errorf("dry run changed the output directory:\nbefore %v\nafter  %v", before, after)
+	}
+}
+
+// listFiles 返回 dir 下所有文件与目录的相对路径及大小，用于比对是否写盘
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, fmt.Sprintf("%s (%d)", rel, info.Size()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,81 @@
+// Package main - 外链图片本地化
+// 将文档中引用的非飞书 media 外链图片下载到本地，并按需上传图床
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/picgo"
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// externalImagePattern 匹配 Markdown 中引用 http(s) 外链的图片，如 ![alt](https://...)
+var externalImagePattern = regexp.MustCompile(`!\[[^\]]*\]\((https?://[^)\s]+)\)`)
+
+// findExternalImageURLs 按出现顺序返回 Markdown 中去重后的外链图片 URL
+func findExternalImageURLs(markdown string) []string {
+	var urls []string
+	seen := make(map[string]struct{})
+	for _, m := range externalImagePattern.FindAllStringSubmatch(markdown, -1) {
+		if _, ok := seen[m[1]]; ok {
+			continue
+		}
+		seen[m[1]] = struct{}{}
+		urls = append(urls, m[1])
+	}
+	return urls
+}
+
+// localizeExternalImages 下载外链图片并把 Markdown 中的链接替换为本地路径或图床 URL
+// 单张下载失败时保留原链接，不影响文档导出
+func localizeExternalImages(ctx context.Context, client *core.Client, markdown, outputDir, imageDir string) string {
+	urls := findExternalImageURLs(markdown)
+	if len(urls) == 0 {
+		return markdown
+	}
+
+	picgoEnabled := dlConfig.PicGo.Enabled && picgo.IsAvailable()
+	urlToLink := make(map[string]string, len(urls))
+	urlByPath := make(map[string]string)
+
+	for _, u := range urls {
+		if picgoEnabled {
+			if cachedURL, ok := picgo.GetCached(core.ExternalImageName(u)); ok {
+				urlToLink[u] = cachedURL
+				continue
+			}
+		}
+		link, err := client.DownloadExternalImage(ctx, u, outputDir, imageDir)
+		if err != nil {
+			fmt.Printf(utils.L("⚠️  外链图片本地化失败，保留原链接: %v\n", "⚠️  Failed to localize external image, keeping original link: %v\n"), err)
+			continue
+		}
+		urlToLink[u] = link
+		if picgoEnabled {
+			urlByPath[filepath.Join(outputDir, link)] = u
+		}
+	}
+
+	// 启用图床时上传新下载的外链图片，成功后删除本地文件
+	if len(urlByPath) > 0 {
+		localPaths := make([]string, 0, len(urlByPath))
+		for p := range urlByPath {
+			localPaths = append(localPaths, p)
+		}
+		for fullPath, picgoURL := range picgo.BatchUpload(ctx, localPaths) {
+			urlToLink[urlByPath[fullPath]] = picgoURL
+			os.Remove(fullPath)
+		}
+	}
+
+	for u, link := range urlToLink {
+		markdown = strings.ReplaceAll(markdown, "("+u+")", "("+link+")")
+	}
+	return markdown
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Perfecto23/feishu2md/core"
+)
+
+func TestLocalizeExternalImages(t *testing.T) {
+	dir := setupDownload(t)
+	png := testPNG(t)
+	var hits atomic.Int32
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/a.png" {
+			http.NotFound(w, r)
+			return
+		}
+		hits.Add(1)
+		w.Write(png)
+	}))
+	defer site.Close()
+
+	okURL, missingURL := site.URL+"/a.png", site.URL+"/missing.png"
+	markdown := "![图一](" + okURL + ")\n\n![图一再次](" + okURL + ")\n\n![丢失](" + missingURL + ")\n\n![飞书](./img/imgA.png)\n"
+	got := localizeExternalImages(context.Background(), core.NewClient("cli_test", "secret"), markdown, dir, "img")
+
+	local := "./img/" + core.ExternalImageName(okURL) + ".png"
+	if strings.Count(got, "("+local+")") != 2 || strings.Contains(got, okURL) {
+		t.Errorf("external image was not localized to %s:\n%s", local, got)
+	}
+	if !strings.Contains(got, "("+missingURL+")") {
+		t.Errorf("failed download did not keep the original link:\n%s", got)
+	}
+	if !strings.Contains(got, "(./img/imgA.png)") {
+		t.Errorf("local image link changed:\n%s", got)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("external image fetched %d times, want 1", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(local))); err != nil {
+		t.Errorf("localized image not saved: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package main - 下载失败的文档
+// 文件夹下载时单篇文档失败不再中断其余文档，失败记录下来，
+// 全部下载结束后在汇总中统一列出，并以一个多错误返回
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// failedDoc 下载失败的文档
+type failedDoc struct {
+	Path string // 文档在输出目录中的位置
+	Err  error
+}
+
+// FailureCollector 并发安全地收集下载失败的文档
+type FailureCollector struct {
+	mu      sync.Mutex
+	entries []failedDoc
+}
+
+// Add 记录一篇下载失败的文档
+func (c *FailureCollector) Add(path string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, failedDoc{Path: path, Err: err})
+}
+
+// Entries 返回按路径排序的失败清单
+func (c *FailureCollector) Entries() []failedDoc {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]failedDoc, len(c.entries))
+	copy(entries, c.entries)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// Err 没有失败时返回 nil，否则返回包含全部失败原因的 *DocErrors
+func (c *FailureCollector) Err() error {
+	entries := c.Entries()
+	if len(entries) == 0 {
+		return nil
+	}
+	return &DocErrors{Docs: entries}
+}
+
+// DocErrors 多篇文档下载失败；Unwrap 返回各文档的错误，可用 errors.Is / errors.As 逐个判断
+type DocErrors struct {
+	Docs []failedDoc
+}
+
+func (e *DocErrors) Error() string {
+	return fmt.Sprintf(utils.L("%d 个文档下载失败", "%d documents failed to download"), len(e.Docs))
+}
+
+func (e *DocErrors) Unwrap() []error {
+	errs := make([]error, len(e.Docs))
+	for i, d := range e.Docs {
+		errs[i] = d.Err
+	}
+	return errs
+}
+
+// printFailures 列出下载失败的文档，没有时不输出
+func printFailures(c *FailureCollector) {
+	entries := c.Entries()
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Printf(utils.L("❌ %d 个文档下载失败：\n", "❌ %d documents failed to download:\n"), len(entries))
+	for _, e := range entries {
+		fmt.Printf("  - %s（%v）\n", e.Path, e.Err)
+	}
+}
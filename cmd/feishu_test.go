@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/urfave/cli/v2"
+)
+
+// fakeFeishu 模拟的飞书开放平台：鉴权接口固定成功，按路径返回预设的 JSON，未登记的路径返回 404 错误码
+type fakeFeishu struct {
+	*httptest.Server
+	routes map[string]string     // "METHOD /path" 或 "METHOD /path?query" -> 响应 JSON
+	hook   func(r *http.Request) // 非 nil 时在返回预设响应前调用，便于观察请求
+}
+
+// newFakeFeishu 启动模拟服务，测试结束时关闭
+func newFakeFeishu(t *testing.T) *fakeFeishu {
+	t.Helper()
+	f := &fakeFeishu{routes: make(map[string]string)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/open-apis/auth/v3/tenant_access_token/internal" {
+			fmt.Fprint(w, `{"code":0,"msg":"ok","tenant_access_token":"t-app","expire":7200}`)
+			return
+		}
+		if f.hook != nil {
+			f.hook(r)
+		}
+		// 带查询参数的登记优先，便于区分同一路径的不同请求（如不同父节点的子节点列表）
+		body, ok := f.routes[r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery]
+		if !ok {
+			body, ok = f.routes[r.Method+" "+r.URL.Path]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":1770002,"msg":"not found"}`)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// addDocx 登记一篇只含一段正文的新版文档及其修改时间
+func (f *fakeFeishu) addDocx(token, title, text string) {
+	f.addDocxBlocks(token, title, fmt.Sprintf(`{"block_type":2,"text":{"elements":[{"text_run":{"content":%q}}]}}`, text))
+}
+
+// addDocxBlocks 登记一篇新版文档及其修改时间：blocks 为根块下依次排列的子块 JSON，
+// 不含 block_id 与 parent_id，按顺序编号为 b1、b2…
+func (f *fakeFeishu) addDocxBlocks(token, title string, blocks ...string) {
+	f.routes["GET /open-apis/docx/v1/documents/"+token] = fmt.Sprintf(
+		`{"code":0,"data":{"document":{"document_id":%q,"revision_id":1,"title":%q}}}`, token, title)
+	ids := make([]string, len(blocks))
+	items := []string{""}
+	for i, b := range blocks {
+		ids[i] = fmt.Sprintf("%q", fmt.Sprintf("b%d", i+1))
+		items = append(items, fmt.Sprintf(`{"block_id":%s,"parent_id":%q,`, ids[i], token)+strings.TrimPrefix(b, "{"))
+	}
+	items[0] = fmt.Sprintf(`{"block_id":%q,"block_type":1,"page":{"elements":[{"text_run":{"content":%q}}]},"children":[%s]}`,
+		token, title, strings.Join(ids, ","))
+	f.routes["GET /open-apis/docx/v1/documents/"+token+"/blocks"] = `{"code":0,"data":{"has_more":false,"items":[` + strings.Join(items, ",") + `]}}`
+	f.routes["POST /open-apis/drive/v1/metas/batch_query"] = `{"code":0,"data":{"metas":[
+		{"doc_token":"any","doc_type":"docx","create_time":"1704067200","latest_modify_time":"1704067200"}]}}`
+}
+
+// addImage 登记一张图片素材，data 为下载接口返回的文件内容
+func (f *fakeFeishu) addImage(token string, data []byte) {
+	f.routes["GET /open-apis/drive/v1/medias/"+token+"/download"] = string(data)
+}
+
+// imageBlock 引用 token 图片的图片块 JSON，供 addDocxBlocks 使用
+func imageBlock(token string) string {
+	return fmt.Sprintf(`{"block_type":27,"image":{"token":%q,"width":1,"height":1}}`, token)
+}
+
+// testPNG 返回 1x1 的 PNG 图片
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// wikiNode 知识库中的一个节点
+type wikiNode struct {
+	Token, ObjToken, ObjType, Title string
+	HasChild                        bool
+}
+
+// addWikiSpace 登记知识空间的名称
+func (f *fakeFeishu) addWikiSpace(spaceID, name string) {
+	f.routes["GET /open-apis/wiki/v2/spaces/"+spaceID] = fmt.Sprintf(
+		`{"code":0,"data":{"space":{"space_id":%q,"name":%q}}}`, spaceID, name)
+}
+
+// addWikiNodes 登记 parent 的子节点列表（parent 为空时为顶层节点）及各节点的 get_node 信息；
+// 没有子节点的节点同时登记空的子节点列表，供下载前的子节点检查使用
+func (f *fakeFeishu) addWikiNodes(spaceID, parent string, nodes ...wikiNode) {
+	items := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		node := fmt.Sprintf(`{"space_id":%q,"node_token":%q,"parent_node_token":%q,"obj_token":%q,"obj_type":%q,"title":%q,"has_child":%t}`,
+			spaceID, n.Token, parent, n.ObjToken, n.ObjType, n.Title, n.HasChild)
+		items = append(items, node)
+		f.routes["GET /open-apis/wiki/v2/spaces/get_node?token="+n.Token] = `{"code":0,"data":{"node":` + node + `}}`
+		if !n.HasChild {
+			f.addWikiNodes(spaceID, n.Token)
+		}
+	}
+	route := "GET /open-apis/wiki/v2/spaces/" + spaceID + "/nodes"
+	body := `{"code":0,"data":{"has_more":false,"items":[` + strings.Join(items, ",") + `]}}`
+	if parent == "" {
+		f.routes[route] = body
+		return
+	}
+	// 遍历子节点时不指定分页大小，下载前的子节点检查每页 50 个
+	f.routes[route+"?parent_node_token="+parent] = body
+	f.routes[route+"?page_size=50&parent_node_token="+parent] = body
+}
+
+// clientOptions 指向模拟服务、不限流且快速重试的客户端选项
+func (f *fakeFeishu) clientOptions() []core.ClientOption {
+	return []core.ClientOption{
+		core.WithOpenBaseURL(f.URL),
+		core.WithRateLimit(core.RateLimitConfig{PerSecond: 1000, PerMinute: 100000, Burst: 1000}),
+		core.WithRetry(1, time.Millisecond),
+	}
+}
+
+// client 返回使用 clientOptions 的客户端
+func (f *fakeFeishu) client() *core.Client {
+	return core.NewClient("cli_test", "secret", f.clientOptions()...)
+}
+
+// runApp 以 args 运行命令行应用，命令中创建的客户端指向模拟服务；返回 Action 的错误而不退出进程
+func (f *fakeFeishu) runApp(t *testing.T, args ...string) error {
+	t.Helper()
+	extraClientOptions = f.clientOptions()
+	t.Cleanup(func() { extraClientOptions = nil })
+	app := newApp()
+	app.ExitErrHandler = func(*cli.Context, error) {}
+	return app.Run(append([]string{app.Name}, args...))
+}
+
+// setupDownload 在临时目录中运行下载：切换工作目录（缓存写入 .feishu2md/）并重置全局下载状态
+func setupDownload(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	dlConfig = *core.NewConfig("cli_test", "secret")
+	dlConfig.Output.OutputDir = dir
+	dlConfig.Output.SkipImgDownload = true
+	dlStats = &DownloadStats{}
+	logCollector = &LogCollector{}
+	dirFileNames = NewFileNameRegistry()
+	revisionCache = &RevisionCache{path: filepath.Join(".feishu2md", "revision-cache.json")}
+	return dir
+}
+
+// setCredentialEnv 设置 createCommonOpts 所需的应用凭据环境变量
+func setCredentialEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("FEISHU_APP_ID", "cli_test")
+	t.Setenv("FEISHU_APP_SECRET", "secret")
+}
+
+// newCLIContext 用应用的全局标志解析 args，返回供 createCommonOpts 使用的命令行上下文
+func newCLIContext(t *testing.T, args ...string) *cli.Context {
+	t.Helper()
+	app := newApp()
+	set := flag.NewFlagSet(app.Name, flag.ContinueOnError)
+	for _, f := range app.Flags {
+		if err := f.Apply(set); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(app, set, nil)
+}
+
+// captureStdout 运行 fn 并返回其间写入标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	defer func() { os.Stdout = orig }()
+	fn()
+	w.Close()
+	return string(<-done)
+}
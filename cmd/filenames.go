@@ -0,0 +1,94 @@
+// Package main - 批次内文件名去重
+// flat 布局下不同目录的同名文档会落到同一目录，需要在整个批次范围内分配唯一文件名；
+// 其他布局下同一目录内的同名文档依次追加 -1、-2 等后缀，避免互相覆盖；
+// 知识库中同名的兄弟目录节点依次追加 -2、-3 等后缀，各自映射到不同目录
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileNameRegistry 记录本批次已分配的文件名及其所属文档
+type FileNameRegistry struct {
+	mu     sync.Mutex
+	owners map[string]string // 小写文件名 -> docToken（兼容大小写不敏感的文件系统）
+}
+
+// NewFileNameRegistry 创建空的文件名登记表
+func NewFileNameRegistry() *FileNameRegistry {
+	return &FileNameRegistry{owners: make(map[string]string)}
+}
+
+// Reserve 为文档分配唯一文件名
+// 文件名未被占用或已属于同一文档时原样返回；冲突时在扩展名前追加 docToken 前 6 位，如 "笔记-AbCdEf.md"，
+// 仍冲突则依次尝试完整 docToken 与数字序号
+func (r *FileNameRegistry) Reserve(name, docToken string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	short := docToken
+	if len(short) > 6 {
+		short = short[:6]
+	}
+	candidates := []string{name, base + "-" + short + ext, base + "-" + docToken + ext}
+	for i := 0; ; i++ {
+		candidate := fmt.Sprintf("%s-%s-%d%s", base, docToken, i-len(candidates)+2, ext)
+		if i < len(candidates) {
+			candidate = candidates[i]
+		}
+		key := strings.ToLower(candidate)
+		if owner, taken := r.owners[key]; !taken || owner == docToken {
+			r.owners[key] = docToken
+			return candidate
+		}
+	}
+}
+
+// ReserveInDir 为 dir 目录下的文档分配唯一文件名
+// 文件名未被占用或已属于同一文档时原样返回；冲突时在扩展名前依次追加 -1、-2 等序号
+func (r *FileNameRegistry) ReserveInDir(dir, name, docToken string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; ; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		key := strings.ToLower(filepath.Join(dir, candidate))
+		if owner, taken := r.owners[key]; !taken || owner == docToken {
+			r.owners[key] = docToken
+			return candidate
+		}
+	}
+}
+
+// ReserveDir 为 parent 下的目录节点分配唯一目录路径
+// 目录名未被占用或已属于同一节点时返回 parent/name；冲突时依次追加 -2、-3 等序号，目录名中的 . 不视为扩展名
+func (r *FileNameRegistry) ReserveDir(parent, name, nodeToken string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", name, i)
+		}
+		dir := filepath.Join(parent, candidate)
+		key := strings.ToLower(dir)
+		if owner, taken := r.owners[key]; !taken || owner == nodeToken {
+			r.owners[key] = nodeToken
+			return dir
+		}
+	}
+}
+
+// dirFileNames 本次运行各输出目录内已分配的文件名，每次下载开始时重置
+var dirFileNames = NewFileNameRegistry()
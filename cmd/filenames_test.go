@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReserveInDirAppendsSuffixes(t *testing.T) {
+	r := NewFileNameRegistry()
+	if got := r.ReserveInDir("out", "笔记.md", "doxA"); got != "笔记.md" {
+		t.Errorf("first = %q, want 笔记.md", got)
+	}
+	if got := r.ReserveInDir("out", "笔记.md", "doxB"); got != "笔记-1.md" {
+		t.Errorf("second = %q, want 笔记-1.md", got)
+	}
+	// 大小写不敏感的文件系统上视为同名
+	if got := r.ReserveInDir("out", "笔记.MD", "doxC"); got != "笔记-2.MD" {
+		t.Errorf("third = %q, want 笔记-2.MD", got)
+	}
+	// 同一文档再次分配得到相同的文件名
+	if got := r.ReserveInDir("out", "笔记.md", "doxB"); got != "笔记-1.md" {
+		t.Errorf("doxB again = %q, want 笔记-1.md", got)
+	}
+	// 不同目录互不影响
+	if got := r.ReserveInDir(filepath.Join("out", "sub"), "笔记.md", "doxB"); got != "笔记.md" {
+		t.Errorf("other dir = %q, want 笔记.md", got)
+	}
+}
+
+func TestReserveFileNamesIsOrderIndependent(t *testing.T) {
+	setupDownload(t)
+	dlConfig.Output.TitleAsFilename = true
+
+	assign := func(order []string, downloadOrder []string) map[string]string {
+		dirFileNames = NewFileNameRegistry()
+		opts := &DownloadOpts{outputDir: "out"}
+		var planned []plannedDoc
+		for _, token := range order {
+			planned = append(planned, plannedDoc{"周报", token, opts})
+		}
+		reserveFileNames(planned)
+		// 下载按任意顺序完成，分配结果与枚举时一致
+		names := make(map[string]string)
+		for _, token := range downloadOrder {
+			names[token] = outputFileName("周报", token, opts)
+		}
+		return names
+	}
+
+	a := assign([]string{"doxB", "doxA", "doxC"}, []string{"doxC", "doxB", "doxA"})
+	b := assign([]string{"doxC", "doxA", "doxB"}, []string{"doxA", "doxC", "doxB"})
+	want := map[string]string{"doxA": "周报.md", "doxB": "周报-1.md", "doxC": "周报-2.md"}
+	for token, name := range want {
+		if a[token] != name || b[token] != name {
+			t.Errorf("%s = %q / %q, want %q", token, a[token], b[token], name)
+		}
+	}
+}
+
+func TestReserveDirSameNamedSiblings(t *testing.T) {
+	run := func() (string, string) {
+		r := NewFileNameRegistry()
+		a := r.ReserveDir(".", "设计", "wikA")
+		b := r.ReserveDir(".", "设计", "wikB")
+		// 同一节点再次分配得到相同目录
+		if again := r.ReserveDir(".", "设计", "wikB"); again != b {
+			t.Errorf("wikB again = %q, want %q", again, b)
+		}
+		return a, b
+	}
+
+	a, b := run()
+	if a != "设计" || b != "设计-2" {
+		t.Fatalf("ReserveDir() = %q, %q, want 设计, 设计-2", a, b)
+	}
+	// 再次运行（新的登记表、相同的枚举顺序）分配结果不变，增量导出不会把文档写到另一个目录
+	if a2, b2 := run(); a2 != a || b2 != b {
+		t.Errorf("rerun = %q, %q, want %q, %q", a2, b2, a, b)
+	}
+
+	// 不同父目录下的同名目录互不影响
+	r := NewFileNameRegistry()
+	r.ReserveDir(".", "设计", "wikA")
+	if got := r.ReserveDir("产品", "设计", "wikC"); got != filepath.Join("产品", "设计") {
+		t.Errorf("other parent = %q, want %q", got, filepath.Join("产品", "设计"))
+	}
+}
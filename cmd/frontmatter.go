@@ -0,0 +1,291 @@
+// Package main - 文档 frontmatter 生成
+// docx 与电子表格共用：标题、时间、分类、标签等元信息输出为 YAML frontmatter，HTML 导出时写入 <head>；
+// 可通过 Go text/template 模板自定义 frontmatter 的字段与格式（如 Hugo/Zola 的 TOML）
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/88250/lute"
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// 内置 frontmatter 风格
+const (
+	frontmatterFlavorDefault = "default" // categories 输出为单个分类名
+	frontmatterFlavorHexo    = "hexo"    // categories 输出为路径层级列表，Hexo 按列表顺序嵌套为父子分类
+)
+
+// draftPattern 识别草稿标题的规则，启用 --draft 时非 nil
+var draftPattern *regexp.Regexp
+
+// draftStatus 按标题中的草稿标记映射 frontmatter 的 draft 值；飞书接口不提供文档的发布状态
+func draftStatus(title string) string {
+	return strconv.FormatBool(draftPattern.MatchString(title))
+}
+
+// outputLocation frontmatter 时间、--since 日期与图片按月归档使用的时区，由 --timezone 配置
+var outputLocation = time.FixedZone("CST", 8*3600)
+
+// loadOutputLocation 加载 IANA 时区，名称无效或系统缺少时区数据时提示并回退到固定的 +08:00
+func loadOutputLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		fmt.Printf(utils.L("⚠️  无法加载时区 %q，使用 +08:00: %v\n", "⚠️  Cannot load time zone %q, using +08:00: %v\n"), name, err)
+		return time.FixedZone("CST", 8*3600)
+	}
+	return loc
+}
+
+// frontmatterTemplate 自定义 frontmatter 模板，为 nil 时使用内置的 YAML 格式
+var frontmatterTemplate *template.Template
+
+// frontmatterData 传给自定义模板的文档元数据
+// 时间字段 Date/Updated 为输出时区（默认 +08:00）的 RFC3339 字符串，DateTime/UpdatedTime 可用 .Format 自行格式化
+type frontmatterData struct {
+	htmlPageMeta
+	Token       string
+	DocType     string
+	DateTime    time.Time
+	UpdatedTime time.Time
+}
+
+// frontmatterFuncs 模板可用的辅助函数
+var frontmatterFuncs = template.FuncMap{
+	"yaml":  escapeYAML,    // 按需加引号的 YAML 标量
+	"quote": strconv.Quote, // 双引号字符串，YAML 与 TOML 通用
+	"join":  strings.Join,  // 连接字符串列表
+	"now":   time.Now,      // 当前时间
+}
+
+// loadFrontmatterTemplate 解析自定义 frontmatter 模板文件；path 为空时使用内置格式，
+// 读取或解析失败时提示并回退到内置格式
+func loadFrontmatterTemplate(path string) *template.Template {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var tmpl *template.Template
+		tmpl, err = template.New("frontmatter").Funcs(frontmatterFuncs).Parse(string(data))
+		if err == nil {
+			return tmpl
+		}
+	}
+	fmt.Printf(utils.L("⚠️  frontmatter 模板 %s 无效，使用内置格式: %v\n", "⚠️  Invalid frontmatter template %s, using the built-in format: %v\n"), path, err)
+	return nil
+}
+
+// escapeYAML 若包含特殊字符，则使用双引号并转义
+func escapeYAML(s string) string {
+	special := ":-#{}[],&*?|\"<>=!%@`) \\" // 包含引号、反斜线与常见特殊字符
+	if strings.ContainsAny(s, special) {
+		// 转义双引号与反斜线
+		s = strings.ReplaceAll(s, "\\", "\\\\")
+		s = strings.ReplaceAll(s, "\"", "\\\"")
+		return "\"" + s + "\""
+	}
+	return s
+}
+
+// docFrontmatter 一篇文档的元信息
+type docFrontmatter struct {
+	yaml      string       // Markdown 输出的 YAML frontmatter
+	page      htmlPageMeta // HTML 输出写入 <head> 的元信息
+	updatedAt time.Time    // 文档最近修改时间（用于站点地图与文件 mtime），未知时为零值
+}
+
+// buildFrontmatter 获取文档时间并结合层级分类、标签生成元信息，docType 为 docx、sheet 等云文档类型
+func buildFrontmatter(ctx context.Context, client *core.Client, docToken, docType, title string, opts *DownloadOpts) docFrontmatter {
+	fmTitle := title
+	// 获取时间元数据
+	var fmDate, fmUpdated string
+	var fmDateAt, fmUpdatedAt time.Time
+	var docUpdatedAt time.Time // 文档最近修改时间（用于站点地图与文件 mtime），未知时为零值
+	if createdAt, updatedAt, terr := client.GetFileTimes(ctx, docToken, docType); terr == nil {
+		// 按输出时区（默认东八区 +08:00）格式化
+		loc := outputLocation
+		if createdAt != nil {
+			fmDateAt = createdAt.In(loc)
+			fmDate = fmDateAt.Format("2006-01-02T15:04:05-07:00")
+		}
+		if updatedAt != nil {
+			fmUpdatedAt = updatedAt.In(loc)
+			fmUpdated = fmUpdatedAt.Format("2006-01-02T15:04:05-07:00")
+			docUpdatedAt = updatedAt.In(loc)
+		}
+	}
+	// 兜底：若时间缺失，使用当前时间
+	if fmDate == "" || fmUpdated == "" {
+		now := time.Now().In(outputLocation)
+		if fmDate == "" {
+			fmDateAt = now
+			fmDate = now.Format("2006-01-02T15:04:05-07:00")
+		}
+		if fmUpdated == "" {
+			fmUpdatedAt = now
+			fmUpdated = now.Format("2006-01-02T15:04:05-07:00")
+		}
+	}
+	// categories: 使用提供的 category，或取 tags 第一个，或使用默认分类
+	fmCategory := opts.category
+	if fmCategory == "" && len(opts.tags) > 0 {
+		fmCategory = opts.tags[0] // 使用第一个 tag 作为 category
+	}
+	if fmCategory == "" {
+		fmCategory = dlConfig.Output.DefaultCategory // 默认分类，为空则不输出
+	}
+
+	page := htmlPageMeta{
+		Title:      fmTitle,
+		Date:       fmDate,
+		Updated:    fmUpdated,
+		Category:   fmCategory,
+		Categories: opts.categories,
+		Tags:       opts.tags,
+		ID:         docToken,
+	}
+	if dlConfig.Output.UTCDates {
+		page.DateUTC = fmDateAt.UTC().Format(time.RFC3339)
+		page.UpdatedUTC = fmUpdatedAt.UTC().Format(time.RFC3339)
+	}
+	if dlConfig.Output.Permalink {
+		page.Permalink = derivePermalink(docToken)
+	}
+	if dlConfig.Output.Author {
+		page.Author, page.Avatar = docAuthor(ctx, client, docToken, docType, opts)
+	}
+	if draftPattern != nil {
+		page.Draft = draftStatus(fmTitle)
+	}
+
+	// --no-frontmatter 时只输出正文；时间等元数据仍用于图片归档、站点地图与文件修改时间
+	var yaml string
+	switch {
+	case dlConfig.Output.SkipFrontmatter:
+	case frontmatterTemplate != nil:
+		yaml = renderFrontmatterTemplate(page, docToken, docType, fmDateAt, fmUpdatedAt)
+	default:
+		yaml = renderFrontmatterYAML(page)
+	}
+	return docFrontmatter{yaml: yaml, page: page, updatedAt: docUpdatedAt}
+}
+
+// renderFrontmatterYAML 生成内置格式的 YAML frontmatter
+func renderFrontmatterYAML(page htmlPageMeta) string {
+	var fmBuilder strings.Builder
+	fmBuilder.WriteString("---\n")
+	fmBuilder.WriteString("title: " + escapeYAML(page.Title) + "\n")
+	fmBuilder.WriteString("date: " + page.Date + "\n")
+	fmBuilder.WriteString("updated: " + page.Updated + "\n")
+	// 部分静态站点生成器只接受 UTC 时间，按需同时输出
+	if page.DateUTC != "" {
+		fmBuilder.WriteString("date_utc: " + page.DateUTC + "\n")
+		fmBuilder.WriteString("updated_utc: " + page.UpdatedUTC + "\n")
+	}
+	if dlConfig.Output.FrontmatterFlavor == frontmatterFlavorHexo && len(page.Categories) > 1 {
+		// Hexo 中 categories 列表的各项依次为父子分类，如 [技术, 后端] 表示 技术 > 后端
+		fmBuilder.WriteString("categories:\n")
+		for _, c := range page.Categories {
+			fmBuilder.WriteString("  - " + escapeYAML(c) + "\n")
+		}
+	} else if page.Category != "" {
+		fmBuilder.WriteString("categories: " + escapeYAML(page.Category) + "\n")
+	}
+
+	// tags: 输出标签列表
+	if len(page.Tags) > 0 {
+		fmBuilder.WriteString("tags:\n")
+		for _, tag := range page.Tags {
+			if strings.TrimSpace(tag) == "" {
+				continue
+			}
+			fmBuilder.WriteString("  - " + escapeYAML(tag) + "\n")
+		}
+	}
+	// id: 使用 docToken 作为唯一标识
+	fmBuilder.WriteString("id: " + escapeYAML(page.ID) + "\n")
+	if page.Permalink != "" {
+		fmBuilder.WriteString("permalink: " + page.Permalink + "\n")
+	}
+	if page.Author != "" {
+		fmBuilder.WriteString("author: " + escapeYAML(page.Author) + "\n")
+	}
+	if page.Avatar != "" {
+		fmBuilder.WriteString("avatar: " + escapeYAML(page.Avatar) + "\n")
+	}
+	if page.Draft != "" {
+		fmBuilder.WriteString("draft: " + page.Draft + "\n")
+	}
+	fmBuilder.WriteString("---\n\n")
+	return fmBuilder.String()
+}
+
+// renderFrontmatterTemplate 用自定义模板生成 frontmatter，执行失败时回退到内置格式
+func renderFrontmatterTemplate(page htmlPageMeta, docToken, docType string, dateAt, updatedAt time.Time) string {
+	data := frontmatterData{
+		htmlPageMeta: page,
+		Token:        docToken,
+		DocType:      docType,
+		DateTime:     dateAt,
+		UpdatedTime:  updatedAt,
+	}
+	var buf strings.Builder
+	if err := frontmatterTemplate.Execute(&buf, data); err != nil {
+		fmt.Printf(utils.L("⚠️  %s 的 frontmatter 模板执行失败，使用内置格式: %v\n", "⚠️  Frontmatter template failed for %s, using the built-in format: %v\n"), page.Title, err)
+		return renderFrontmatterYAML(page)
+	}
+	if out := strings.TrimSpace(buf.String()); out != "" {
+		return out + "\n\n"
+	}
+	return ""
+}
+
+// docAuthor 返回文档作者姓名与头像链接；头像下载到图片目录，--no-img 时引用原始链接
+// 获取失败时提示并返回空值，不影响文档导出
+func docAuthor(ctx context.Context, client *core.Client, docToken, docType string, opts *DownloadOpts) (name, avatar string) {
+	author, err := client.GetDocAuthor(ctx, docToken, docType)
+	if err != nil {
+		fmt.Printf(utils.L("⚠️  获取文档 %s 的作者失败: %v\n", "⚠️  Failed to fetch the author of %s: %v\n"), docToken, err)
+		return "", ""
+	}
+	if author.AvatarURL == "" || dlConfig.Output.SkipImgDownload {
+		return author.Name, author.AvatarURL
+	}
+	link, err := client.DownloadExternalImage(ctx, author.AvatarURL, opts.outputDir, dlConfig.Output.ImageDir)
+	if err != nil {
+		fmt.Printf(utils.L("⚠️  作者头像下载失败，引用原始链接: %v\n", "⚠️  Failed to download the author avatar, linking the original URL: %v\n"), err)
+		return author.Name, author.AvatarURL
+	}
+	return author.Name, link
+}
+
+// docCommentsSection 返回文档末尾的评论章节，没有评论时为空
+// 获取失败时提示并返回空值，不影响文档导出
+func docCommentsSection(ctx context.Context, client *core.Client, docToken string) string {
+	comments, err := client.GetDocxComments(ctx, docToken)
+	if err != nil {
+		fmt.Printf(utils.L("⚠️  获取文档 %s 的评论失败: %v\n", "⚠️  Failed to fetch the comments of %s: %v\n"), docToken, err)
+		return ""
+	}
+	if len(comments) == 0 {
+		return ""
+	}
+	return "\n\n" + core.RenderComments(comments)
+}
+
+// apply 合并元信息与正文；HTML 导出时元信息改为写入 <head> 的 <meta> 标签
+func (fm docFrontmatter) apply(engine *lute.Lute, body string) string {
+	if dlConfig.Output.UseHTMLTags {
+		return renderHTMLPage(engine, fm.page, body)
+	}
+	return fm.yaml + body
+}
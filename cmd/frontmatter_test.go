@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/core"
+)
+
+func TestTimezoneUTC(t *testing.T) {
+	dir := setupDownload(t)
+	setCredentialEnv(t)
+	t.Setenv("OUTPUT_TIMEZONE", "")
+	defaultLocation := outputLocation
+	t.Cleanup(func() {
+		outputLocation = defaultLocation
+		core.SetDisplayLocation(defaultLocation)
+	})
+
+	// 默认时区（东八区）下 --since 的日期从当地零点起算
+	_, config, err := createCommonOpts(newCLIContext(t, "--since", "2024-01-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2023, 12, 31, 16, 0, 0, 0, time.UTC); !config.Output.Since.Equal(want) {
+		t.Errorf("default --since = %v, want %v", config.Output.Since, want)
+	}
+
+	_, config, err = createCommonOpts(newCLIContext(t, "--timezone", "UTC", "--since", "2024-01-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !config.Output.Since.Equal(want) {
+		t.Errorf("--timezone UTC --since = %v, want %v", config.Output.Since, want)
+	}
+
+	// frontmatter 时间按 UTC 输出（样例文档创建与修改时间均为 2024-01-01T00:00:00Z）
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxGood", "好文档", "正文内容")
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxGood", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "好文档.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"date: 2024-01-01T00:00:00+00:00", "updated: 2024-01-01T00:00:00+00:00"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("frontmatter missing %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestHexoNestedCategories(t *testing.T) {
+	setupDownload(t)
+	page := htmlPageMeta{Title: "接口设计", Date: "2024-01-01", Updated: "2024-01-01", Category: "技术", Categories: []string{"技术", "后端", "Go: 进阶"}}
+
+	dlConfig.Output.FrontmatterFlavor = frontmatterFlavorHexo
+	got := renderFrontmatterYAML(page)
+	if want := "categories:\n  - 技术\n  - 后端\n  - " + escapeYAML("Go: 进阶") + "\n"; !strings.Contains(got, want) {
+		t.Errorf("hexo frontmatter missing nested categories %q:\n%s", want, got)
+	}
+
+	// 只有一层目录时 Hexo 也输出单个分类
+	single := page
+	single.Categories = []string{"技术"}
+	if got := renderFrontmatterYAML(single); !strings.Contains(got, "categories: 技术\n") {
+		t.Errorf("hexo single category:\n%s", got)
+	}
+
+	dlConfig.Output.FrontmatterFlavor = frontmatterFlavorDefault
+	if got := renderFrontmatterYAML(page); !strings.Contains(got, "categories: 技术\n") || strings.Contains(got, "  - 后端") {
+		t.Errorf("default flavor should keep a single category:\n%s", got)
+	}
+}
+
+func TestWikiTreeHexoCategories(t *testing.T) {
+	dir := setupDownload(t)
+	dlConfig.Output.FrontmatterFlavor = frontmatterFlavorHexo
+	feishu := newFakeFeishu(t)
+	feishu.addWikiNodes("spc1", "", wikiNode{Token: "wikRoot", ObjToken: "doxRoot", ObjType: "docx", Title: "根", HasChild: true})
+	feishu.addWikiNodes("spc1", "wikRoot", wikiNode{Token: "wikD1", ObjToken: "bas1", ObjType: "bitable", Title: "技术", HasChild: true})
+	feishu.addWikiNodes("spc1", "wikD1", wikiNode{Token: "wikD2", ObjToken: "bas2", ObjType: "bitable", Title: "后端", HasChild: true})
+	feishu.addWikiNodes("spc1", "wikD2", wikiNode{Token: "wikA", ObjToken: "doxA", ObjType: "docx", Title: "接口设计"})
+	feishu.addDocx("doxA", "接口设计", "正文")
+
+	opts := &DownloadOpts{outputDir: dir, spaceID: "spc1"}
+	if err := downloadWikiChildren(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/wikRoot", opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "技术", "后端", "接口设计.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "categories:\n  - 技术\n  - 后端\n") {
+		t.Errorf("frontmatter missing nested categories:\n%s", data)
+	}
+}
@@ -0,0 +1,65 @@
+// Package main - 完整 HTML 页面导出
+// --html 时将 Markdown 渲染为独立的 HTML 页面，frontmatter 中的元信息写入 <head> 的 <meta> 标签
+package main
+
+import (
+	"html"
+	"strings"
+
+	"github.com/88250/lute"
+)
+
+// htmlPageMeta 页面元信息，字段与 Markdown frontmatter 一一对应，为空的字段不输出
+type htmlPageMeta struct {
+	Title      string
+	Date       string
+	Updated    string
+	DateUTC    string
+	UpdatedUTC string
+	Category   string
+	Categories []string // 分类层级，仅 hexo 风格输出为嵌套分类
+	Tags       []string
+	ID         string
+	Permalink  string
+	Author     string
+	Avatar     string
+	Draft      string // "true" 或 "false"，未启用 --draft 时为空
+}
+
+// renderHTMLPage 将 Markdown 正文渲染为包含 <head> 元信息的完整 HTML 页面
+func renderHTMLPage(engine *lute.Lute, meta htmlPageMeta, markdown string) string {
+	var tags []string
+	for _, tag := range meta.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	buf := new(strings.Builder)
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	buf.WriteString("<meta charset=\"utf-8\">\n")
+	buf.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	buf.WriteString("<title>" + html.EscapeString(meta.Title) + "</title>\n")
+	for _, m := range [][2]string{
+		{"date", meta.Date},
+		{"updated", meta.Updated},
+		{"date_utc", meta.DateUTC},
+		{"updated_utc", meta.UpdatedUTC},
+		{"categories", meta.Category},
+		{"tags", strings.Join(tags, ",")},
+		{"id", meta.ID},
+		{"permalink", meta.Permalink},
+		{"author", meta.Author},
+		{"avatar", meta.Avatar},
+		{"draft", meta.Draft},
+	} {
+		if m[1] == "" {
+			continue
+		}
+		buf.WriteString("<meta name=\"" + m[0] + "\" content=\"" + html.EscapeString(m[1]) + "\">\n")
+	}
+	buf.WriteString("</head>\n<body>\n<article>\n")
+	buf.WriteString(engine.Md2HTML(markdown))
+	buf.WriteString("</article>\n</body>\n</html>\n")
+	return buf.String()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Perfecto23/feishu2md/utils"
 	"github.com/urfave/cli/v2"
 )
 
@@ -20,6 +21,11 @@ const envTemplate = `# ====================================
 FEISHU_APP_ID=your_app_id_here
 FEISHU_APP_SECRET=your_app_secret_here
 
+# 以用户身份访问（可选）：填写 user_access_token 后，可下载本人有权限、但未共享给应用的个人文档
+# 与应用凭据同时配置时优先使用 user token；只配置 user token 时可不填写上面两项
+# user_access_token 通过 OAuth 授权获取，有效期约 2 小时，过期后需重新获取
+# FEISHU_USER_ACCESS_TOKEN=u-xxxxxxxx
+
 # ----------------------------------
 # 知识库配置（可选）
 # ----------------------------------
@@ -36,7 +42,7 @@ FEISHU_APP_SECRET=your_app_secret_here
 # ----------------------------------
 # 输出配置（可选）
 # ----------------------------------
-# 文档输出目录
+# 文档输出目录（命令行 --output/-o 优先）
 # 默认: ./dist
 # OUTPUT_DIR=./dist
 
@@ -44,6 +50,67 @@ FEISHU_APP_SECRET=your_app_secret_here
 # 默认: img
 # IMAGE_DIR=img
 
+# 图片按文档修改时间归档到 YYYY/MM 子目录（如 img/2024/05/xxx.png），图床上传路径同样追加
+# IMAGE_BY_MONTH=true
+
+# 重新编码 JPEG 图片的质量（1-100），结果不比原图小时保留原图
+# 默认: 85
+# IMAGE_QUALITY=85
+
+# 图片输出格式：keep（保持原格式，仅压缩）、png、jpeg 或 webp（WebP 保持原样）
+# 默认: keep
+# IMAGE_FORMAT=keep
+
+# frontmatter 时间、日期提醒、--since 日期与图片按月归档使用的 IANA 时区（如 UTC、America/New_York）
+# 默认: Asia/Shanghai
+# OUTPUT_TIMEZONE=Asia/Shanghai
+
+# 标题文件名（不含扩展名）的字节上限，超长时按完整字符截断
+# 默认: 200
+# MAX_FILENAME_BYTES=200
+
+# 站点地图前缀（wiki / wiki-tree 下载完成后生成 sitemap.xml）
+# 文档 URL = 前缀 + 文档相对路径（去掉 .md 扩展名）
+# SITEMAP_BASE_URL=https://blog.example.com/docs
+
+# frontmatter 缺省分类（无法从目录推导分类时使用）
+# 默认: 未分类；设为空值则不输出 categories
+# DEFAULT_CATEGORY=未分类
+
+# 自定义 frontmatter 模板（Go text/template，可用字段见 README）
+# 模板无效时使用内置 YAML 格式
+# FRONTMATTER_TEMPLATE=./frontmatter.tmpl
+
+# 内置 frontmatter 风格：default 或 hexo（categories 输出为目录层级列表，Hexo 嵌套为父子分类）
+# FRONTMATTER_FLAVOR=hexo
+
+# 水印文本块识别规则（正则，配合 --strip-watermark 使用）
+# 默认识别"内部资料""仅供内部使用""请勿外传"等常见水印用语
+# WATERMARK_PATTERN=^内部资料.*$
+
+# 草稿标题识别规则（正则，配合 --draft 使用，匹配的文档输出 draft: true）
+# 默认识别以 草稿、未发布、未完成、Draft、WIP 等标记开头的标题
+# DRAFT_PATTERN=^\[草稿\]
+
+# 公式图片识别服务（疑似公式的图片 POST 给该地址，响应 {"latex": "..."} 时替换为 LaTeX）
+# FORMULA_OCR_URL=http://localhost:8502/predict
+
+# Markdown 格式化选项（true/false）
+# LUTE_AUTO_SPACE: 在中西文之间自动插入空格，默认 true
+# LUTE_FIX_TERM_TYPO: 修正常见术语拼写（如 github -> GitHub），默认 false
+# LUTE_AUTO_SPACE=true
+# LUTE_FIX_TERM_TYPO=false
+
+# 跨进程共享的限流预算文件
+# 同一机器上对同一租户同时运行多个 feishu2md 时指向同一文件，合计不超过飞书配额
+# SHARED_RATE_LIMIT_FILE=/tmp/feishu2md-ratelimit
+
+# 飞书 API 调用速率（正整数），应与应用的实际配额一致
+# 默认: 每秒 5 次、每分钟 100 次；突发数默认与每秒次数相同
+# FEISHU_RATE_PER_SECOND=5
+# FEISHU_RATE_PER_MINUTE=100
+# FEISHU_RATE_BURST=5
+
 
 # ====================================
 # PicGo 图床配置（可选）
@@ -74,6 +141,29 @@ FEISHU_APP_SECRET=your_app_secret_here
 # 值: true/false 或 1/0
 PICGO_ENABLED=false
 
+# ----------------------------------
+# 备用图床（可选）
+# ----------------------------------
+# 主图床（picgo 默认配置）上传失败时，依次使用以下 picgo 配置文件重试
+# 多个路径用逗号分隔，每个文件是一份独立的 picgo 配置（picgo -c <path>）
+# PICGO_BACKUP_CONFIGS=~/.picgo/backup-github.json,~/.picgo/backup-smms.json
+
+# 图床 URL 输出协议：https、http 或 //（相对协议），避免站点与图床协议不一致产生混合内容
+# PICGO_URL_SCHEME=//
+
+# 图床上传缓存有效期（Go 时长格式，如 720h 即 30 天），过期后重新上传；默认永不过期
+# PICGO_CACHE_TTL=720h
+
+# 单个图床上传失败后的重试次数，仍失败时保留本地图片；默认 3
+# PICGO_UPLOAD_RETRIES=3
+
+# 整个进程同时进行的图床上传数上限，所有文档共用；上传跟不上时暂停图片下载；默认 10
+# PICGO_CONCURRENCY=10
+
+# 按文档划分图床目录：上传路径追加 docToken（如 images/<docToken>/xxx.png），便于按文档管理与删除
+# 支持 github、aliyun、tcyun、qiniu、upyun、aws-s3
+# PICGO_DOC_PREFIX=true
+
 
 # ----------------------------------
 # 使用说明
@@ -93,6 +183,20 @@ PICGO_ENABLED=false
 # 4. picgo config plugin compress   # 配置压缩选项（可选）
 # 5. 设置 PICGO_ENABLED=true        # 启用 PicGo
 #
+# 使用 AWS S3 / MinIO 图床:
+#   picgo install s3 && picgo use uploader aws-s3
+#   在 ~/.picgo/config.json 的 picBed.aws-s3 中填写 accessKeyID、secretAccessKey、bucketName，
+#   MinIO 另需 endpoint（自建服务地址）与 pathStyleAccess=true，可用 urlPrefix 指定 CDN 域名
+#
+# 使用 SM.MS / GitHub / 七牛云 图床（HTTP API 图床，需要鉴权 token 或密钥）:
+#   picgo set uploader smms && picgo use uploader smms
+#     picBed.smms 填写 token（sm.ms 用户中心生成的 API Token）
+#   picgo set uploader github && picgo use uploader github
+#     picBed.github 填写 repo（用户名/仓库）、branch、token（有 repo 权限的 Personal Access Token）
+#   picgo set uploader qiniu && picgo use uploader qiniu
+#     picBed.qiniu 填写 accessKey、secretKey、bucket、url（访问域名）、area（存储区域，如 z0）
+#   下载开始前会检查上述必填项；图床限流时至少等待 30 秒再重试，token 无效时不再重试
+#
 # 注意: .env 文件包含敏感信息，请勿提交到 Git 仓库
 #       本项目的 .gitignore 已默认忽略 .env 文件
 `
@@ -105,29 +209,31 @@ func handleInitCommand(ctx *cli.Context) error {
 	// 检查文件是否已存在
 	if !force {
 		if _, err := os.Stat(filename); err == nil {
-			return cli.Exit(fmt.Sprintf("❌ 文件 %s 已存在\n"+
-				"使用 --force 参数强制覆盖，或手动删除后重试", filename), 1)
+			return cli.Exit(fmt.Sprintf(utils.L("❌ 文件 %s 已存在\n"+
+				"使用 --force 参数强制覆盖，或手动删除后重试",
+				"❌ File %s already exists\n"+
+					"Use --force to overwrite, or delete it and retry"), filename), 1)
 		}
 	}
 
 	// 写入配置文件
 	if err := os.WriteFile(filename, []byte(envTemplate), 0644); err != nil {
-		return cli.Exit(fmt.Sprintf("❌ 创建配置文件失败: %v", err), 1)
+		return cli.Exit(fmt.Sprintf(utils.L("❌ 创建配置文件失败: %v", "❌ Failed to create config file: %v"), err), 1)
 	}
 
 	// 成功提示
-	fmt.Println("✅ 配置文件已创建: " + filename)
+	fmt.Println(utils.L("✅ 配置文件已创建: ", "✅ Config file created: ") + filename)
 	fmt.Println()
-	fmt.Println("📝 后续步骤:")
-	fmt.Println("  1. 编辑配置文件: vim .env  # 或使用你喜欢的编辑器")
-	fmt.Println("  2. 填写必需的配置项（至少需要 FEISHU_APP_ID 和 FEISHU_APP_SECRET）")
-	fmt.Println("  3. 开始使用: feishu2md document <url>")
+	fmt.Println(utils.L("📝 后续步骤:", "📝 Next steps:"))
+	fmt.Println(utils.L("  1. 编辑配置文件: vim .env  # 或使用你喜欢的编辑器", "  1. Edit the config file: vim .env  # or your favorite editor"))
+	fmt.Println(utils.L("  2. 填写必需的配置项（至少需要 FEISHU_APP_ID 和 FEISHU_APP_SECRET）", "  2. Fill in the required settings (at least FEISHU_APP_ID and FEISHU_APP_SECRET)"))
+	fmt.Println(utils.L("  3. 开始使用: feishu2md document <url>", "  3. Get started: feishu2md document <url>"))
 	fmt.Println()
-	fmt.Println("💡 提示:")
-	fmt.Println("  - 工具会自动加载当前目录的 .env 文件")
-	fmt.Println("  - 也可使用 --config 指定其他配置文件: feishu2md --config my.env document <url>")
-	fmt.Println("  - 图床功能为可选，不需要可保持 PICGO_ENABLED=false")
-	fmt.Println("  - .env 文件已在 .gitignore 中，不会被提交到版本控制")
+	fmt.Println(utils.L("💡 提示:", "💡 Tips:"))
+	fmt.Println(utils.L("  - 工具会自动加载当前目录的 .env 文件", "  - The .env file in the current directory is loaded automatically"))
+	fmt.Println(utils.L("  - 也可使用 --config 指定其他配置文件: feishu2md --config my.env document <url>", "  - Use --config to load another file: feishu2md --config my.env document <url>"))
+	fmt.Println(utils.L("  - 图床功能为可选，不需要可保持 PICGO_ENABLED=false", "  - The image host is optional; keep PICGO_ENABLED=false if you don't need it"))
+	fmt.Println(utils.L("  - .env 文件已在 .gitignore 中，不会被提交到版本控制", "  - .env is listed in .gitignore and won't be committed"))
 
 	return nil
 }
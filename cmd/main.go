@@ -5,20 +5,23 @@ package main
 import (
 	"log"
 	"os"
-	"strings"
 
+	"github.com/Perfecto23/feishu2md/utils"
 	"github.com/urfave/cli/v2"
 )
 
-// version 是应用程序版本，通常在构建时设置
-var version = "v2-test"
-
 // main 是应用程序的入口点
-// 它设置带有全局标志和命令的 CLI 应用程序
 func main() {
-	app := &cli.App{
+	if err := newApp().Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newApp 创建带有全局标志和命令的 CLI 应用程序
+func newApp() *cli.App {
+	return &cli.App{
 		Name:    "feishu2md",
-		Version: strings.TrimSpace(string(version)),
+		Version: formatVersion(currentBuildInfo()),
 		Usage:   "下载飞书/LarkSuite文档并转换为Markdown文件",
 		Description: "一个用于批量下载飞书/LarkSuite文档并转换为Markdown格式的命令行工具。\n" +
 			"支持单个文档、文件夹批量下载、完整知识库下载以及知识库子文档下载。\n\n" +
@@ -27,9 +30,30 @@ func main() {
 			"  feishu2md folder https://example.feishu.cn/drive/folder/xxx\n" +
 			"  feishu2md wiki https://example.feishu.cn/wiki/space/xxx\n" +
 			"  feishu2md wiki-tree https://example.feishu.cn/wiki/xxx",
+		// 在任何命令执行前设置输出语言，保证参数错误等早期提示也能切换
+		Before: func(ctx *cli.Context) error {
+			if err := utils.SetLang(ctx.String("lang")); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			if err := utils.SetColor(ctx.String("color")); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			return nil
+		},
 		// 可与任何命令一起使用或作为独立选项的全局标志
 		// 全局标志，适用于所有子命令
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "lang",
+				Usage: "输出语言 / output language: zh 或 en",
+				Value: "zh",
+			},
+			&cli.StringFlag{
+				Name:  "color",
+				Usage: "终端 emoji 与颜色: auto(仅终端中启用) | always | never，CI 中可设为 never",
+				Value: "auto",
+			},
+
 			// === 配置文件 ===
 			&cli.StringFlag{
 				Name:    "config",
@@ -38,13 +62,40 @@ func main() {
 				Value:   ".env",
 			},
 
+			// === 认证选项 ===
+			&cli.StringFlag{
+				Name:  "app-id",
+				Usage: "飞书应用ID（优先于 FEISHU_APP_ID 环境变量）",
+			},
+			&cli.StringFlag{
+				Name:  "app-secret",
+				Usage: "飞书应用密钥（优先于 FEISHU_APP_SECRET 环境变量）",
+			},
+
 			// === 文件选项 ===
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "输出目录（优先于 OUTPUT_DIR 环境变量，默认 ./dist）",
+			},
 			&cli.BoolFlag{
 				Name:    "title-name",
 				Aliases: []string{"t"},
 				Usage:   "使用标题作为文件名",
 				Value:   true,
 			},
+			&cli.StringFlag{
+				Name:  "filename",
+				Usage: "文件名策略: title(标题) | token(文档令牌) | slug(标题的 ASCII slug，中文转写为拼音，无法转写时回退为令牌)，优先于 --title-name",
+			},
+			&cli.BoolFlag{
+				Name:  "strip-title-emoji",
+				Usage: "生成文件名时去掉标题开头的 emoji（如 🚀 发布计划.md -> 发布计划.md），frontmatter 保留原标题",
+			},
+			&cli.IntFlag{
+				Name:  "max-filename-bytes",
+				Usage: "标题文件名（不含扩展名）的字节上限，超长时按完整字符截断，覆盖 MAX_FILENAME_BYTES (默认: 200)",
+			},
 			&cli.BoolFlag{
 				Name:    "skip-same",
 				Aliases: []string{"s"},
@@ -58,16 +109,205 @@ func main() {
 			},
 
 			// === 内容选项 ===
+			&cli.StringFlag{
+				Name:  "token-type",
+				Usage: "只提供裸 token（不含域名）时的文档类型: docx、wiki 或 sheet",
+				Value: "docx",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-empty",
+				Usage: "跳过仅有标题、没有正文的空壳文档，不生成空 md（子文档仍按层级建目录）",
+			},
 			&cli.BoolFlag{
 				Name:  "no-img",
 				Usage: "跳过图片下载",
 			},
+			&cli.StringFlag{
+				Name:  "image-dir",
+				Usage: "图片保存目录（相对于文档所在目录，可为多级如 assets/img），覆盖 IMAGE_DIR",
+			},
+			&cli.BoolFlag{
+				Name:  "image-by-month",
+				Usage: "图片按文档修改时间归档到 YYYY/MM 子目录（如 img/2024/05/xxx.png），启用图床时上传路径同样追加",
+			},
+			&cli.BoolFlag{
+				Name:  "download-external-img",
+				Usage: "下载文档中的外链图片（非飞书 media）并本地化，启用图床时一并上传",
+			},
+			&cli.StringFlag{
+				Name:  "formula-ocr",
+				Usage: "公式识别服务地址：高度较小的疑似公式图片 POST 给该服务，返回 {\"latex\": \"...\"} 时替换为行间公式，失败保留图片，覆盖 FORMULA_OCR_URL",
+			},
+			&cli.BoolFlag{
+				Name:  "strip-exif",
+				Usage: "移除下载的 JPEG 图片中的 EXIF 信息（位置、设备等）",
+			},
+			&cli.IntFlag{
+				Name:  "image-quality",
+				Usage: "重新编码 JPEG 图片的质量（1-100），结果不比原图小时保留原图，覆盖 IMAGE_QUALITY (默认: 85)",
+			},
+			&cli.StringFlag{
+				Name:  "image-format",
+				Usage: "图片输出格式：keep（保持原格式，仅压缩）、png、jpeg 或 webp（WebP 保持原样），覆盖 IMAGE_FORMAT (默认: keep)",
+			},
 			&cli.BoolFlag{
 				Name:  "html",
-				Usage: "使用HTML而非Markdown",
+				Usage: "导出为完整的 HTML 页面（.html），frontmatter 元信息写入 <head> 的 <meta> 标签",
+			},
+			&cli.BoolFlag{
+				Name:  "gallery",
+				Usage: "HTML 模式下把连续的多张图片包成 <div class=\"gallery\"> 画廊（需配合 --html）",
+			},
+			&cli.StringFlag{
+				Name:  "srcset",
+				Usage: "HTML 模式下为本地图片生成这些宽度的缩略图并输出 <img srcset>，逗号分隔（如 480,960）",
+			},
+			&cli.BoolFlag{
+				Name:  "strip-code-line-numbers",
+				Usage: "去除代码块每行开头的行号（仅当所有行都带连续行号时生效）",
+			},
+			&cli.IntFlag{
+				Name:  "cell-max-width",
+				Usage: "表格单元格最多保留的字符数，超出部分截断并加省略号（0 表示不限制）",
+			},
+			&cli.StringFlag{
+				Name:  "callout-style",
+				Usage: "高亮块渲染方式：alert（按样式输出 GFM alert，如 > [!WARNING]）或 quote（带图标的引用块，如 > ⚠️）",
+				Value: "alert",
+			},
+			&cli.BoolFlag{
+				Name:  "fix-heading-levels",
+				Usage: "修复跳级的标题层级（如 H1 下直接出现的 H3 调整为 H2），使目录结构连续",
+			},
+			&cli.BoolFlag{
+				Name:  "split-divider",
+				Usage: "按正文顶层分割线把长文档拆分为多个章节文件：第一章写入原文件，其余依次写入 <文件名>-2.md、<文件名>-3.md …，每章带同样的 frontmatter",
+			},
+			&cli.BoolFlag{
+				Name:  "lint-fix",
+				Usage: "按 markdownlint 常见规则修复输出：标题前后空行、无序列表标记统一为 -、文件以单个换行结尾",
+			},
+			&cli.BoolFlag{
+				Name:  "strip-watermark",
+				Usage: "移除混入正文的水印文本块（识别规则可通过 WATERMARK_PATTERN 自定义）",
+			},
+
+			// === Frontmatter 选项 ===
+			&cli.BoolFlag{
+				Name:  "no-frontmatter",
+				Usage: "不输出 frontmatter，只写格式化后的正文（便于直接粘贴到其他系统）",
+			},
+			&cli.BoolFlag{
+				Name:  "permalink",
+				Usage: "frontmatter 中输出基于 docToken 短 hash 的稳定 permalink（如 /p/1a2b3c4d/）",
+			},
+			&cli.BoolFlag{
+				Name:  "draft",
+				Usage: "frontmatter 输出 draft: true/false，标题以 草稿、未发布、Draft、WIP 等标记开头时为 true（规则可用 DRAFT_PATTERN 覆盖）",
+			},
+			&cli.BoolFlag{
+				Name:  "with-comments",
+				Usage: "在文档末尾追加“评论”章节，列出被评论的文字、评论人、时间与回复（需评论读取权限，评论人姓名需通讯录读取权限）",
+			},
+			&cli.BoolFlag{
+				Name:  "author",
+				Usage: "frontmatter 输出文档作者（所有者）的姓名 author 与头像 avatar，头像下载到图片目录（需通讯录读取权限）",
+			},
+			&cli.BoolFlag{
+				Name:  "utc-dates",
+				Usage: "frontmatter 在 date/updated（输出时区）之外同时输出 UTC 时间 date_utc/updated_utc",
+			},
+			&cli.StringFlag{
+				Name:  "timezone",
+				Usage: "frontmatter 时间、日期提醒、--since 日期与图片按月归档使用的 IANA 时区（如 UTC、America/New_York），无效时回退 +08:00，覆盖 OUTPUT_TIMEZONE (默认: Asia/Shanghai)",
+			},
+			&cli.StringFlag{
+				Name:  "default-category",
+				Usage: "无法从路径推导分类时使用的默认分类名，设为空字符串则不输出 categories (默认: 未分类)",
+			},
+			&cli.StringFlag{
+				Name:  "frontmatter-template",
+				Usage: "自定义 frontmatter 的 Go text/template 模板文件，模板无效时使用内置 YAML 格式",
+			},
+			&cli.StringFlag{
+				Name:  "frontmatter-flavor",
+				Usage: "内置 frontmatter 风格：default 或 hexo（wiki-tree 下 categories 输出为目录层级列表，Hexo 按顺序嵌套为父子分类）",
+				Value: "default",
+			},
+
+			// === 后处理选项 ===
+			&cli.StringFlag{
+				Name:  "post-process",
+				Usage: "后处理命令：每篇文档内容通过 stdin 传入，用其 stdout 替换（如 \"sed 's/foo/bar/g'\"）",
+			},
+
+			// === 站点选项 ===
+			&cli.StringFlag{
+				Name:  "sitemap-base-url",
+				Usage: "站点URL前缀，设置后知识库下载完成时在输出目录生成 sitemap.xml（如 https://blog.example.com/docs）",
+			},
+			&cli.StringFlag{
+				Name:  "report",
+				Usage: "下载结束后把每篇文档的块数、字数、图片数写入该 JSON 报告（如 report.json）",
+			},
+			&cli.BoolFlag{
+				Name:  "images-manifest",
+				Usage: "下载结束后在输出目录生成 images-manifest.json，列出每张图片的 token、本地路径、图床 URL、所属文档、大小与 SHA-256",
+			},
+
+			// === 图床选项 ===
+			&cli.StringFlag{
+				Name:  "imgbed-scheme",
+				Usage: "图床 URL 输出协议：https、http 或 //（相对协议），默认保持图床返回的原样",
+			},
+			&cli.BoolFlag{
+				Name:  "imgbed-doc-prefix",
+				Usage: "按文档划分图床目录：上传路径追加 docToken（如 images/<docToken>/xxx.png），便于按文档管理与删除",
+			},
+			&cli.IntFlag{
+				Name:  "imgbed-retries",
+				Usage: "单个图床上传失败后的重试次数（间隔 1s 起逐次翻倍），仍失败时保留本地图片并引用本地路径，覆盖 PICGO_UPLOAD_RETRIES (默认: 3)",
+			},
+			&cli.IntFlag{
+				Name:  "imgbed-concurrency",
+				Usage: "整个进程同时进行的图床上传数上限，所有文档共用；上传跟不上时暂停图片下载，覆盖 PICGO_CONCURRENCY (默认: 10)",
+			},
+			&cli.DurationFlag{
+				Name:  "imgbed-cache-ttl",
+				Usage: "图床上传缓存有效期（如 720h），过期或无上传时间的记录重新上传，0 表示永不过期，覆盖 PICGO_CACHE_TTL",
+			},
+
+			// === 并发选项 ===
+			&cli.IntFlag{
+				Name:    "doc-concurrency",
+				Aliases: []string{"concurrency"},
+				Usage:   "文档下载并发数，folder、wiki、wiki-tree 共用",
+				Value:   10,
+			},
+			&cli.IntFlag{
+				Name:  "img-concurrency",
+				Usage: "单文档内图片下载并发数，与文档并发互不影响",
+				Value: 16,
+			},
+
+			&cli.StringFlag{
+				Name:  "shared-rate-limit",
+				Usage: "跨进程共享的限流预算文件，多个 feishu2md 进程指向同一文件时合计不超过配置的速率（默认 100次/分钟、5次/秒）",
 			},
 
 			// === 调试选项 ===
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "增量下载：只下载该时间之后修改过的文档，其余标记为“未改动跳过”；接受日期（如 2024-01-01，按 --timezone 解释）或相对时长（如 72h、7d）",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "只遍历节点并按目录树列出每篇文档的目标路径、类型与将新增、修改、跳过的情况（基于文档版本比对），不拉取正文与图片、不写入任何文件",
+			},
+			&cli.BoolFlag{
+				Name:  "api-stats",
+				Usage: "运行结束后输出 API 调用次数、按接口分布、限流等待时间与 P50/P95 耗时",
+			},
 			&cli.BoolFlag{
 				Name:  "json",
 				Usage: "导出JSON响应",
@@ -78,12 +318,18 @@ func main() {
 		Action: func(ctx *cli.Context) error {
 			if ctx.NArg() == 0 {
 				cli.ShowAppHelp(ctx)
-				return cli.Exit("\n错误: 请指定要下载的URL\n\n"+
+				return cli.Exit(utils.L("\n错误: 请指定要下载的URL\n\n"+
 					"使用示例:\n"+
 					"  feishu2md document <文档URL>\n"+
 					"  feishu2md folder <文件夹URL>\n"+
 					"  feishu2md wiki <知识库URL>\n\n"+
-					"运行 'feishu2md help' 查看完整帮助信息", 1)
+					"运行 'feishu2md help' 查看完整帮助信息",
+					"\nError: please specify a URL to download\n\n"+
+						"Examples:\n"+
+						"  feishu2md document <document URL>\n"+
+						"  feishu2md folder <folder URL>\n"+
+						"  feishu2md wiki <wiki URL>\n\n"+
+						"Run 'feishu2md help' for full usage"), 1)
 			}
 			url := ctx.Args().First()
 			return handleDownloadCommand(ctx, url)
@@ -120,13 +366,16 @@ func main() {
 				Description: "下载指定的飞书/LarkSuite文档并转换为Markdown文件。\n\n" +
 					"支持的URL格式:\n" +
 					"  - https://example.feishu.cn/docx/xxx\n" +
-					"  - https://example.feishu.cn/wiki/xxx (单个知识库文档)\n\n" +
+					"  - https://example.feishu.cn/wiki/xxx (单个知识库文档)\n" +
+					"  - 分享链接，如 https://example.feishu.cn/docx?token=xxx 或 applink 跳转链接\n" +
+					"  - 裸 token（默认按 docx 处理，知识库节点加 --token-type=wiki）\n\n" +
 					"示例:\n" +
 					"  feishu2md document https://example.feishu.cn/docx/abc123\n" +
 					"  feishu2md doc https://example.feishu.cn/wiki/def456 --no-img",
 				Action: func(ctx *cli.Context) error {
 					if ctx.NArg() == 0 {
-						return cli.Exit("错误: 请指定文档URL\n\n示例: feishu2md document https://example.feishu.cn/docx/xxx", 1)
+						return cli.Exit(utils.L("错误: 请指定文档URL\n\n示例: feishu2md document https://example.feishu.cn/docx/xxx",
+							"Error: please specify a document URL\n\nExample: feishu2md document https://example.feishu.cn/docx/xxx"), 1)
 					}
 					url := ctx.Args().First()
 					return handleDocumentDownload(ctx, url)
@@ -138,22 +387,28 @@ func main() {
 				Name:      "folder",
 				Aliases:   []string{"f", "batch"},
 				Usage:     "批量下载文件夹中的所有文档",
-				ArgsUsage: "<文件夹URL>",
+				ArgsUsage: "<文件夹URL | ->",
 				Description: "递归下载指定文件夹中的所有文档，保持原有目录结构。\n\n" +
 					"支持的URL格式:\n" +
-					"  - https://example.feishu.cn/drive/folder/xxx\n\n" +
+					"  - https://example.feishu.cn/drive/folder/xxx\n" +
+					"  - - (从标准输入逐行读取URL，自动识别文档/文件夹/知识库)\n\n" +
 					"特性:\n" +
 					"  - 递归遍历子文件夹\n" +
 					"  - 并发下载提升效率\n" +
 					"  - 自动跳过非文档文件\n\n" +
 					"示例:\n" +
 					"  feishu2md folder https://example.feishu.cn/drive/folder/abc123\n" +
-					"  feishu2md f https://example.feishu.cn/drive/folder/abc123 --force",
+					"  feishu2md f https://example.feishu.cn/drive/folder/abc123 --force\n" +
+					"  cat urls.txt | feishu2md batch -",
 				Action: func(ctx *cli.Context) error {
 					if ctx.NArg() == 0 {
-						return cli.Exit("错误: 请指定文件夹URL\n\n示例: feishu2md folder https://example.feishu.cn/drive/folder/xxx", 1)
+						return cli.Exit(utils.L("错误: 请指定文件夹URL\n\n示例: feishu2md folder https://example.feishu.cn/drive/folder/xxx",
+							"Error: please specify a folder URL\n\nExample: feishu2md folder https://example.feishu.cn/drive/folder/xxx"), 1)
 					}
 					url := ctx.Args().First()
+					if url == "-" {
+						return handleStdinDownload(ctx)
+					}
 					return handleFolderDownload(ctx, url)
 				},
 			},
@@ -177,13 +432,39 @@ func main() {
 					"  feishu2md w https://example.feishu.cn/wiki/space/abc123 --skip-same",
 				Action: func(ctx *cli.Context) error {
 					if ctx.NArg() == 0 {
-						return cli.Exit("错误: 请指定知识库URL\n\n示例: feishu2md wiki https://example.feishu.cn/wiki/space/xxx", 1)
+						return cli.Exit(utils.L("错误: 请指定知识库URL\n\n示例: feishu2md wiki https://example.feishu.cn/wiki/space/xxx",
+							"Error: please specify a wiki URL\n\nExample: feishu2md wiki https://example.feishu.cn/wiki/space/xxx"), 1)
 					}
 					url := ctx.Args().First()
 					return handleWikiDownload(ctx, url)
 				},
 			},
 
+			// 从文件批量下载
+			{
+				Name:      "batch-file",
+				Aliases:   []string{"bf"},
+				Usage:     "从文本文件读取 URL 列表批量下载",
+				ArgsUsage: "<URL列表文件 | ->",
+				Description: "逐行读取文件中的 URL，按 URL 类型自动选择文档/文件夹/知识库下载方式。\n\n" +
+					"文件格式:\n" +
+					"  - 每行一个 URL 或裸 token（按 --token-type 处理）\n" +
+					"  - 空行与 # 开头的注释行会被跳过\n\n" +
+					"特性:\n" +
+					"  - 单个 URL 失败不中断整体，结束后汇总成功/失败数并列出失败的 URL\n" +
+					"  - 文件名为 - 时从标准输入读取\n\n" +
+					"示例:\n" +
+					"  feishu2md batch-file urls.txt\n" +
+					"  feishu2md bf urls.txt --no-img",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() == 0 {
+						return cli.Exit(utils.L("错误: 请指定URL列表文件\n\n示例: feishu2md batch-file urls.txt",
+							"Error: please specify a URL list file\n\nExample: feishu2md batch-file urls.txt"), 1)
+					}
+					return handleBatchFileDownload(ctx, ctx.Args().First())
+				},
+			},
+
 			// 知识库子文档下载
 			{
 				Name:      "wiki-tree",
@@ -204,13 +485,18 @@ func main() {
 					"示例:\n" +
 					"  feishu2md wiki-tree https://example.feishu.cn/wiki/abc123\n" +
 					"  feishu2md wiki-tree --category-level=1  # 取第1层目录作为分类\n" +
-					"  feishu2md wiki-tree --category-level=-1 # 取最后一层目录作为分类",
+					"  feishu2md wiki-tree --category-level=-1 # 取最后一层目录作为分类\n" +
+					"  feishu2md wiki-tree --resume            # 从上次中断处继续",
 				Flags: []cli.Flag{
 					&cli.IntFlag{
 						Name:  "category-level",
 						Usage: "分类取第几层目录: 正数从外向内(1=第一层), 负数从内向外(-1=最后一层), 层级不够时回退到最近层",
 						Value: 1,
 					},
+					&cli.IntFlag{
+						Name:  "tag-max-levels",
+						Usage: "tags 最多取几层目录: 正数取前 n 层(外层), 负数取后 n 层(内层), 0 不限制",
+					},
 					&cli.BoolFlag{
 						Name:  "no-body-title",
 						Usage: "禁用正文开头的H1标题（frontmatter已含title）",
@@ -219,6 +505,23 @@ func main() {
 						Name:  "clean-output",
 						Usage: "同步前清空输出目录，再按最新知识库树生成，避免重命名/删除后旧文件残留（输出目录应仅用于本同步）",
 					},
+					&cli.BoolFlag{
+						Name:  "resume",
+						Usage: "从上次中断处继续，跳过输出目录进度文件中已完成的文档",
+					},
+					&cli.IntFlag{
+						Name:  "checkpoint-every",
+						Usage: "每完成 N 篇保存一次进度与缓存检查点，超大知识库中途失败时最多重做 N 篇；0 表示只在结束时保存",
+						Value: defaultCheckpointEvery,
+					},
+					&cli.BoolFlag{
+						Name:  "flat",
+						Usage: "所有文档输出到同一目录（层级仍用于 tags/categories），跨目录同名文档自动追加 token 后缀去重",
+					},
+					&cli.BoolFlag{
+						Name:  "include-self",
+						Usage: "同时下载根节点自身（docx）到输出目录根部",
+					},
 				},
 				Action: handleWikiTreeCommand,
 			},
@@ -232,7 +535,7 @@ func main() {
 				Hidden:    true,
 				Action: func(ctx *cli.Context) error {
 					if ctx.NArg() == 0 {
-						return cli.Exit("请指定URL", 1)
+						return cli.Exit(utils.L("请指定URL", "Please specify a URL"), 1)
 					}
 					url := ctx.Args().First()
 					return handleLegacyDownload(ctx, url)
@@ -240,8 +543,4 @@ func main() {
 			},
 		},
 	}
-
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
-	}
 }
@@ -0,0 +1,109 @@
+// Package main - 图片清单
+// 记录本次导出的每张图片：token、本地路径、图床 URL、所属文档、大小与哈希，
+// 下载结束后写入输出目录下的 images-manifest.json，便于图床迁移与核对
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// imagesManifestName 图片清单的文件名，写入输出根目录
+const imagesManifestName = "images-manifest.json"
+
+// imageDigest 下载到本地的图片文件的大小与 SHA-256
+type imageDigest struct {
+	Size   int64
+	SHA256 string
+}
+
+// digestImageFile 计算图片文件的大小与 SHA-256，读取失败时返回零值
+func digestImageFile(path string) imageDigest {
+	f, err := os.Open(path)
+	if err != nil {
+		return imageDigest{}
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return imageDigest{}
+	}
+	return imageDigest{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}
+}
+
+// manifestEntry 清单中的一张图片，同一图片被多篇文档引用时每篇各记一条
+type manifestEntry struct {
+	Token     string `json:"token"`
+	Doc       string `json:"doc"` // 所属文档相对输出根目录的路径
+	DocToken  string `json:"doc_token"`
+	LocalPath string `json:"local_path,omitempty"` // 本地文件相对输出根目录的路径，上传图床后已删除时为空
+	URL       string `json:"url,omitempty"`        // 图床 URL，未上传时为空
+	Size      int64  `json:"size,omitempty"`       // 图床缓存命中、未下载到本地时为 0
+	SHA256    string `json:"sha256,omitempty"`
+}
+
+// ImageManifest 并发安全地收集图片清单
+type ImageManifest struct {
+	mu      sync.Mutex
+	entries []manifestEntry
+}
+
+// AddDoc 记录一篇文档的图片：links 为 token -> 最终链接（本地相对路径或图床 URL），
+// digests 为本次下载到本地的图片大小与哈希
+func (m *ImageManifest) AddDoc(docPath, docToken, outputDir string, links map[string]string, digests map[string]imageDigest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for token, link := range links {
+		e := manifestEntry{Token: token, Doc: reportPath(docPath), DocToken: docToken}
+		if isRemoteImageLink(link) {
+			e.URL = link
+		} else {
+			e.LocalPath = reportPath(filepath.Join(outputDir, link))
+		}
+		if d, ok := digests[token]; ok {
+			e.Size, e.SHA256 = d.Size, d.SHA256
+		}
+		m.entries = append(m.entries, e)
+	}
+}
+
+var imageManifest = &ImageManifest{}
+
+// isRemoteImageLink 判断图片链接是否为图床等远程地址
+func isRemoteImageLink(link string) bool {
+	return strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") || strings.HasPrefix(link, "//")
+}
+
+// writeImagesManifest 将图片清单按文档路径、token 排序后写入输出根目录，返回写入的路径
+func writeImagesManifest() (string, error) {
+	imageManifest.mu.Lock()
+	entries := make([]manifestEntry, len(imageManifest.entries))
+	copy(entries, imageManifest.entries)
+	imageManifest.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Doc != entries[j].Doc {
+			return entries[i].Doc < entries[j].Doc
+		}
+		return entries[i].Token < entries[j].Token
+	})
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dlConfig.Output.OutputDir, imagesManifestName)
+	if err := os.MkdirAll(dlConfig.Output.OutputDir, 0o755); err != nil {
+		return "", err
+	}
+	return path, utils.WriteFileAtomic(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImagesManifestContent(t *testing.T) {
+	dir := setupDownload(t)
+	dlConfig.Output.SkipImgDownload = false
+	dlConfig.Output.ImagesManifest = true
+	imageManifest = &ImageManifest{}
+	png := testPNG(t)
+	feishu := newFakeFeishu(t)
+	feishu.addDocxBlocks("doxImg", "带图文档", imageBlock("imgB"), imageBlock("imgA"), imageBlock("imgA"))
+	feishu.addImage("imgA", png)
+	feishu.addImage("imgB", png)
+
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxImg", &DownloadOpts{outputDir: filepath.Join(dir, "子目录")}); err != nil {
+		t.Fatal(err)
+	}
+	// 已上传图床的图片只记录 URL
+	imageManifest.AddDoc(filepath.Join(dir, "远程.md"), "doxRemote", dir, map[string]string{"imgC": "https://cdn.example.com/c.png"}, nil)
+
+	path, err := writeImagesManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, imagesManifestName) {
+		t.Errorf("manifest path = %q", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []manifestEntry
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	// 大小与摘要取自落盘后的图片（保存时可能重新编码）
+	local := func(token string) manifestEntry {
+		saved, err := os.ReadFile(filepath.Join(dir, "子目录", "img", token+".png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(saved)
+		return manifestEntry{Token: token, Doc: "子目录/带图文档.md", DocToken: "doxImg", LocalPath: "子目录/img/" + token + ".png",
+			Size: int64(len(saved)), SHA256: hex.EncodeToString(sum[:])}
+	}
+	// 按文档路径、token 排序，重复引用的图片只记一条
+	want := []manifestEntry{
+		local("imgA"),
+		local("imgB"),
+		{Token: "imgC", Doc: "远程.md", DocToken: "doxRemote", URL: "https://cdn.example.com/c.png"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("manifest = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
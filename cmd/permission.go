@@ -0,0 +1,72 @@
+// Package main - 权限不足的节点
+// 遍历文件夹、知识库时，应用无权读取的文档或子目录不再中断整个导出，
+// 而是记录下来跳过，结束时统一列出，便于用户逐个申请权限
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/chyroc/lark"
+)
+
+// deniedNode 因权限不足被跳过的节点
+type deniedNode struct {
+	Path  string // 节点在输出目录中的位置
+	Token string // 文档、文件夹或知识库节点 token
+	Code  int64  // 飞书返回的错误码
+}
+
+// PermissionCollector 并发安全地收集权限不足的节点
+type PermissionCollector struct {
+	mu      sync.Mutex
+	entries []deniedNode
+}
+
+// Add 在 err 为权限不足时记录节点并返回 true，调用方据此跳过该节点继续导出；
+// 其他错误不记录，返回 false
+func (c *PermissionCollector) Add(path, token string, err error) bool {
+	if !core.IsPermissionDenied(err) {
+		return false
+	}
+	node := deniedNode{Path: path, Token: token}
+	var larkErr *lark.Error
+	if errors.As(err, &larkErr) {
+		node.Code = larkErr.Code
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, node)
+	return true
+}
+
+// Entries 返回按路径排序的节点清单
+func (c *PermissionCollector) Entries() []deniedNode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]deniedNode, len(c.entries))
+	copy(entries, c.entries)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+var permissionCollector = &PermissionCollector{}
+
+// printPermissionWarnings 列出因权限不足被跳过的节点，没有时不输出
+func printPermissionWarnings() {
+	entries := permissionCollector.Entries()
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Printf(utils.L("🔒 %d 个节点因权限不足被跳过：\n", "🔒 %d nodes skipped due to insufficient permissions:\n"), len(entries))
+	for _, e := range entries {
+		fmt.Printf(utils.L("  - %s（token: %s，错误码 %d）\n", "  - %s (token: %s, code %d)\n"), e.Path, e.Token, e.Code)
+	}
+	fmt.Println(utils.L("提示: 将应用添加为这些文档或目录的协作者，或在开放平台为应用开通对应权限后重新导出",
+		"Tip: add the app as a collaborator on these documents or folders, or grant the app the required scopes on the open platform, then export again"))
+}
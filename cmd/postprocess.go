@@ -0,0 +1,49 @@
+// Package main - Markdown 后处理钩子
+// 将生成的内容通过标准输入交给用户脚本，并用脚本的标准输出替换原内容
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// postProcessTimeout 单次后处理脚本的最长执行时间
+const postProcessTimeout = 60 * time.Second
+
+// runPostProcess 通过系统 shell 执行后处理命令，content 写入 stdin，返回 stdout
+// 额外向脚本提供 FEISHU2MD_DOC_TOKEN 与 FEISHU2MD_OUTPUT_PATH 环境变量
+func runPostProcess(ctx context.Context, command, content, docToken, outputPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, postProcessTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"FEISHU2MD_DOC_TOKEN="+docToken,
+		"FEISHU2MD_OUTPUT_PATH="+outputPath,
+	)
+	cmd.Stdin = strings.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf(utils.L("后处理脚本超时（%v）: %s", "post-process command timed out (%v): %s"), postProcessTimeout, command)
+		}
+		return "", fmt.Errorf(utils.L("后处理脚本执行失败: %v\n输出: %s", "post-process command failed: %v\noutput: %s"), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
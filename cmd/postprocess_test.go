@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("post-process scripts below require a POSIX shell")
+	}
+	ctx := context.Background()
+	if got, err := runPostProcess(ctx, "cat", "# 标题\n", "doxA", "out/a.md"); err != nil || got != "# 标题\n" {
+		t.Errorf("cat = %q, %v", got, err)
+	}
+	got, err := runPostProcess(ctx, `sed 's/飞书/Lark/g'; echo "$FEISHU2MD_DOC_TOKEN $FEISHU2MD_OUTPUT_PATH"`, "飞书文档\n", "doxA", "out/a.md")
+	if err != nil || got != "Lark文档\ndoxA out/a.md\n" {
+		t.Errorf("sed = %q, %v", got, err)
+	}
+	if _, err := runPostProcess(ctx, "echo broken >&2; exit 3", "x", "doxA", "out/a.md"); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("failing script error = %v, want it to include stderr", err)
+	}
+}
+
+func TestDownloadAppliesPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("post-process scripts below require a POSIX shell")
+	}
+	dir := setupDownload(t)
+	dlConfig.Output.PostProcessCmd = `sed 's/原始正文/处理后正文/'`
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxPost", "钩子文档", "原始正文")
+
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxPost", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "钩子文档.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); !strings.Contains(got, "处理后正文") || strings.Contains(got, "原始正文") {
+		t.Errorf("post-process output was not written:\n%s", got)
+	}
+
+	// 脚本失败时降级为导出 JSON，不写入 Markdown
+	dir = setupDownload(t)
+	dlConfig.Output.PostProcessCmd = "exit 1"
+	feishu.addDocx("doxFail", "失败文档", "正文")
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxFail", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "失败文档.md")); !os.IsNotExist(err) {
+		t.Errorf("failed post-process still wrote the markdown file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, failedDirName, "doxFail.json")); err != nil {
+		t.Errorf("raw JSON not saved after post-process failure: %v", err)
+	}
+}
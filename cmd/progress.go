@@ -0,0 +1,178 @@
+// Package main - 导出进度持久化
+// wiki-tree 下载时逐篇记录已完成的节点，中断后可通过 --resume 从下一篇继续；
+// 每完成若干篇保存一次检查点，把进度与各类缓存落盘，进程意外退出时最多损失一个周期
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/picgo"
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// progressFileName 进度文件名，位于输出目录下，全部完成后自动删除
+const progressFileName = ".feishu2md-progress"
+
+// progressHeaderPrefix 进度文件首行前缀，记录本次导出的根节点，避免误用其他树的进度
+const progressHeaderPrefix = "# root="
+
+// defaultCheckpointEvery 默认每完成多少篇保存一次检查点
+const defaultCheckpointEvery = 100
+
+// ProgressTracker 记录已完成的文档节点
+// 文件格式为首行根节点标识，之后每完成一篇追加一行节点令牌，追加写入开销与节点总数无关
+type ProgressTracker struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	done map[string]struct{}
+
+	checkpointEvery int    // 每完成多少篇触发一次检查点，0 表示不触发
+	sinceCheckpoint int    // 距上次检查点新完成的篇数
+	onCheckpoint    func() // 检查点时执行的落盘操作
+}
+
+// openProgress 打开输出目录下的进度文件
+// resume 为 true 且进度文件属于同一根节点时载入已完成列表，否则重新开始记录
+func openProgress(dir, rootToken string, resume bool) (*ProgressTracker, error) {
+	p := &ProgressTracker{
+		path: filepath.Join(dir, progressFileName),
+		done: make(map[string]struct{}),
+	}
+
+	if resume {
+		if err := p.load(rootToken); err != nil {
+			return nil, err
+		}
+	}
+	// 预览模式只读取已有进度，不创建进度文件
+	if dlConfig.Output.DryRun {
+		return p, nil
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if len(p.done) == 0 {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(p.path, flag, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf(utils.L("打开进度文件失败: %w", "failed to open progress file: %w"), err)
+	}
+	if len(p.done) == 0 {
+		if _, err := fmt.Fprintf(f, "%s%s\n", progressHeaderPrefix, rootToken); err != nil {
+			f.Close()
+			return nil, fmt.Errorf(utils.L("写入进度文件失败: %w", "failed to write progress file: %w"), err)
+		}
+	}
+	p.file = f
+	return p, nil
+}
+
+// load 读取进度文件；文件不存在或根节点不一致时视为没有进度
+func (p *ProgressTracker) load(rootToken string) error {
+	f, err := os.Open(p.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(utils.L("读取进度文件失败: %w", "failed to read progress file: %w"), err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || scanner.Text() != progressHeaderPrefix+rootToken {
+		fmt.Println(utils.L("⚠️  进度文件与当前知识库节点不匹配，将重新开始", "⚠️  Progress file belongs to a different wiki node, starting over"))
+		return nil
+	}
+	for scanner.Scan() {
+		if token := strings.TrimSpace(scanner.Text()); token != "" {
+			p.done[token] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+// Completed 返回已完成的文档数
+func (p *ProgressTracker) Completed() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.done)
+}
+
+// IsDone 判断节点是否已在之前的运行中完成
+func (p *ProgressTracker) IsDone(nodeToken string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.done[nodeToken]
+	return ok
+}
+
+// SetCheckpoint 设置每完成 every 篇执行一次 fn；every 为 0 时不触发
+func (p *ProgressTracker) SetCheckpoint(every int, fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkpointEvery = every
+	p.onCheckpoint = fn
+}
+
+// MarkDone 记录节点已完成并立即追加到进度文件，达到检查点间隔时把进度文件同步到磁盘并执行检查点
+func (p *ProgressTracker) MarkDone(nodeToken string) {
+	p.mu.Lock()
+	if _, ok := p.done[nodeToken]; ok {
+		p.mu.Unlock()
+		return
+	}
+	p.done[nodeToken] = struct{}{}
+	if p.file == nil {
+		p.mu.Unlock()
+		return
+	}
+	if _, err := fmt.Fprintln(p.file, nodeToken); err != nil {
+		fmt.Printf(utils.L("⚠️  写入进度文件失败: %v\n", "⚠️  Failed to write progress file: %v\n"), err)
+	}
+	p.sinceCheckpoint++
+	due := p.checkpointEvery > 0 && p.sinceCheckpoint >= p.checkpointEvery
+	if due {
+		p.sinceCheckpoint = 0
+		p.file.Sync()
+	}
+	fn := p.onCheckpoint
+	p.mu.Unlock()
+
+	// 缓存落盘较慢，不持有锁，避免阻塞其他文档记录进度
+	if due && fn != nil {
+		fn()
+	}
+}
+
+// saveCheckpoint 把文档版本缓存、图片缓存与图床上传缓存落盘，失败只提示不中断下载
+func saveCheckpoint(completed int) {
+	if err := revisionCache.Flush(); err != nil {
+		fmt.Printf(utils.L("⚠️  检查点：文档版本缓存保存失败: %v\n", "⚠️  Checkpoint: failed to save revision cache: %v\n"), err)
+	}
+	if err := imageHitCache.Flush(); err != nil {
+		fmt.Printf(utils.L("⚠️  检查点：图片缓存保存失败: %v\n", "⚠️  Checkpoint: failed to save image cache: %v\n"), err)
+	}
+	if err := picgo.PersistCache(); err != nil {
+		fmt.Printf(utils.L("⚠️  检查点：上传缓存保存失败: %v\n", "⚠️  Checkpoint: failed to save upload cache: %v\n"), err)
+	}
+	fmt.Printf(utils.L("💾 已保存检查点（已完成 %d 篇）\n", "💾 Checkpoint saved (%d documents done)\n"), completed)
+}
+
+// Close 关闭进度文件；finished 为 true 表示全部完成，此时删除进度文件
+func (p *ProgressTracker) Close(finished bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.file == nil {
+		return
+	}
+	p.file.Close()
+	if finished {
+		os.Remove(p.path)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResumeSkipsCompletedDocs(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	feishu.addWikiNodes("spc1", "", wikiNode{Token: "wikRoot", ObjToken: "doxRoot", ObjType: "docx", Title: "根", HasChild: true})
+	feishu.addWikiNodes("spc1", "wikRoot",
+		wikiNode{Token: "wikA", ObjToken: "doxA", ObjType: "docx", Title: "甲"},
+		wikiNode{Token: "wikB", ObjToken: "doxB", ObjType: "docx", Title: "乙"})
+	feishu.addDocx("doxA", "甲", "正文甲")
+	feishu.addDocx("doxB", "乙", "正文乙")
+
+	// 上次运行中断前已完成 wikA
+	progressPath := filepath.Join(dir, progressFileName)
+	if err := os.WriteFile(progressPath, []byte(progressHeaderPrefix+"wikRoot\nwikA\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var fetched []string
+	feishu.hook = func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/blocks") {
+			mu.Lock()
+			fetched = append(fetched, strings.Split(strings.TrimPrefix(r.URL.Path, "/open-apis/docx/v1/documents/"), "/")[0])
+			mu.Unlock()
+		}
+	}
+	opts := &DownloadOpts{outputDir: dir, spaceID: "spc1", resume: true}
+	if err := downloadWikiChildren(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/wikRoot", opts); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(fetched, ",") != "doxB" {
+		t.Errorf("fetched documents = %v, want only doxB", fetched)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "甲.md")); !os.IsNotExist(err) {
+		t.Errorf("completed document was downloaded again: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "乙.md")); err != nil {
+		t.Errorf("remaining document not downloaded: %v", err)
+	}
+	// 全部完成后删除进度文件
+	if _, err := os.Stat(progressPath); !os.IsNotExist(err) {
+		t.Errorf("progress file kept after finishing: %v", err)
+	}
+}
+
+func TestOpenProgressIgnoresOtherRoot(t *testing.T) {
+	dir := setupDownload(t)
+	if err := os.WriteFile(filepath.Join(dir, progressFileName), []byte(progressHeaderPrefix+"wikOther\nwikA\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := openProgress(dir, "wikRoot", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close(false)
+	if p.IsDone("wikA") || p.Completed() != 0 {
+		t.Errorf("progress of another root was loaded: %d done", p.Completed())
+	}
+}
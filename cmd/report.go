@@ -0,0 +1,64 @@
+// Package main - 内容盘点报告
+// 记录每篇导出文档的块数、字数与图片数，下载结束后写入 JSON 报告
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// reportEntry 报告中单篇文档的统计
+type reportEntry struct {
+	Path     string `json:"path"` // 相对输出根目录的文件路径
+	DocToken string `json:"doc_token"`
+	Title    string `json:"title"`
+	core.DocStats
+}
+
+// ReportCollector 并发安全地收集文档统计
+type ReportCollector struct {
+	mu      sync.Mutex
+	entries []reportEntry
+}
+
+func (c *ReportCollector) Add(e reportEntry) {
+	c.mu.Lock()
+	c.entries = append(c.entries, e)
+	c.mu.Unlock()
+}
+
+var reportCollector = &ReportCollector{}
+
+// writeReport 将收集到的统计按路径排序后写入 JSON 报告
+func writeReport(path string) error {
+	reportCollector.mu.Lock()
+	entries := make([]reportEntry, len(reportCollector.entries))
+	copy(entries, reportCollector.entries)
+	reportCollector.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return utils.WriteFileAtomic(path, append(data, '\n'), 0o644)
+}
+
+// reportPath 返回文档相对输出根目录的路径，无法计算时退回原路径
+func reportPath(outputPath string) string {
+	if rel, err := filepath.Rel(dlConfig.Output.OutputDir, outputPath); err == nil {
+		return utils.ToPosixPath(rel)
+	}
+	return utils.ToPosixPath(outputPath)
+}
@@ -0,0 +1,98 @@
+// Package main - 文档版本缓存
+// 记录每个输出文件对应的文档 RevisionID，--skip-same 时版本未变可直接跳过，省去内容拉取
+// 缓存存储在当前工作目录的 .feishu2md/ 下，与图床上传缓存放在一起
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// revisionEntry 记录输出文件对应的文档与版本
+type revisionEntry struct {
+	DocToken   string `json:"doc_token"`
+	RevisionID int64  `json:"revision_id"`
+}
+
+// RevisionCache 维护 输出路径 -> 文档版本 的映射
+type RevisionCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]revisionEntry
+	loaded  bool
+	dirty   bool
+}
+
+// revisionCache 全局版本缓存
+var revisionCache = &RevisionCache{
+	path: filepath.Join(".feishu2md", "revision-cache.json"),
+}
+
+// load 懒加载缓存文件，文件不存在或损坏时视为空缓存
+func (c *RevisionCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = make(map[string]revisionEntry)
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]revisionEntry)
+	}
+}
+
+// Unchanged 判断输出文件存在且上次写入时的文档版本与当前一致
+func (c *RevisionCache) Unchanged(outputPath, docToken string, revisionID int64) bool {
+	if revisionID <= 0 || !fileExists(outputPath) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	e, ok := c.entries[filepath.Clean(outputPath)]
+	return ok && e.DocToken == docToken && e.RevisionID == revisionID
+}
+
+// Set 记录输出文件当前对应的文档版本
+func (c *RevisionCache) Set(outputPath, docToken string, revisionID int64) {
+	if revisionID <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	key := filepath.Clean(outputPath)
+	if e, ok := c.entries[key]; ok && e.DocToken == docToken && e.RevisionID == revisionID {
+		return
+	}
+	c.entries[key] = revisionEntry{DocToken: docToken, RevisionID: revisionID}
+	c.dirty = true
+}
+
+// Flush 将有变更的缓存写回磁盘
+func (c *RevisionCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(c.path, data, 0o644); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSkipSameRevisionFetchesNothing(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxRev", "版本文档", "正文")
+	opts := &DownloadOpts{outputDir: dir, skipDuplicate: true}
+
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxRev", opts); err != nil {
+		t.Fatal(err)
+	}
+	// 替换为标记内容，第二次运行若重写文件即可发现
+	mdPath := filepath.Join(dir, "版本文档.md")
+	if err := os.WriteFile(mdPath, []byte("sentinel"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var contentFetches atomic.Int32
+	feishu.hook = func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/blocks") {
+			contentFetches.Add(1)
+		}
+	}
+	if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxRev", opts); err != nil {
+		t.Fatal(err)
+	}
+	if n := contentFetches.Load(); n != 0 {
+		t.Errorf("second run fetched document content %d times, want 0", n)
+	}
+	if data, _ := os.ReadFile(mdPath); string(data) != "sentinel" {
+		t.Errorf("second run rewrote the file: %q", data)
+	}
+}
@@ -0,0 +1,94 @@
+// Package main - 电子表格下载
+// 电子表格（sheet）的每个工作表渲染为一张 Markdown 表格，输出流程与 docx 文档一致：
+// 文件名策略、frontmatter、后处理、跳过重复、站点地图与统计日志
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// downloadSheet 下载电子表格并写为 Markdown（--html 时为 HTML 页面）
+func downloadSheet(ctx context.Context, client *core.Client, sheetToken string, opts *DownloadOpts) error {
+	ss, err := client.GetSheetContent(ctx, sheetToken)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf(utils.L("获取电子表格 %s 失败: %w", "failed to fetch spreadsheet %s: %w"), sheetToken, err)
+	}
+
+	mdName := outputFileName(ss.Title, sheetToken, opts)
+	outputPath := filepath.Join(opts.outputDir, mdName)
+	pathForLog := mdName
+	if opts.relDir != "" {
+		pathForLog = filepath.Join(opts.relDir, mdName)
+	}
+
+	// 电子表格没有可用于比对的版本号，本地已存在时按修改计
+	if dlConfig.Output.DryRun {
+		dryRunCollector.Add(reportPath(outputPath), "sheet", classifyDryRun(outputPath, sheetToken, 0, opts))
+		return nil
+	}
+	if unchangedSince(ctx, client, sheetToken, "sheet", mdName, opts) {
+		return nil
+	}
+
+	markdown := core.RenderSpreadsheet(ss, dlConfig.Output.NoBodyTitle)
+	engine := newLuteEngine()
+	result := markdown
+	if err := recoverAsError(func() { result = engine.FormatStr("md", markdown) }); err != nil {
+		// 格式化只影响排版，失败时使用未格式化的表格
+		result = markdown
+	}
+	result = core.CollapseBlankLines(result)
+	if dlConfig.Output.LintFix {
+		result = core.LintFixMarkdown(result)
+	}
+
+	fm := buildFrontmatter(ctx, client, sheetToken, "sheet", ss.Title, opts)
+	result = fm.apply(engine, result)
+
+	if dlConfig.Output.PostProcessCmd != "" {
+		processed, err := runPostProcess(ctx, dlConfig.Output.PostProcessCmd, result, sheetToken, outputPath)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s: %w", mdName, err)
+		}
+		result = processed
+	}
+
+	if err := os.MkdirAll(opts.outputDir, 0o755); err != nil {
+		return err
+	}
+	if opts.dumpJSON {
+		jsonOutputPath := filepath.Join(opts.outputDir, sheetToken+".json")
+		if err := utils.WriteFileAtomic(jsonOutputPath, []byte(utils.PrettyPrint(ss)), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf(utils.L("📄 JSON响应已转储到 %s\n", "📄 JSON response dumped to %s\n"), jsonOutputPath)
+	}
+
+	if dlConfig.Output.SitemapBaseURL != "" {
+		sitemapCollector.Add(filepath.Join(opts.relDir, mdName), fm.updatedAt)
+	}
+
+	if !opts.forceDownload && shouldSkipFile(outputPath, result, opts.skipDuplicate) {
+		syncFileModTime(outputPath, fm.updatedAt)
+		return nil
+	}
+	if err := utils.WriteFileAtomic(outputPath, []byte(result), 0o644); err != nil {
+		return err
+	}
+	syncFileModTime(outputPath, fm.updatedAt)
+	dlStats.AddDocNew()
+	logCollector.Add(DocLog{Path: pathForLog, DocNew: true})
+	return nil
+}
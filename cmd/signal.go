@@ -0,0 +1,102 @@
+// Package main - 中断信号处理
+// 收到 Ctrl+C / SIGTERM 时取消 context，等待进行中的写入完成并刷新缓存后再退出
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/picgo"
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// newSignalContext 创建一个在收到 SIGINT/SIGTERM 时被取消的 context
+// 第一次中断只取消 context 以便优雅退出；之后恢复默认行为，再次中断会强制结束进程
+func newSignalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigCh:
+			fmt.Println(utils.L("\n⚠️  收到中断信号，等待进行中的任务完成后退出（再次按 Ctrl+C 强制退出）...",
+				"\n⚠️  Interrupted, waiting for in-flight tasks to finish (press Ctrl+C again to force quit)..."))
+			signal.Stop(sigCh)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigCh)
+		cancel()
+	}
+}
+
+// finishDownload 在下载结束（正常完成或被中断）后刷新缓存，并将中断转换为明确的退出码
+func finishDownload(ctx context.Context, client *core.Client, err error) error {
+	if ferr := picgo.FlushCache(); ferr != nil {
+		fmt.Printf(utils.L("⚠️  上传缓存保存失败: %v\n", "⚠️  Failed to save upload cache: %v\n"), ferr)
+	}
+	if ferr := revisionCache.Flush(); ferr != nil {
+		fmt.Printf(utils.L("⚠️  文档版本缓存保存失败: %v\n", "⚠️  Failed to save revision cache: %v\n"), ferr)
+	}
+	if ferr := imageHitCache.Flush(); ferr != nil {
+		fmt.Printf(utils.L("⚠️  图片缓存保存失败: %v\n", "⚠️  Failed to save image cache: %v\n"), ferr)
+	}
+	if dlConfig.Output.DryRun {
+		printDryRun()
+	}
+	if dlConfig.Output.ReportPath != "" {
+		if ferr := writeReport(dlConfig.Output.ReportPath); ferr != nil {
+			fmt.Printf(utils.L("⚠️  统计报告写入失败: %v\n", "⚠️  Failed to write report: %v\n"), ferr)
+		} else {
+			fmt.Printf(utils.L("📝 统计报告已写入 %s\n", "📝 Report written to %s\n"), dlConfig.Output.ReportPath)
+		}
+	}
+	if dlConfig.Output.ImagesManifest && !dlConfig.Output.DryRun {
+		if path, ferr := writeImagesManifest(); ferr != nil {
+			fmt.Printf(utils.L("⚠️  图片清单写入失败: %v\n", "⚠️  Failed to write image manifest: %v\n"), ferr)
+		} else {
+			fmt.Printf(utils.L("🖼️  图片清单已写入 %s\n", "🖼️  Image manifest written to %s\n"), path)
+		}
+	}
+	if dlConfig.Output.ReportAPIStats {
+		printAPIStats(client.APIStats())
+	}
+	printPermissionWarnings()
+	if ctx.Err() != nil {
+		return cli.Exit(utils.L("⏹️  下载已中断，进行中的文件已写完，缓存已保存", "⏹️  Download interrupted; in-flight files were completed and caches saved"), 130)
+	}
+	return err
+}
+
+// printAPIStats 输出 API 调用次数、按接口分布、限流等待与耗时分位
+func printAPIStats(s core.APIStatsSummary) {
+	fmt.Println()
+	fmt.Println(utils.L("📊 API 调用统计：", "📊 API call statistics:"))
+	fmt.Printf(utils.L("- 总调用次数: %d\n", "- Total calls: %d\n"), s.Total)
+	apis := make([]string, 0, len(s.ByAPI))
+	for api := range s.ByAPI {
+		apis = append(apis, api)
+	}
+	// 按调用次数降序，次数相同按名称排序，保证输出稳定
+	sort.Slice(apis, func(i, j int) bool {
+		if s.ByAPI[apis[i]] != s.ByAPI[apis[j]] {
+			return s.ByAPI[apis[i]] > s.ByAPI[apis[j]]
+		}
+		return apis[i] < apis[j]
+	})
+	for _, api := range apis {
+		fmt.Printf("  - %s: %d\n", api, s.ByAPI[api])
+	}
+	fmt.Printf(utils.L("- 限流累计等待: %.2fs\n", "- Rate limiter wait: %.2fs\n"), s.LimiterWait.Seconds())
+	fmt.Printf(utils.L("- 单次调用耗时: P50 %dms / P95 %dms\n", "- Call latency: P50 %dms / P95 %dms\n"), s.P50.Milliseconds(), s.P95.Milliseconds())
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestSignalCancelsContextAndExitsGracefully(t *testing.T) {
+	dir := setupDownload(t)
+	feishu := newFakeFeishu(t)
+	feishu.addDocx("doxGood", "好文档", "正文内容")
+	ctx, stop := newSignalContext()
+	defer stop()
+
+	// 中断前已写完的文档：版本缓存尚未落盘
+	if err := downloadDocument(ctx, feishu.client(), "https://x.feishu.cn/docx/doxGood", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("sending SIGINT is not supported: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled after SIGINT")
+	}
+
+	// 中断后刷新缓存并以 130 退出，而不是返回下载过程中的错误
+	err = finishDownload(ctx, feishu.client(), context.Canceled)
+	var exit cli.ExitCoder
+	if !errors.As(err, &exit) || exit.ExitCode() != 130 {
+		t.Fatalf("finishDownload() = %v, want exit code 130", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".feishu2md", "revision-cache.json")); err != nil {
+		t.Errorf("revision cache was not flushed on interrupt: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Package main - 按修改时间增量下载
+// --since 指定时间阈值，最近修改时间早于阈值的文档视为未改动，跳过下载；
+// 阈值可以是日期（按输出时区解释，默认 Asia/Shanghai）或相对时长（如 72h、7d）
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/core"
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// sinceDateLayouts --since 支持的日期格式
+var sinceDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// parseSince 解析 --since：相对时长（72h、90m、7d）表示距 now 多久之前，
+// 日期或日期时间按输出时区解释（带时区的 RFC 3339 时间保持其时区）
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf(utils.L("--since 时长不能为负数: %s", "--since duration must not be negative: %s"), value)
+		}
+		return now.Add(-d), nil
+	}
+	for _, layout := range sinceDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, outputLocation); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(utils.L("无法解析 --since %q，应为日期（如 2024-01-01）或时长（如 72h、7d）",
+		"cannot parse --since %q, expected a date (e.g. 2024-01-01) or a duration (e.g. 72h, 7d)"), value)
+}
+
+// unchangedSince 判断文档最近修改时间是否早于 --since 阈值；早于时记录"未改动跳过"日志并返回 true
+// 未设置阈值或取不到修改时间时返回 false，照常下载
+func unchangedSince(ctx context.Context, client *core.Client, docToken, docType, mdName string, opts *DownloadOpts) bool {
+	since := dlConfig.Output.Since
+	if since.IsZero() {
+		return false
+	}
+	_, updatedAt, err := client.GetFileTimes(ctx, docToken, docType)
+	if err != nil || updatedAt == nil || !updatedAt.Before(since) {
+		return false
+	}
+	pathForLog := mdName
+	if opts.relDir != "" {
+		pathForLog = filepath.Join(opts.relDir, mdName)
+	}
+	logCollector.Add(DocLog{Path: pathForLog, Skipped: true, Reason: utils.L("未改动跳过", "unchanged, skipped")})
+	return true
+}
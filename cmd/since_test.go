@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadSinceThreshold(t *testing.T) {
+	// 模拟文档的最近修改时间
+	modified := time.Unix(1704067200, 0)
+	tests := []struct {
+		name     string
+		since    time.Time
+		wantSkip bool
+	}{
+		{"阈值早于修改时间", modified.Add(-time.Second), false},
+		{"阈值等于修改时间", modified, false},
+		{"阈值晚于修改时间", modified.Add(time.Second), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupDownload(t)
+			dlConfig.Output.Since = tt.since
+			feishu := newFakeFeishu(t)
+			feishu.addDocx("doxSince", "增量文档", "正文")
+
+			if err := downloadDocument(context.Background(), feishu.client(), "https://x.feishu.cn/docx/doxSince", &DownloadOpts{outputDir: dir}); err != nil {
+				t.Fatal(err)
+			}
+			_, err := os.Stat(filepath.Join(dir, "增量文档.md"))
+			if written := err == nil; written == tt.wantSkip {
+				t.Errorf("file written = %v, want %v", written, !tt.wantSkip)
+			}
+			skipped := false
+			for _, l := range logCollector.Drain() {
+				skipped = skipped || l.Skipped
+			}
+			if skipped != tt.wantSkip {
+				t.Errorf("logged as skipped = %v, want %v", skipped, tt.wantSkip)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, outputLocation)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"72h", now.Add(-72 * time.Hour)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, outputLocation)},
+		{"2024-01-01 08:30", time.Date(2024, 1, 1, 8, 30, 0, 0, outputLocation)},
+		{"2024-01-01T00:00:00Z", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got, err := parseSince(tt.value, now); err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"-1h", "yesterday", ""} {
+		if _, err := parseSince(bad, now); err == nil {
+			t.Errorf("parseSince(%q) succeeded, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package main - 站点地图生成
+// 知识库下载完成后，根据各文档的相对路径与修改时间生成 sitemap.xml
+package main
+
+import (
+	"encoding/xml"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// sitemapEntry 记录单篇文档在站点地图中的信息
+type sitemapEntry struct {
+	Path    string    // 相对输出根目录的文件路径
+	LastMod time.Time // 文档最近修改时间，零值表示未知
+}
+
+// SitemapCollector 并发安全地收集站点地图条目
+type SitemapCollector struct {
+	mu      sync.Mutex
+	entries []sitemapEntry
+}
+
+func (c *SitemapCollector) Add(relPath string, lastMod time.Time) {
+	c.mu.Lock()
+	c.entries = append(c.entries, sitemapEntry{Path: relPath, LastMod: lastMod})
+	c.mu.Unlock()
+}
+
+func (c *SitemapCollector) Entries() []sitemapEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]sitemapEntry, len(c.entries))
+	copy(out, c.entries)
+	return out
+}
+
+var sitemapCollector = &SitemapCollector{}
+
+// sitemapURLSet 对应 sitemap.xml 的根元素
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// buildSitemapURL 拼接站点前缀与文档路径：去掉扩展名、统一使用 / 分隔并逐段转义
+func buildSitemapURL(baseURL, relPath string) string {
+	p := utils.ToPosixPath(relPath)
+	p = strings.TrimSuffix(p, path.Ext(p))
+	segments := strings.Split(strings.TrimPrefix(path.Clean("/"+p), "/"), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + strings.Join(segments, "/")
+}
+
+// renderSitemap 将收集的条目渲染为 sitemap.xml 内容，按 URL 排序保证输出稳定
+func renderSitemap(baseURL string, entries []sitemapEntry) ([]byte, error) {
+	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, e := range entries {
+		u := sitemapURL{Loc: buildSitemapURL(baseURL, e.Path)}
+		if !e.LastMod.IsZero() {
+			u.LastMod = e.LastMod.Format("2006-01-02T15:04:05-07:00")
+		}
+		set.URLs = append(set.URLs, u)
+	}
+	sort.Slice(set.URLs, func(i, j int) bool { return set.URLs[i].Loc < set.URLs[j].Loc })
+
+	data, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// writeSitemap 在 dir 下生成 sitemap.xml，未配置站点前缀时不生成
+func writeSitemap(dir, baseURL string) error {
+	if baseURL == "" || dlConfig.Output.DryRun {
+		return nil
+	}
+	data, err := renderSitemap(baseURL, sitemapCollector.Entries())
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(dir, "sitemap.xml"), data, 0o644)
+}
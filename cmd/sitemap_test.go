@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildSitemapURL(t *testing.T) {
+	tests := []struct {
+		baseURL, relPath, want string
+	}{
+		{"https://docs.example.com", "入门.md", "https://docs.example.com/%E5%85%A5%E9%97%A8"},
+		{"https://docs.example.com/", filepath.Join("指南", "a b.md"), "https://docs.example.com/%E6%8C%87%E5%8D%97/a%20b"},
+		{"https://docs.example.com/kb", "release-notes.md", "https://docs.example.com/kb/release-notes"},
+		{"https://docs.example.com", "../逃逸.md", "https://docs.example.com/%E9%80%83%E9%80%B8"},
+	}
+	for _, tt := range tests {
+		if got := buildSitemapURL(tt.baseURL, tt.relPath); got != tt.want {
+			t.Errorf("buildSitemapURL(%q, %q) = %q, want %q", tt.baseURL, tt.relPath, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadWikiWritesSitemap(t *testing.T) {
+	dir := setupDownload(t)
+	dlConfig.Output.SitemapBaseURL = "https://docs.example.com/"
+	sitemapCollector = &SitemapCollector{}
+	feishu := newFakeFeishu(t)
+	feishu.addWikiSpace("spc1", "知识库")
+	feishu.addWikiNodes("spc1", "",
+		wikiNode{Token: "wikA", ObjToken: "doxA", ObjType: "docx", Title: "入门"},
+		wikiNode{Token: "wikB", ObjToken: "doxB", ObjType: "docx", Title: "常见问题"})
+	feishu.addDocx("doxA", "入门", "正文")
+	feishu.addDocx("doxB", "常见问题", "正文")
+
+	if err := downloadWiki(context.Background(), feishu.client(), "https://x.feishu.cn/wiki/settings/spc1", &DownloadOpts{outputDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	var sitemap []byte
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.Name() == "sitemap.xml" {
+			sitemap, err = os.ReadFile(path)
+		}
+		return err
+	})
+	if err != nil || sitemap == nil {
+		t.Fatalf("sitemap.xml not written: %v", err)
+	}
+	got := string(sitemap)
+	lastMod := "<lastmod>" + time.Unix(1704067200, 0).In(outputLocation).Format("2006-01-02T15:04:05-07:00") + "</lastmod>"
+	for _, want := range []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<loc>https://docs.example.com/%E5%85%A5%E9%97%A8</loc>",
+		"<loc>https://docs.example.com/%E5%B8%B8%E8%A7%81%E9%97%AE%E9%A2%98</loc>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("sitemap missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "<url>") != 2 || strings.Count(got, lastMod) != 2 {
+		t.Errorf("want 2 urls with %s:\n%s", lastMod, got)
+	}
+}
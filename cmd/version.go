@@ -0,0 +1,62 @@
+// Package main - 版本与构建信息
+// version、commit、date 通过 ldflags 在构建时注入（见 Makefile），
+// 未注入时尝试从 Go 工具链记录的 VCS 信息中补全
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// 构建信息，通过 -ldflags "-X main.version=... -X main.commit=... -X main.date=..." 注入
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo 汇总后的构建信息
+type buildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// currentBuildInfo 返回当前二进制的构建信息，ldflags 未注入的字段从 debug.ReadBuildInfo 补全
+func currentBuildInfo() buildInfo {
+	info := buildInfo{Version: version, Commit: commit, Date: date}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// formatVersion 生成 --version 输出，如 v1.2.0 (commit 1a2b3c4, built 2024-05-01T08:00:00Z, go1.21.5 linux/amd64)
+// commit 只保留前 7 位，缺失的字段显示为 unknown
+func formatVersion(info buildInfo) string {
+	shortCommit := info.Commit
+	if len(shortCommit) > 7 {
+		shortCommit = shortCommit[:7]
+	}
+	if shortCommit == "" {
+		shortCommit = "unknown"
+	}
+	builtAt := info.Date
+	if builtAt == "" {
+		builtAt = "unknown"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s, %s %s/%s)",
+		info.Version, shortCommit, builtAt, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,94 @@
+// Package core - 飞书错误码说明
+// 常见飞书开放平台错误码映射为说明与修复建议（随 --lang 输出中文或英文），接口调用失败时包装原始错误返回；
+// 包装后的错误仍可用 errors.As 取出 *lark.Error
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/chyroc/lark"
+)
+
+// feishuErrorHint 一个飞书错误码的说明与修复建议（中英文）
+type feishuErrorHint struct {
+	Desc   string // 错误含义
+	Fix    string // 修复建议
+	DescEn string // 英文的错误含义
+	FixEn  string // 英文的修复建议
+}
+
+// feishuErrorHints 常见飞书错误码的说明，未收录的错误码保持原始错误
+var feishuErrorHints = map[int64]feishuErrorHint{
+	// 鉴权
+	10014: {"应用密钥错误", "检查 FEISHU_APP_SECRET 是否与开发者后台「凭证与基础信息」一致",
+		"invalid app secret", "check that FEISHU_APP_SECRET matches \"Credentials & Basic Info\" in the developer console"},
+	99991661: {"请求缺少访问凭证", "检查 FEISHU_APP_ID、FEISHU_APP_SECRET 是否已配置",
+		"missing access token", "check that FEISHU_APP_ID and FEISHU_APP_SECRET are set"},
+	99991663: {"应用访问凭证（tenant_access_token）无效或已过期", "检查 FEISHU_APP_ID、FEISHU_APP_SECRET 是否正确，并确认应用未被停用",
+		"tenant_access_token is invalid or expired", "check FEISHU_APP_ID and FEISHU_APP_SECRET, and make sure the app is not disabled"},
+	99991668: {"用户访问凭证（user_access_token）无效", "重新获取 user_access_token 并更新 FEISHU_USER_ACCESS_TOKEN",
+		"user_access_token is invalid", "obtain a new user_access_token and update FEISHU_USER_ACCESS_TOKEN"},
+	99991677: {"用户访问凭证（user_access_token）已过期", "user_access_token 有效期约 2 小时，请重新获取并更新 FEISHU_USER_ACCESS_TOKEN",
+		"user_access_token has expired", "user_access_token lasts about 2 hours; obtain a new one and update FEISHU_USER_ACCESS_TOKEN"},
+	99991672: {"应用未开通接口所需的权限", "在开发者后台「权限管理」开通对应权限（见 README 权限清单），并发布新版本使其生效",
+		"the app lacks the scope required by this API", "enable the scope under \"Permissions & Scopes\" in the developer console (see the README) and publish a new version"},
+	99991679: {"用户未授权接口所需的权限", "获取 user_access_token 时勾选对应权限后重新授权",
+		"the user has not granted the scope required by this API", "re-authorize with the required scope when obtaining user_access_token"},
+	// 频率限制
+	99991400: {"请求过于频繁，触发飞书频率限制", "调低 FEISHU_RATE_PER_SECOND / FEISHU_RATE_PER_MINUTE 或 --doc-concurrency，多个进程同时导出时使用 --shared-rate-limit",
+		"too many requests, Feishu rate limit triggered", "lower FEISHU_RATE_PER_SECOND / FEISHU_RATE_PER_MINUTE or --doc-concurrency, and use --shared-rate-limit when running several exports at once"},
+	// 新版文档
+	1770002: {"文档不存在或已被删除", "确认文档链接正确，文档未被删除或移入回收站",
+		"document not found or deleted", "check the document URL and make sure it has not been deleted or moved to the trash"},
+	1770032: {"无文档阅读权限", "在文档「分享」中把应用（或其所在群）添加为协作者，或改用 FEISHU_USER_ACCESS_TOKEN 以个人身份下载",
+		"no permission to read the document", "add the app (or a group containing it) as a collaborator under \"Share\", or download as yourself with FEISHU_USER_ACCESS_TOKEN"},
+	// 知识库
+	131005: {"知识库节点不存在或已被删除", "确认知识库链接正确，节点未被删除",
+		"wiki node not found or deleted", "check the wiki URL and make sure the node has not been deleted"},
+	131006: {"无知识库节点或空间的访问权限", "在知识空间「设置 - 成员设置」中把应用添加为成员，或为节点单独授权",
+		"no permission to access the wiki node or space", "add the app as a member under \"Settings - Members\" of the wiki space, or grant access to the node"},
+	// 云空间
+	1061004: {"无文件夹或文件的访问权限", "在文件夹「分享」中把应用添加为协作者",
+		"no permission to access the folder or file", "add the app as a collaborator under \"Share\" of the folder"},
+	1061007: {"文件已被删除", "确认文件未被删除或移入回收站",
+		"file has been deleted", "make sure the file has not been deleted or moved to the trash"},
+	// 电子表格
+	91403: {"无电子表格访问权限", "在表格「分享」中把应用添加为协作者",
+		"no permission to access the spreadsheet", "add the app as a collaborator under \"Share\" of the spreadsheet"},
+}
+
+// FeishuAPIError 带说明与修复建议的飞书接口错误，Unwrap 返回原始的 *lark.Error
+type FeishuAPIError struct {
+	Code int64
+	Desc string
+	Fix  string
+	err  *lark.Error
+}
+
+func (e *FeishuAPIError) Error() string {
+	return fmt.Sprintf(utils.L("%s（飞书接口 %s#%s 错误码 %d：%s）。建议：%s", "%s (Feishu API %s#%s error code %d: %s). Suggestion: %s"),
+		e.Desc, e.err.Scope, e.err.FuncName, e.Code, e.err.Msg, e.Fix)
+}
+
+func (e *FeishuAPIError) Unwrap() error {
+	return e.err
+}
+
+// ExplainError 错误为已收录错误码的飞书接口错误时包装为 *FeishuAPIError，其他错误原样返回
+func ExplainError(err error) error {
+	var larkErr *lark.Error
+	if !errors.As(err, &larkErr) {
+		return err
+	}
+	var explained *FeishuAPIError
+	if errors.As(err, &explained) {
+		return err
+	}
+	hint, ok := feishuErrorHints[larkErr.Code]
+	if !ok {
+		return err
+	}
+	return &FeishuAPIError{Code: larkErr.Code, Desc: utils.L(hint.Desc, hint.DescEn), Fix: utils.L(hint.Fix, hint.FixEn), err: larkErr}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/chyroc/lark"
+)
+
+func TestExplainError(t *testing.T) {
+	tests := []struct {
+		code     int64
+		wantDesc string
+	}{
+		{10014, "应用密钥错误"},
+		{99991663, "应用访问凭证（tenant_access_token）无效或已过期"},
+		{99991677, "用户访问凭证（user_access_token）已过期"},
+		{99991672, "应用未开通接口所需的权限"},
+		{99991400, "请求过于频繁，触发飞书频率限制"},
+		{1770002, "文档不存在或已被删除"},
+		{1770032, "无文档阅读权限"},
+		{131006, "无知识库节点或空间的访问权限"},
+		{1061004, "无文件夹或文件的访问权限"},
+		{91403, "无电子表格访问权限"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			larkErr := lark.NewError("Drive", "GetDocxDocument", tt.code, "raw msg")
+			err := ExplainError(fmt.Errorf("GetDocxDocumentMeta err: %w", larkErr))
+			var apiErr *FeishuAPIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("ExplainError() = %v, want *FeishuAPIError", err)
+			}
+			if apiErr.Code != tt.code || apiErr.Desc != tt.wantDesc || apiErr.Fix == "" {
+				t.Errorf("ExplainError() = %+v, want code %d desc %q with a fix", apiErr, tt.code, tt.wantDesc)
+			}
+			// 仍可取出原始的 *lark.Error
+			var got *lark.Error
+			if !errors.As(err, &got) || got != larkErr {
+				t.Errorf("errors.As(*lark.Error) = %v", got)
+			}
+			// 已包装的错误不重复包装
+			if again := ExplainError(err); again != err {
+				t.Errorf("ExplainError(explained) = %v, want unchanged", again)
+			}
+		})
+	}
+
+	// 未收录的错误码与非飞书错误原样返回
+	unknown := lark.NewError("Drive", "GetDocxDocument", 12345, "unknown")
+	if ExplainError(unknown) != error(unknown) {
+		t.Error("unknown code was wrapped")
+	}
+	plain := errors.New("network down")
+	if ExplainError(plain) != plain {
+		t.Error("non-Feishu error was wrapped")
+	}
+	if ExplainError(nil) != nil {
+		t.Error("ExplainError(nil) != nil")
+	}
+}
+
+func TestFeishuAPIErrorMessage(t *testing.T) {
+	t.Cleanup(func() { utils.SetLang("zh") })
+	wrapped := fmt.Errorf("GetDocxDocumentMeta err: %w", lark.NewError("Drive", "GetDocxDocument", 1770032, "forbidden"))
+
+	utils.SetLang("zh")
+	if msg := ExplainError(wrapped).Error(); !strings.HasPrefix(msg, "无文档阅读权限（飞书接口 Drive#GetDocxDocument 错误码 1770032：forbidden）。建议：") {
+		t.Errorf("zh message = %q", msg)
+	}
+	utils.SetLang("en")
+	if msg := ExplainError(wrapped).Error(); !strings.HasPrefix(msg, "no permission to read the document (Feishu API Drive#GetDocxDocument error code 1770032: forbidden). Suggestion: ") {
+		t.Errorf("en message = %q", msg)
+	}
+}
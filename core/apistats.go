@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chyroc/lark"
+)
+
+// APIStats 统计飞书 API 的调用次数与单次耗时，用于运行结束后的性能报告
+type APIStats struct {
+	mu        sync.Mutex
+	calls     map[string]int
+	durations []time.Duration
+}
+
+// APIStatsSummary 是某一时刻的统计汇总
+type APIStatsSummary struct {
+	Total       int            // 总调用次数
+	ByAPI       map[string]int // 按接口分类的调用次数，键为 "Scope.API"
+	LimiterWait time.Duration  // 限流器累计等待时间
+	P50         time.Duration  // 单次调用耗时中位数
+	P95         time.Duration  // 单次调用耗时 95 分位
+}
+
+// NewAPIStats 创建空的调用统计
+func NewAPIStats() *APIStats {
+	return &APIStats{calls: make(map[string]int)}
+}
+
+// Record 记录一次接口调用及其耗时
+func (s *APIStats) Record(api string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[api]++
+	s.durations = append(s.durations, d)
+}
+
+// Middleware 返回挂载到 lark 客户端的中间件，对经过 SDK 的每次请求计数计时
+func (s *APIStats) Middleware() lark.ApiMiddleware {
+	return func(next lark.ApiEndpoint) lark.ApiEndpoint {
+		return func(ctx context.Context, req *lark.RawRequestReq, resp interface{}) (*lark.Response, error) {
+			start := time.Now()
+			r, err := next(ctx, req, resp)
+			s.Record(req.Scope+"."+req.API, time.Since(start))
+			return r, err
+		}
+	}
+}
+
+// Summary 汇总当前统计；limiterWait 为限流器累计等待时间
+func (s *APIStats) Summary(limiterWait time.Duration) APIStatsSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byAPI := make(map[string]int, len(s.calls))
+	for k, v := range s.calls {
+		byAPI[k] = v
+	}
+	sorted := make([]time.Duration, len(s.durations))
+	copy(sorted, s.durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return APIStatsSummary{
+		Total:       len(sorted),
+		ByAPI:       byAPI,
+		LimiterWait: limiterWait,
+		P50:         percentile(sorted, 0.50),
+		P95:         percentile(sorted, 0.95),
+	}
+}
+
+// percentile 按最近秩法取已排序耗时的分位数，空切片返回 0
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
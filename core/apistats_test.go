@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAPIStatsSummary(t *testing.T) {
+	s := NewAPIStats()
+	if got := s.Summary(0); got.Total != 0 || got.P50 != 0 || got.P95 != 0 {
+		t.Errorf("empty Summary() = %+v", got)
+	}
+
+	// 并发记录 20 次调用，耗时为 1ms..20ms
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		api := "Drive.GetDocxDocument"
+		if i%4 == 0 {
+			api = "Drive.DownloadDriveMedia"
+		}
+		wg.Add(1)
+		go func(api string, d time.Duration) {
+			defer wg.Done()
+			s.Record(api, d)
+		}(api, time.Duration(i)*time.Millisecond)
+	}
+	wg.Wait()
+
+	got := s.Summary(3 * time.Second)
+	if got.Total != 20 || got.ByAPI["Drive.GetDocxDocument"] != 15 || got.ByAPI["Drive.DownloadDriveMedia"] != 5 {
+		t.Errorf("Summary() counts = %d %v", got.Total, got.ByAPI)
+	}
+	if got.P50 != 10*time.Millisecond || got.P95 != 19*time.Millisecond || got.LimiterWait != 3*time.Second {
+		t.Errorf("Summary() p50 = %v, p95 = %v, wait = %v", got.P50, got.P95, got.LimiterWait)
+	}
+
+	// 汇总是快照，后续记录不影响已返回的结果
+	s.Record("Drive.GetDocxDocument", time.Second)
+	if got.ByAPI["Drive.GetDocxDocument"] != 15 {
+		t.Error("Summary() shares its map with the live stats")
+	}
+}
+
+func TestClientRecordsAPIStats(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":0,"data":{"document":{"document_id":"doxA","revision_id":1,"title":"甲"}}}`)
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetDocxDocumentMeta(context.Background(), "doxA"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := client.APIStats()
+	if n := stats.ByAPI["Drive.GetDocxDocument"]; n != 2 {
+		t.Errorf("GetDocxDocument calls = %d, want 2 (all: %v)", n, stats.ByAPI)
+	}
+}
@@ -0,0 +1,65 @@
+// Package core - 文档作者
+// 取云文档所有者的姓名与头像，用于博客的作者卡片；同一作者只查询一次通讯录
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/chyroc/lark"
+)
+
+// DocAuthor 文档作者（云文档所有者）
+type DocAuthor struct {
+	OpenID    string
+	Name      string
+	AvatarURL string // 头像原图链接，未授权读取头像时为空
+}
+
+// GetDocAuthor 获取云文档所有者的姓名与头像，需要应用具备通讯录读取权限
+func (c *Client) GetDocAuthor(ctx context.Context, docToken, docType string) (*DocAuthor, error) {
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetDriveFileMetaResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetDriveFileMeta(ctx, &lark.GetDriveFileMetaReq{
+			RequestDocs: []*lark.GetDriveFileMetaReqRequestDocs{
+				{DocToken: docToken, DocType: docType},
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || len(resp.Metas) == 0 || resp.Metas[0] == nil || resp.Metas[0].OwnerID == "" {
+		return nil, errors.New(utils.L("未获取到文档所有者", "document owner not found"))
+	}
+	return c.getUser(ctx, resp.Metas[0].OwnerID)
+}
+
+// getUser 按 open_id 查询用户姓名与头像，结果在客户端内缓存
+func (c *Client) getUser(ctx context.Context, openID string) (*DocAuthor, error) {
+	if cached, ok := c.authors.Load(openID); ok {
+		return cached.(*DocAuthor), nil
+	}
+	idType := lark.IDTypeOpenID
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetUserResp, *lark.Response, error) {
+		return c.larkClient.Contact.GetUser(ctx, &lark.GetUserReq{
+			UserID:     openID,
+			UserIDType: &idType,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	author := &DocAuthor{OpenID: openID}
+	if resp != nil && resp.User != nil {
+		author.Name = resp.User.Name
+		if avatar := resp.User.Avatar; avatar != nil {
+			author.AvatarURL = avatar.AvatarOrigin
+			if author.AvatarURL == "" {
+				author.AvatarURL = avatar.Avatar640
+			}
+		}
+	}
+	c.authors.Store(openID, author)
+	return author, nil
+}
@@ -3,55 +3,213 @@ package core
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"image/png"
 	"io"
-	"os"
+	"math/rand"
+	"net"
+	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Perfecto23/feishu2md/utils"
 	"github.com/chyroc/lark"
 )
 
 type Client struct {
 	larkClient *lark.Lark
 	limiter    *FeishuRateLimiter // 飞书API限流器
+	imageOpts  ImageOptions       // 图片写盘前的处理选项
+	imageCache *ImageCache        // 图片命中缓存，nil 表示不使用
+	stats      *APIStats          // API 调用统计
+	authors    sync.Map           // open_id -> *DocAuthor，同一作者只查询一次
+	userToken  string             // 非空时所有请求以用户身份（user_access_token）发出
+	baseURL    string             // 飞书开放平台地址
+
+	maxRetries     int           // 可重试错误（429/5xx/网络超时）的最大重试次数
+	retryBaseDelay time.Duration // 首次重试前的等待时间，之后每次翻倍
 }
 
-func NewClient(appID, appSecret string) *Client {
-	return &Client{
-		larkClient: lark.New(
-			lark.WithAppCredential(appID, appSecret),
-			lark.WithTimeout(60*time.Second),
-			// 移除SDK自带限流，使用我们的精确控制
-		),
-		limiter: NewFeishuRateLimiter(), // 100次/分钟, 5次/秒
+// 默认重试策略
+const (
+	DefaultMaxRetries     = 3
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+)
+
+// ClientOption 创建客户端时的可选配置
+type ClientOption func(*Client)
+
+// WithRateLimit 按应用的实际配额设置限流速率，未设置时使用飞书默认配额
+func WithRateLimit(cfg RateLimitConfig) ClientOption {
+	return func(c *Client) {
+		c.limiter = NewFeishuRateLimiterWithConfig(cfg)
 	}
 }
 
-func (c *Client) DownloadImage(ctx context.Context, imgToken, outDir string) (string, error) {
-	// 如果本地已经存在以 imgToken 命名的图片文件（任意扩展名），则直接复用，跳过网络下载
-	if existingPath, ok := findExistingLocalImage(outDir, imgToken); ok {
-		relativePath := fmt.Sprintf("./%s/%s", filepath.Base(outDir), filepath.Base(existingPath))
-		return relativePath, nil
+// WithUserAccessToken 以用户身份访问文档，可读取用户本人有权限、但未共享给应用的文档；
+// 同时配置应用凭据时优先使用 user token
+func WithUserAccessToken(token string) ClientOption {
+	return func(c *Client) {
+		c.userToken = token
+	}
+}
+
+// WithOpenBaseURL 指定飞书开放平台地址，如 Lark 国际版或测试用的模拟服务
+func WithOpenBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithRetry 设置可重试错误的最大重试次数与退避基准间隔，maxRetries 为 0 表示不重试
+func WithRetry(maxRetries int, baseDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBaseDelay = baseDelay
 	}
+}
 
-	// 限流: 等待飞书API调用许可
-	if err := c.limiter.Wait(ctx); err != nil {
-		return imgToken, fmt.Errorf("限流等待失败: %v", err)
+func NewClient(appID, appSecret string, opts ...ClientOption) *Client {
+	stats := NewAPIStats()
+	c := &Client{
+		limiter:        NewFeishuRateLimiter(), // 默认 100次/分钟, 5次/秒
+		stats:          stats,
+		maxRetries:     DefaultMaxRetries,
+		retryBaseDelay: DefaultRetryBaseDelay,
+		baseURL:        feishuOpenBaseURL,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.larkClient = lark.New(
+		lark.WithAppCredential(appID, appSecret),
+		lark.WithTimeout(60*time.Second),
+		lark.WithOpenBaseURL(c.baseURL),
+		lark.WithApiMiddleware(stats.Middleware(), c.userTokenMiddleware()),
+		// 移除SDK自带限流，使用我们的精确控制
+	)
+	return c
+}
 
-	resp, _, err := c.larkClient.Drive.DownloadDriveMedia(ctx, &lark.DownloadDriveMediaReq{
-		FileToken: imgToken,
+// userTokenMiddleware 配置了 user token 时，让经过 SDK 的请求改用用户身份鉴权；
+// 获取应用 token 等鉴权接口保持原样
+func (c *Client) userTokenMiddleware() lark.ApiMiddleware {
+	return func(next lark.ApiEndpoint) lark.ApiEndpoint {
+		return func(ctx context.Context, req *lark.RawRequestReq, resp interface{}) (*lark.Response, error) {
+			if c.userToken != "" && req.Scope != "Auth" {
+				option := new(lark.MethodOption)
+				lark.WithUserAccessToken(c.userToken)(option)
+				req.NeedUserAccessToken = true
+				req.MethodOption = option
+			}
+			return next(ctx, req, resp)
+		}
+	}
+}
+
+// APIStats 返回截至目前的 API 调用统计汇总
+func (c *Client) APIStats() APIStatsSummary {
+	return c.stats.Summary(c.limiter.TotalWait())
+}
+
+// 凭据校验失败的原因，可用 errors.Is 区分
+var (
+	ErrInvalidCredentials = utils.NewError("AppId/AppSecret 错误", "invalid AppId/AppSecret")
+	ErrNetworkUnreachable = utils.NewError("无法连接飞书开放平台", "cannot reach the Feishu open platform")
+)
+
+// ErrImageForbidden 应用无权下载图片（403），重试无意义，可用 errors.Is 判断后统一提示
+var ErrImageForbidden = utils.NewError("图片下载权限不足 (403 Forbidden)", "no permission to download the image (403 Forbidden)")
+
+// credentialErrorCodes 获取 tenant_access_token 时表示应用凭据本身有误的错误码，
+// 其余错误码（频率限制、服务端故障等）重试或稍后再试即可恢复，不应提示用户修改凭据
+var credentialErrorCodes = map[int64]bool{
+	10003:    true, // app_id 或 app_secret 参数无效
+	10014:    true, // 应用密钥错误
+	99991661: true, // 缺少访问凭证
+	99991663: true, // tenant_access_token 无效
+	99991664: true, // app_access_token 无效
+}
+
+// VerifyCredentials 通过获取 tenant_access_token 校验应用凭据，
+// 飞书返回凭据错误码时包装为 ErrInvalidCredentials，其余错误码与请求未能完成时包装为 ErrNetworkUnreachable。
+// 获取到的 token 会被 SDK 缓存，后续调用无需重复鉴权
+func (c *Client) VerifyCredentials(ctx context.Context) error {
+	// 以用户身份访问时不使用应用 token，无需校验应用凭据
+	if c.userToken != "" {
+		return nil
+	}
+	_, err := doWithRetry(ctx, c, func() (*lark.TokenExpire, *lark.Response, error) {
+		return c.larkClient.Auth.GetTenantAccessToken(ctx)
+	})
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var larkErr *lark.Error
+	if errors.As(err, &larkErr) {
+		if credentialErrorCodes[larkErr.Code] {
+			return fmt.Errorf(utils.L("%w: %s（错误码 %d）", "%w: %s (error code %d)"), ErrInvalidCredentials, larkErr.Msg, larkErr.Code)
+		}
+		return fmt.Errorf(utils.L("%w: %s（错误码 %d）", "%w: %s (error code %d)"), ErrNetworkUnreachable, larkErr.Msg, larkErr.Code)
+	}
+	return fmt.Errorf("%w: %v", ErrNetworkUnreachable, err)
+}
+
+// SetSharedRateLimit 使用 path 作为跨进程共享的限流预算文件
+func (c *Client) SetSharedRateLimit(path string) {
+	c.limiter.SetShared(NewSharedBudget(path, c.limiter.Config()))
+}
+
+// SetImageOptions 设置下载图片时的处理选项
+func (c *Client) SetImageOptions(opts ImageOptions) {
+	c.imageOpts = opts
+}
+
+// SetImageCache 设置图片命中缓存，已下载过的图片复制本地文件而不再调用下载接口
+func (c *Client) SetImageCache(cache *ImageCache) {
+	c.imageCache = cache
+}
+
+// DownloadImage 下载飞书图片到 docDir/imageDir，返回相对文档目录的引用路径
+func (c *Client) DownloadImage(ctx context.Context, imgToken, docDir, imageDir string) (string, error) {
+	// 如果本地已经存在以 imgToken 命名的图片文件（任意扩展名），则直接复用，跳过网络下载
+	if existingPath, ok := findExistingLocalImage(filepath.Join(docDir, imageDir), imgToken); ok {
+		if c.imageCache != nil {
+			c.imageCache.Store(imgToken, existingPath)
+		}
+		return imageLink(imageDir, filepath.Base(existingPath)), nil
+	}
+	// 其他文档目录或之前的运行中已下载过，复制本地文件
+	if c.imageCache != nil {
+		if cachedPath, ok := c.imageCache.Lookup(imgToken); ok {
+			if link, err := copyCachedImage(cachedPath, docDir, imageDir); err == nil {
+				return link, nil
+			}
+		}
+	}
+
+	statusCode := 0 // 最后一次请求的 HTTP 状态码
+	resp, err := doWithRetry(ctx, c, func() (*lark.DownloadDriveMediaResp, *lark.Response, error) {
+		result, larkResp, err := c.larkClient.Drive.DownloadDriveMedia(ctx, &lark.DownloadDriveMediaReq{
+			FileToken: imgToken,
+		})
+		if larkResp != nil {
+			statusCode = larkResp.StatusCode
+		}
+		return result, larkResp, err
 	})
 	if err != nil {
-		// 提供更详细的错误信息，帮助诊断权限问题
-		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Forbidden") {
-			return imgToken, fmt.Errorf("图片下载权限不足 (403 Forbidden): 请检查飞书应用是否有 drive:media:download 权限")
+		// 权限错误不在 doWithRetry 的重试范围内，直接返回便于调用方汇总提示
+		if statusCode == http.StatusForbidden || IsPermissionDenied(err) {
+			return imgToken, fmt.Errorf(utils.L("%w: 请检查飞书应用是否有 drive:media:download 权限", "%w: check that the Feishu app has the drive:media:download scope"), ErrImageForbidden)
 		}
-		return imgToken, fmt.Errorf("图片下载失败: %v", err)
+		return imgToken, fmt.Errorf(utils.L("图片下载失败: %v", "failed to download image: %v"), err)
 	}
 
 	// 获取文件扩展名，如果没有则使用默认的
@@ -60,54 +218,20 @@ func (c *Client) DownloadImage(ctx context.Context, imgToken, outDir string) (st
 		fileext = ".png" // 默认扩展名
 	}
 
-	// 确保输出目录存在
-	err = os.MkdirAll(outDir, 0o755)
-	if err != nil {
-		return imgToken, fmt.Errorf("创建目录失败: %v", err)
-	}
-
-	// 构建完整的文件路径
-	filename := filepath.Join(outDir, fmt.Sprintf("%s%s", imgToken, fileext))
-
 	// 先将远端文件读入内存，便于按类型进行无损压缩处理（目前仅对 PNG 应用）
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, resp.File); err != nil {
-		return imgToken, fmt.Errorf("读取远端文件失败: %v", err)
+		return imgToken, fmt.Errorf(utils.L("读取远端文件失败: %v", "failed to read remote file: %v"), err)
 	}
 
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o666)
+	relativePath, err := c.saveImage(buf.Bytes(), imgToken, fileext, resp.Filename, docDir, imageDir)
 	if err != nil {
-		return imgToken, fmt.Errorf("创建文件失败: %v", err)
-	}
-	defer file.Close()
-
-	// 对 PNG 进行无损压缩（BestCompression）。若解码/编码失败则回退为原始字节写入。
-	if strings.EqualFold(fileext, ".png") {
-		if img, err := png.Decode(bytes.NewReader(buf.Bytes())); err == nil {
-			enc := png.Encoder{CompressionLevel: png.BestCompression}
-			if err := enc.Encode(file, img); err == nil {
-				// 已完成优化写入
-			} else {
-				// 编码失败，回退原始字节
-				if _, werr := file.Write(buf.Bytes()); werr != nil {
-					return imgToken, fmt.Errorf("写入文件失败: %v", werr)
-				}
-			}
-		} else {
-			// 解码失败，回退原始字节
-			if _, werr := file.Write(buf.Bytes()); werr != nil {
-				return imgToken, fmt.Errorf("写入文件失败: %v", werr)
-			}
-		}
-	} else {
-		// 其他类型暂不处理，直接原样写入
-		if _, werr := file.Write(buf.Bytes()); werr != nil {
-			return imgToken, fmt.Errorf("写入文件失败: %v", werr)
-		}
+		return imgToken, err
+	}
+	if c.imageCache != nil {
+		c.imageCache.Store(imgToken, filepath.Join(docDir, filepath.FromSlash(relativePath)))
 	}
-
 	// 返回相对路径，用于markdown引用
-	relativePath := fmt.Sprintf("./%s/%s%s", filepath.Base(outDir), imgToken, fileext)
 	return relativePath, nil
 }
 
@@ -125,8 +249,10 @@ func findExistingLocalImage(outDir, imgToken string) (string, bool) {
 }
 
 func (c *Client) DownloadImageRaw(ctx context.Context, imgToken, imgDir string) (string, []byte, error) {
-	resp, _, err := c.larkClient.Drive.DownloadDriveMedia(ctx, &lark.DownloadDriveMediaReq{
-		FileToken: imgToken,
+	resp, err := doWithRetry(ctx, c, func() (*lark.DownloadDriveMediaResp, *lark.Response, error) {
+		return c.larkClient.Drive.DownloadDriveMedia(ctx, &lark.DownloadDriveMediaReq{
+			FileToken: imgToken,
+		})
 	})
 	if err != nil {
 		return imgToken, nil, err
@@ -140,13 +266,10 @@ func (c *Client) DownloadImageRaw(ctx context.Context, imgToken, imgDir string)
 
 // GetDocxDocumentMeta 仅获取文档的基本信息（不拉取块列表），用于快速判断修订版本
 func (c *Client) GetDocxDocumentMeta(ctx context.Context, docToken string) (*lark.DocxDocument, error) {
-	// 限流: 等待飞书API调用许可
-	if err := c.limiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("限流等待失败: %v", err)
-	}
-
-	resp, _, err := c.larkClient.Drive.GetDocxDocument(ctx, &lark.GetDocxDocumentReq{
-		DocumentID: docToken,
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetDocxDocumentResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetDocxDocument(ctx, &lark.GetDocxDocumentReq{
+			DocumentID: docToken,
+		})
 	})
 	if err != nil {
 		return nil, err
@@ -160,13 +283,10 @@ func (c *Client) GetDocxDocumentMeta(ctx context.Context, docToken string) (*lar
 }
 
 func (c *Client) GetDocxContent(ctx context.Context, docToken string) (*lark.DocxDocument, []*lark.DocxBlock, error) {
-	// 限流: 等待飞书API调用许可
-	if err := c.limiter.Wait(ctx); err != nil {
-		return nil, nil, fmt.Errorf("限流等待失败: %v", err)
-	}
-
-	resp, _, err := c.larkClient.Drive.GetDocxDocument(ctx, &lark.GetDocxDocumentReq{
-		DocumentID: docToken,
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetDocxDocumentResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetDocxDocument(ctx, &lark.GetDocxDocumentReq{
+			DocumentID: docToken,
+		})
 	})
 	if err != nil {
 		return nil, nil, err
@@ -180,13 +300,11 @@ func (c *Client) GetDocxContent(ctx context.Context, docToken string) (*lark.Doc
 	var pageToken *string
 	for {
 		// 每次分页调用都需要限流
-		if err := c.limiter.Wait(ctx); err != nil {
-			return docx, nil, fmt.Errorf("限流等待失败: %v", err)
-		}
-
-		resp2, _, err := c.larkClient.Drive.GetDocxBlockListOfDocument(ctx, &lark.GetDocxBlockListOfDocumentReq{
-			DocumentID: docx.DocumentID,
-			PageToken:  pageToken,
+		resp2, err := doWithRetry(ctx, c, func() (*lark.GetDocxBlockListOfDocumentResp, *lark.Response, error) {
+			return c.larkClient.Drive.GetDocxBlockListOfDocument(ctx, &lark.GetDocxBlockListOfDocumentReq{
+				DocumentID: docx.DocumentID,
+				PageToken:  pageToken,
+			})
 		})
 		if err != nil {
 			return docx, nil, err
@@ -203,16 +321,23 @@ func (c *Client) GetDocxContent(ctx context.Context, docToken string) (*lark.Doc
 // GetDocxTimes 获取 docx 文档的创建时间与最近修改时间
 // 返回值为指针，若对应字段不可用则为 nil
 func (c *Client) GetDocxTimes(ctx context.Context, docToken string) (createdAt *time.Time, updatedAt *time.Time, err error) {
-	resp, _, err := c.larkClient.Drive.GetDriveFileMeta(ctx, &lark.GetDriveFileMetaReq{
-		RequestDocs: []*lark.GetDriveFileMetaReqRequestDocs{
-			{DocToken: docToken, DocType: "docx"},
-		},
+	return c.GetFileTimes(ctx, docToken, "docx")
+}
+
+// GetFileTimes 获取指定类型（docx、sheet 等）云文档的创建时间与最近修改时间
+func (c *Client) GetFileTimes(ctx context.Context, docToken, docType string) (createdAt *time.Time, updatedAt *time.Time, err error) {
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetDriveFileMetaResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetDriveFileMeta(ctx, &lark.GetDriveFileMetaReq{
+			RequestDocs: []*lark.GetDriveFileMetaReqRequestDocs{
+				{DocToken: docToken, DocType: docType},
+			},
+		})
 	})
 	if err != nil {
 		return nil, nil, err
 	}
 	if resp == nil || len(resp.Metas) == 0 || resp.Metas[0] == nil {
-		return nil, nil, fmt.Errorf("未获取到文档元数据")
+		return nil, nil, errors.New(utils.L("未获取到文档元数据", "document metadata not found"))
 	}
 	meta := resp.Metas[0]
 
@@ -240,13 +365,10 @@ func (c *Client) GetDocxTimes(ctx context.Context, docToken string) (createdAt *
 }
 
 func (c *Client) GetWikiNodeInfo(ctx context.Context, token string) (*lark.GetWikiNodeRespNode, error) {
-	// 限流: 等待飞书API调用许可
-	if err := c.limiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("限流等待失败: %v", err)
-	}
-
-	resp, _, err := c.larkClient.Drive.GetWikiNode(ctx, &lark.GetWikiNodeReq{
-		Token: token,
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetWikiNodeResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetWikiNode(ctx, &lark.GetWikiNodeReq{
+			Token: token,
+		})
 	})
 	if err != nil {
 		return nil, err
@@ -255,20 +377,25 @@ func (c *Client) GetWikiNodeInfo(ctx context.Context, token string) (*lark.GetWi
 }
 
 func (c *Client) GetDriveFolderFileList(ctx context.Context, pageToken *string, folderToken *string) ([]*lark.GetDriveFileListRespFile, error) {
-	resp, _, err := c.larkClient.Drive.GetDriveFileList(ctx, &lark.GetDriveFileListReq{
-		PageSize:    nil,
-		PageToken:   pageToken,
-		FolderToken: folderToken,
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetDriveFileListResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetDriveFileList(ctx, &lark.GetDriveFileListReq{
+			PageSize:    nil,
+			PageToken:   pageToken,
+			FolderToken: folderToken,
+		})
 	})
 	if err != nil {
 		return nil, err
 	}
 	files := resp.Files
 	for resp.HasMore {
-		resp, _, err = c.larkClient.Drive.GetDriveFileList(ctx, &lark.GetDriveFileListReq{
-			PageSize:    nil,
-			PageToken:   &resp.NextPageToken,
-			FolderToken: folderToken,
+		nextPageToken := resp.NextPageToken
+		resp, err = doWithRetry(ctx, c, func() (*lark.GetDriveFileListResp, *lark.Response, error) {
+			return c.larkClient.Drive.GetDriveFileList(ctx, &lark.GetDriveFileListReq{
+				PageSize:    nil,
+				PageToken:   &nextPageToken,
+				FolderToken: folderToken,
+			})
 		})
 		if err != nil {
 			return nil, err
@@ -279,8 +406,10 @@ func (c *Client) GetDriveFolderFileList(ctx context.Context, pageToken *string,
 }
 
 func (c *Client) GetWikiName(ctx context.Context, spaceID string) (string, error) {
-	resp, _, err := c.larkClient.Drive.GetWikiSpace(ctx, &lark.GetWikiSpaceReq{
-		SpaceID: spaceID,
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetWikiSpaceResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetWikiSpace(ctx, &lark.GetWikiSpaceReq{
+			SpaceID: spaceID,
+		})
 	})
 
 	if err != nil {
@@ -291,16 +420,13 @@ func (c *Client) GetWikiName(ctx context.Context, spaceID string) (string, error
 }
 
 func (c *Client) GetWikiNodeList(ctx context.Context, spaceID string, parentNodeToken *string) ([]*lark.GetWikiNodeListRespItem, error) {
-	// 限流: 等待飞书API调用许可
-	if err := c.limiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("限流等待失败: %v", err)
-	}
-
-	resp, _, err := c.larkClient.Drive.GetWikiNodeList(ctx, &lark.GetWikiNodeListReq{
-		SpaceID:         spaceID,
-		PageSize:        nil,
-		PageToken:       nil,
-		ParentNodeToken: parentNodeToken,
+	resp, err := doWithRetry(ctx, c, func() (*lark.GetWikiNodeListResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetWikiNodeList(ctx, &lark.GetWikiNodeListReq{
+			SpaceID:         spaceID,
+			PageSize:        nil,
+			PageToken:       nil,
+			ParentNodeToken: parentNodeToken,
+		})
 	})
 
 	if err != nil {
@@ -312,11 +438,13 @@ func (c *Client) GetWikiNodeList(ctx context.Context, spaceID string, parentNode
 
 	for resp.HasMore && previousPageToken != resp.PageToken {
 		previousPageToken = resp.PageToken
-		resp, _, err := c.larkClient.Drive.GetWikiNodeList(ctx, &lark.GetWikiNodeListReq{
-			SpaceID:         spaceID,
-			PageSize:        nil,
-			PageToken:       &resp.PageToken,
-			ParentNodeToken: parentNodeToken,
+		resp, err := doWithRetry(ctx, c, func() (*lark.GetWikiNodeListResp, *lark.Response, error) {
+			return c.larkClient.Drive.GetWikiNodeList(ctx, &lark.GetWikiNodeListReq{
+				SpaceID:         spaceID,
+				PageSize:        nil,
+				PageToken:       &previousPageToken,
+				ParentNodeToken: parentNodeToken,
+			})
 		})
 
 		if err != nil {
@@ -356,7 +484,9 @@ func (c *Client) GetChildNodes(ctx context.Context, spaceID, parentNodeToken str
 			req.PageToken = &pageToken
 		}
 
-		resp, _, err := c.larkClient.Drive.GetWikiNodeList(ctx, req)
+		resp, err := doWithRetry(ctx, c, func() (*lark.GetWikiNodeListResp, *lark.Response, error) {
+			return c.larkClient.Drive.GetWikiNodeList(ctx, req)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -387,26 +517,117 @@ func (c *Client) GetChildNodes(ctx context.Context, spaceID, parentNodeToken str
 // GetAllChildNodes 递归获取指定父节点下的所有子节点（包括子节点的子节点）
 func (c *Client) GetAllChildNodes(ctx context.Context, spaceID, rootNodeToken string) ([]*Document, error) {
 	var result []*Document
+	err := c.WalkChildNodes(ctx, spaceID, rootNodeToken, func(batch []*Document) error {
+		result = append(result, batch...)
+		return nil
+	}, nil)
+	return result, err
+}
 
+// WalkChildNodes 深度优先遍历指定父节点下的所有子节点
+// 每获取一个父节点的直接子节点就交给 visit 处理，再递归其子节点，调用方无需一次性持有整棵树；
+// visit 返回错误时停止遍历。denied 不为空时，无权限读取的子树交给 denied 记录后跳过，继续遍历其余节点
+func (c *Client) WalkChildNodes(ctx context.Context, spaceID, rootNodeToken string, visit func(batch []*Document) error, denied func(node *Document, err error)) error {
 	var processNode func(nodeToken string) error
 	processNode = func(nodeToken string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		nodes, err := c.GetChildNodes(ctx, spaceID, nodeToken)
 		if err != nil {
 			return err
 		}
+		if err := visit(nodes); err != nil {
+			return err
+		}
 
 		for _, node := range nodes {
-			result = append(result, node)
-
 			// 如果有子节点，递归处理
 			if node.HasChild {
 				if err := processNode(node.NodeToken); err != nil {
-					return err
+					// 更深层的无权限子树已在其所在层跳过，这里的权限错误只可能来自 node 本身
+					if denied == nil || !IsPermissionDenied(err) {
+						return err
+					}
+					denied(node, err)
 				}
 			}
 		}
 		return nil
 	}
 
-	return result, processNode(rootNodeToken)
+	return processNode(rootNodeToken)
+}
+
+// feishuRateLimitCode 飞书接口触发频率限制时返回的错误码
+const feishuRateLimitCode = 99991400
+
+// feishuPermissionCodes 飞书表示应用无权访问资源的错误码
+var feishuPermissionCodes = map[int64]bool{
+	1770032:  true, // 新版文档：无文档阅读权限
+	131006:   true, // 知识库：无节点或空间的访问权限
+	1061004:  true, // 云空间：无文件夹或文件的访问权限
+	91403:    true, // 电子表格：无表格访问权限
+	99991672: true, // 应用未开通接口所需的权限范围
+}
+
+// IsPermissionDenied 判断错误是否因应用对资源没有访问权限
+func IsPermissionDenied(err error) bool {
+	var larkErr *lark.Error
+	return errors.As(err, &larkErr) && feishuPermissionCodes[larkErr.Code]
+}
+
+// doWithRetry 执行一次飞书 API 调用：每次尝试前先通过限流器，
+// 遇到可重试错误时按 retryBaseDelay*2^n（带 jitter）退避后重试，最多重试 maxRetries 次；
+// 最终失败时常见错误码附带中文说明与修复建议（见 ExplainError）
+func doWithRetry[T any](ctx context.Context, c *Client, call func() (T, *lark.Response, error)) (T, error) {
+	var zero T
+	for attempt := 0; ; attempt++ {
+		// 限流: 等待飞书API调用许可
+		if err := c.limiter.Wait(ctx); err != nil {
+			return zero, fmt.Errorf(utils.L("限流等待失败: %v", "rate limiter wait failed: %v"), err)
+		}
+
+		result, resp, err := call()
+		if err == nil {
+			return result, nil
+		}
+		if attempt >= c.maxRetries || ctx.Err() != nil || !isRetryableError(resp, err) {
+			return result, ExplainError(err)
+		}
+
+		timer := time.NewTimer(retryDelay(c.retryBaseDelay, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay 第 attempt 次重试前的等待时间：base*2^attempt，并在 [0.5, 1.5) 倍之间随机抖动
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	d := base << attempt
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// isRetryableError 判断错误是否值得重试：429、5xx 网关类错误、飞书频率限制错误码与网络超时
+func isRetryableError(resp *lark.Response, err error) bool {
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	var larkErr *lark.Error
+	if errors.As(err, &larkErr) && larkErr.Code == feishuRateLimitCode {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
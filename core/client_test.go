@@ -0,0 +1,232 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+const testDocxResp = `{"code":0,"msg":"ok","data":{"document":{"document_id":"doxTest","revision_id":7,"title":"标题"}}}`
+
+func TestDoWithRetryRecoversFrom429(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"code":99991400,"msg":"request trigger frequency limit"}`)
+			return
+		}
+		fmt.Fprint(w, testDocxResp)
+	})
+
+	meta, err := client.GetDocxDocumentMeta(context.Background(), "doxTest")
+	if err != nil {
+		t.Fatalf("GetDocxDocumentMeta() error = %v", err)
+	}
+	if meta.Title != "标题" || meta.RevisionID != 7 {
+		t.Errorf("GetDocxDocumentMeta() = %+v", meta)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("calls = %d, want 2 (429 then success)", got)
+	}
+}
+
+func TestDoWithRetryGivesUpOnPersistent500(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"code":1,"msg":"internal error"}`)
+	})
+
+	if _, err := client.GetDocxDocumentMeta(context.Background(), "doxTest"); err == nil {
+		t.Fatal("GetDocxDocumentMeta() error = nil, want error after retries")
+	}
+	if got, want := atomic.LoadInt32(&calls), int32(DefaultMaxRetries+1); got != want {
+		t.Errorf("calls = %d, want %d (first attempt + %d retries)", got, want, DefaultMaxRetries)
+	}
+}
+
+func TestDoWithRetryDoesNotRetryPermissionError(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, `{"code":1770032,"msg":"forbidden"}`)
+	})
+
+	_, err := client.GetDocxDocumentMeta(context.Background(), "doxTest")
+	if !IsPermissionDenied(err) {
+		t.Fatalf("error = %v, want permission denied", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestRetryDelayBounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 0; attempt < 6; attempt++ {
+		d := base << attempt
+		for i := 0; i < 50; i++ {
+			got := retryDelay(base, attempt)
+			if got < d/2 || got >= d/2+d {
+				t.Fatalf("retryDelay(%v, %d) = %v, want in [%v, %v)", base, attempt, got, d/2, d/2+d)
+			}
+		}
+	}
+	if got := retryDelay(0, 3); got != 0 {
+		t.Errorf("retryDelay(0, 3) = %v, want 0", got)
+	}
+}
+
+// newAuthTestClient 返回鉴权接口由 handler 处理、不重试的客户端
+func newAuthTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := newFakeFeishu(t, map[string]http.HandlerFunc{authPath: handler})
+	return newFakeClient(srv, "cli_test", "secret", WithRetry(1, time.Millisecond))
+}
+
+func TestVerifyCredentials(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		client := newAuthTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"code":0,"msg":"ok","tenant_access_token":"t-app","expire":7200}`)
+		})
+		if err := client.VerifyCredentials(context.Background()); err != nil {
+			t.Errorf("VerifyCredentials() = %v, want nil", err)
+		}
+	})
+
+	t.Run("invalid secret", func(t *testing.T) {
+		client := newAuthTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"code":10014,"msg":"app secret invalid"}`)
+		})
+		err := client.VerifyCredentials(context.Background())
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("VerifyCredentials() = %v, want ErrInvalidCredentials", err)
+		}
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		// 频率限制等非凭据错误码不应提示凭据错误
+		client := newAuthTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"code":99991400,"msg":"request trigger frequency limit"}`)
+		})
+		err := client.VerifyCredentials(context.Background())
+		if errors.Is(err, ErrInvalidCredentials) || !errors.Is(err, ErrNetworkUnreachable) {
+			t.Errorf("VerifyCredentials() = %v, want ErrNetworkUnreachable", err)
+		}
+	})
+
+	t.Run("network error", func(t *testing.T) {
+		srv := newFakeFeishu(t, nil)
+		srv.Close() // 连接被拒绝
+		client := newFakeClient(srv, "cli_test", "secret", WithRetry(1, time.Millisecond))
+		err := client.VerifyCredentials(context.Background())
+		if !errors.Is(err, ErrNetworkUnreachable) {
+			t.Errorf("VerifyCredentials() = %v, want ErrNetworkUnreachable", err)
+		}
+	})
+}
+
+func TestDownloadImageForbidden(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		forbidden bool
+	}{
+		{"http 403", http.StatusForbidden, `{"code":1069902,"msg":"forbidden"}`, true},
+		{"permission code", http.StatusBadRequest, `{"code":1061004,"msg":"no permission"}`, true},
+		// 错误信息中恰好含有 403 / Forbidden 字样不应视为权限不足
+		{"message mentions 403", http.StatusNotFound, `{"code":1061007,"msg":"file 403 Forbidden was deleted"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			_, err := client.DownloadImage(context.Background(), "imgToken", t.TempDir(), "img")
+			if err == nil {
+				t.Fatal("DownloadImage() error = nil")
+			}
+			if got := errors.Is(err, ErrImageForbidden); got != tt.forbidden {
+				t.Errorf("errors.Is(%v, ErrImageForbidden) = %v, want %v", err, got, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorizationHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		appID      string
+		opts       []ClientOption
+		wantAuth   string
+		tokenCalls int32
+	}{
+		{"app credentials only", "cli_test", nil, "Bearer t-app", 1},
+		{"user token only", "", []ClientOption{WithUserAccessToken("u-user")}, "Bearer u-user", 0},
+		{"user token preferred", "cli_test", []ClientOption{WithUserAccessToken("u-user")}, "Bearer u-user", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokenCalls int32
+			var gotAuth string
+			srv := newFakeFeishu(t, map[string]http.HandlerFunc{
+				authPath: func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&tokenCalls, 1)
+					fmt.Fprint(w, `{"code":0,"msg":"ok","tenant_access_token":"t-app","expire":7200}`)
+				},
+				"/": func(w http.ResponseWriter, r *http.Request) {
+					gotAuth = r.Header.Get("Authorization")
+					fmt.Fprint(w, testDocxResp)
+				},
+			})
+
+			secret := ""
+			if tt.appID != "" {
+				secret = "secret"
+			}
+			client := newFakeClient(srv, tt.appID, secret, tt.opts...)
+			if _, err := client.GetDocxDocumentMeta(context.Background(), "doxTest"); err != nil {
+				t.Fatal(err)
+			}
+			if gotAuth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", gotAuth, tt.wantAuth)
+			}
+			if got := atomic.LoadInt32(&tokenCalls); got != tt.tokenCalls {
+				t.Errorf("tenant_access_token calls = %d, want %d", got, tt.tokenCalls)
+			}
+		})
+	}
+}
+
+func TestVerifyCredentialsEnglish(t *testing.T) {
+	t.Cleanup(func() { utils.SetLang("zh") })
+	client := newAuthTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":10014,"msg":"app secret invalid"}`)
+	})
+
+	utils.SetLang("en")
+	err := client.VerifyCredentials(context.Background())
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("VerifyCredentials() = %v, want ErrInvalidCredentials", err)
+	}
+	if want := "invalid AppId/AppSecret: app secret invalid (error code 10014)"; err.Error() != want {
+		t.Errorf("VerifyCredentials() = %q, want %q", err.Error(), want)
+	}
+
+	// 哨兵错误的文案在输出时按当前语言选择
+	utils.SetLang("zh")
+	if got := ErrInvalidCredentials.Error(); got != "AppId/AppSecret 错误" {
+		t.Errorf("zh ErrInvalidCredentials = %q", got)
+	}
+}
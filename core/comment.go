@@ -0,0 +1,187 @@
+// Package core - 文档评论
+// 分页拉取云文档的全部评论（含回复），渲染为文档末尾的"评论"章节；
+// SDK 的评论结构缺少被评论的文字片段（quote），因此直接请求评论接口
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/chyroc/lark"
+)
+
+// DocComment 一条评论，Replies[0] 为评论本身，其余为回复
+type DocComment struct {
+	CommentID string
+	Quote     string // 被评论的文字片段，全文评论时为空
+	IsWhole   bool   // 是否为全文评论
+	IsSolved  bool
+	Replies   []*DocCommentReply
+}
+
+// DocCommentReply 评论或回复的一条发言
+type DocCommentReply struct {
+	Author     string // 作者姓名，无通讯录权限时为 open_id
+	CreateTime time.Time
+	Content    string
+}
+
+// commentListReq 评论列表请求参数
+type commentListReq struct {
+	FileToken string  `path:"file_token" json:"-"`
+	FileType  string  `query:"file_type" json:"-"`
+	PageToken *string `query:"page_token" json:"-"`
+	PageSize  int64   `query:"page_size" json:"-"`
+}
+
+// commentListResp 评论列表响应，字段与飞书评论接口一致
+type commentListResp struct {
+	Code int64  `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data *struct {
+		HasMore   bool   `json:"has_more,omitempty"`
+		PageToken string `json:"page_token,omitempty"`
+		Items     []*struct {
+			CommentID string `json:"comment_id,omitempty"`
+			IsSolved  bool   `json:"is_solved,omitempty"`
+			IsWhole   bool   `json:"is_whole,omitempty"`
+			Quote     string `json:"quote,omitempty"`
+			ReplyList *struct {
+				Replies []*struct {
+					UserID     string                                                 `json:"user_id,omitempty"`
+					CreateTime int64                                                  `json:"create_time,omitempty"`
+					Content    *lark.GetDriveCommentListRespItemReplyListReplyContent `json:"content,omitempty"`
+				} `json:"replies,omitempty"`
+			} `json:"reply_list,omitempty"`
+		} `json:"items,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// GetDocxComments 获取新版文档的全部评论（含已解决的评论），按接口返回顺序排列
+func (c *Client) GetDocxComments(ctx context.Context, docToken string) ([]*DocComment, error) {
+	var comments []*DocComment
+	pageToken := ""
+	for {
+		req := &commentListReq{FileToken: docToken, FileType: "docx", PageSize: 100}
+		if pageToken != "" {
+			req.PageToken = &pageToken
+		}
+		resp, err := doWithRetry(ctx, c, func() (*commentListResp, *lark.Response, error) {
+			resp := new(commentListResp)
+			response, err := c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+				Scope:                 "Drive",
+				API:                   "GetDriveCommentList",
+				Method:                "GET",
+				URL:                   c.baseURL + "/open-apis/drive/v1/files/:file_token/comments",
+				Body:                  req,
+				NeedTenantAccessToken: true,
+			}, resp)
+			return resp, response, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Data == nil {
+			break
+		}
+
+		for _, item := range resp.Data.Items {
+			if item == nil {
+				continue
+			}
+			comment := &DocComment{
+				CommentID: item.CommentID,
+				Quote:     item.Quote,
+				IsWhole:   item.IsWhole,
+				IsSolved:  item.IsSolved,
+			}
+			if item.ReplyList != nil {
+				for _, r := range item.ReplyList.Replies {
+					if r == nil {
+						continue
+					}
+					comment.Replies = append(comment.Replies, &DocCommentReply{
+						Author:     c.userName(ctx, r.UserID),
+						CreateTime: time.Unix(r.CreateTime, 0),
+						Content:    c.commentText(ctx, r.Content),
+					})
+				}
+			}
+			comments = append(comments, comment)
+		}
+
+		// 检查是否有下一页
+		if !resp.Data.HasMore || resp.Data.PageToken == "" || resp.Data.PageToken == pageToken {
+			break
+		}
+		pageToken = resp.Data.PageToken
+	}
+	return comments, nil
+}
+
+// userName 查询用户姓名，无通讯录权限或查询失败时返回 open_id
+func (c *Client) userName(ctx context.Context, openID string) string {
+	if openID == "" {
+		return ""
+	}
+	user, err := c.getUser(ctx, openID)
+	if err != nil || user.Name == "" {
+		return openID
+	}
+	return user.Name
+}
+
+// commentText 把评论内容元素拼接为纯文本：@联系人 渲染为 @姓名，@云文档 渲染为链接
+func (c *Client) commentText(ctx context.Context, content *lark.GetDriveCommentListRespItemReplyListReplyContent) string {
+	if content == nil {
+		return ""
+	}
+	buf := new(strings.Builder)
+	for _, e := range content.Elements {
+		switch {
+		case e == nil:
+		case e.TextRun != nil:
+			buf.WriteString(e.TextRun.Text)
+		case e.Person != nil:
+			buf.WriteString("@" + c.userName(ctx, e.Person.UserID))
+		case e.DocsLink != nil:
+			buf.WriteString(e.DocsLink.URL)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// RenderComments 把评论渲染为"评论"章节：被评论的文字片段作为引用，其后列出评论与回复，时间按东八区显示
+// 没有评论时返回空字符串
+func RenderComments(comments []*DocComment) string {
+	if len(comments) == 0 {
+		return ""
+	}
+	buf := new(strings.Builder)
+	buf.WriteString(utils.L("## 评论\n\n", "## Comments\n\n"))
+	for _, comment := range comments {
+		if comment.IsWhole || comment.Quote == "" {
+			buf.WriteString(utils.L("> （全文评论）\n\n", "> (whole document)\n\n"))
+		} else {
+			buf.WriteString("> " + strings.ReplaceAll(strings.TrimSpace(comment.Quote), "\n", "\n> ") + "\n\n")
+		}
+		for i, reply := range comment.Replies {
+			indent := ""
+			if i > 0 {
+				indent = "  "
+			}
+			line := fmt.Sprintf("%s- **%s** %s：%s", indent, reply.Author,
+				reply.CreateTime.In(reminderLocation).Format("2006-01-02 15:04"),
+				strings.ReplaceAll(reply.Content, "\n", "<br/>"))
+			if i == 0 && comment.IsSolved {
+				line += utils.L("（已解决）", " (resolved)")
+			}
+			buf.WriteString(line + "\n")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -3,35 +3,119 @@
 package core
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
 )
 
 // Config 表示 feishu2md 应用程序的完整配置
 type Config struct {
-	Feishu FeishuConfig // 飞书 API 配置
-	Output OutputConfig // 输出格式配置
-	PicGo  PicGoConfig  // PicGo 图床配置
+	Feishu    FeishuConfig    // 飞书 API 配置
+	RateLimit RateLimitConfig // 飞书 API 限流配置
+	Output    OutputConfig    // 输出格式配置
+	PicGo     PicGoConfig     // PicGo 图床配置
 }
 
 // FeishuConfig 包含飞书/LarkSuite API 凭据
 type FeishuConfig struct {
-	AppId     string // 飞书应用ID
-	AppSecret string // 飞书应用密钥
+	AppId           string // 飞书应用ID
+	AppSecret       string // 飞书应用密钥
+	UserAccessToken string // 用户身份的 user_access_token，配置后以用户身份访问文档，优先于应用凭据
+}
+
+// RateLimitConfig 飞书 API 限流速率，应与应用的调用配额一致
+type RateLimitConfig struct {
+	PerSecond int // 每秒调用次数上限
+	PerMinute int // 每分钟调用次数上限
+	Burst     int // 秒级限流允许的短时突发请求数
+}
+
+// DefaultRateLimit 返回飞书应用的默认配额：5次/秒、100次/分钟
+func DefaultRateLimit() RateLimitConfig {
+	return RateLimitConfig{PerSecond: 5, PerMinute: 100, Burst: 5}
 }
 
 // OutputConfig 包含文档输出格式设置
 type OutputConfig struct {
 	OutputDir       string // 文档输出目录
 	ImageDir        string // 存储下载图片的目录
+	ImageByMonth    bool   // 图片按文档修改时间归档到 YYYY/MM 子目录（本地与图床一致）
 	TitleAsFilename bool   // 使用文档标题作为文件名而不是令牌
+	SlugFilename    bool   // 使用标题的 ASCII slug 作为文件名（优先于 TitleAsFilename）
+	StripTitleEmoji bool   // 生成文件名时去掉标题开头的 emoji（frontmatter 保留原标题）
 	UseHTMLTags     bool   // 使用HTML标签而不是markdown进行某些格式化
 	SkipImgDownload bool   // 跳过下载图片并保留原始链接
 	NoBodyTitle     bool   // 禁用正文开头的 H1 标题（因为 frontmatter 已包含 title）
+	SkipFrontmatter bool   // 不输出 frontmatter，只写正文
+
+	StripCodeLineNumbers bool   // 去除代码块每行开头的行号前缀
+	FixHeadingLevels     bool   // 修复跳级的标题层级（如 H1 下直接出现 H3）
+	LintFix              bool   // 按 markdownlint 常见规则修复输出（标题前后空行、列表标记统一等）
+	SplitOnDivider       bool   // 按正文顶层分割线把文档拆分为多个章节文件
+	CellMaxWidth         int    // 表格单元格可见字符上限，超出部分截断并加省略号，0 表示不限制
+	MaxFileNameBytes     int    // 标题文件名（不含扩展名）的字节上限，超出部分截断
+	CalloutStyle         string // 高亮块渲染方式：alert（GFM alert，默认）或 quote（带图标的引用块）
+	StripWatermark       bool   // 移除混入正文的水印文本块
+	WatermarkPattern     string // 识别水印文本块的正则表达式（整块文本匹配），为空时使用默认规则
+	DraftPattern         string // 识别草稿标题的正则表达式，为空时使用默认规则
+	DefaultCategory      string // frontmatter 缺省分类名，为空时不输出 categories
+	FrontmatterTemplate  string // 自定义 frontmatter 的 Go text/template 模板文件，为空时使用内置 YAML 格式
+	FrontmatterFlavor    string // 内置 frontmatter 的风格：default 或 hexo（categories 输出为嵌套层级）
+	StripExif            bool   // 移除下载的 JPEG 图片中的 EXIF 信息（位置、设备等）
+	ImageQuality         int    // 重新编码 JPEG 图片的质量（1-100）
+	ImageFormat          string // 图片输出格式：keep（默认）、png、jpeg 或 webp
+	SitemapBaseURL       string // 站点前缀，非空时知识库下载完成后生成 sitemap.xml
+	PostProcessCmd       string // 后处理命令，生成的内容经其 stdin/stdout 转换后再写盘
+	FormulaOCRURL        string // 公式识别服务地址，非空时把疑似公式的图片转为 LaTeX，失败时保留图片
+	AutoSpace            bool   // 格式化时在中西文之间自动插入空格
+	FixTermTypo          bool   // 格式化时修正常见术语的拼写与大小写（如 github -> GitHub）
+	Timezone             string // frontmatter 时间、日期提醒等使用的 IANA 时区名，如 Asia/Shanghai、UTC
+
+	DownloadExternalImages bool  // 下载文档中的外链图片（非飞书 media）并本地化/上传图床
+	ReportAPIStats         bool  // 运行结束后输出 API 调用次数、限流等待与耗时分位
+	Permalink              bool  // frontmatter 输出基于 docToken 短 hash 的 permalink
+	Author                 bool  // frontmatter 输出文档作者（所有者）姓名与头像
+	Draft                  bool  // frontmatter 输出 draft，按标题中的草稿标记判断
+	WithComments           bool  // 在文档末尾追加"评论"章节
+	UTCDates               bool  // frontmatter 额外输出 UTC 时间 date_utc / updated_utc
+	DryRun                 bool  // 只预览将新增/修改/跳过的文档，不写入任何文件
+	SkipEmpty              bool  // 跳过没有实质内容的空壳文档，不生成 md
+	Gallery                bool  // HTML 模式下把连续图片包成画廊 div
+	SrcsetWidths           []int // HTML 模式下为本地图片生成的缩略图宽度，非空时输出 <img srcset>
+	ImagesManifest         bool  // 下载结束后在输出目录生成图片清单 images-manifest.json
+
+	ReportPath string // 内容盘点报告（块数、字数、图片数）的输出路径，为空时不生成
+
+	Since time.Time // 增量下载阈值：最近修改时间早于它的文档跳过，零值表示不过滤
+
+	SharedRateLimitFile string // 跨进程共享限流预算文件，多个进程指向同一文件时合计不超过飞书配额
+
+	DocConcurrency int // 文档下载并发数，0 表示使用默认值 10
+	ImgConcurrency int // 单文档内图片下载并发数，0 表示使用默认值
 }
 
+// DefaultTimezone 默认的输出时区
+const DefaultTimezone = "Asia/Shanghai"
+
+// DefaultDraftPattern 默认的草稿标题识别规则：标题以 草稿、未发布、Draft、WIP 等标记开头
+const DefaultDraftPattern = `(?i)^\s*[\[【(（]?\s*(草稿|未发布|未完成|draft|wip)([\]】)）:：\s\-—_|]|$)`
+
+// DefaultWatermarkPattern 默认的水印文本识别规则：整块仅包含常见水印用语及署名/编号
+const DefaultWatermarkPattern = `(?i)^[\s【\[(（]*(内部资料|仅供内部使用|仅供内部参考|请勿外传|禁止外传|严禁外传|confidential|internal use only)[\s】\])）,，。.:：\-—_@#\w\p{Han}]*$`
+
 // PicGoConfig 包含 PicGo 图床配置
 type PicGoConfig struct {
-	Enabled bool // 是否启用 PicGo 图床上传
+	Enabled       bool          // 是否启用 PicGo 图床上传
+	BackupConfigs []string      // 备用图床的 picgo 配置文件路径，主图床失败时按顺序切换
+	URLScheme     string        // 输出图床 URL 的协议：https、http 或 //（相对协议），为空保持原样
+	DocKeyPrefix  bool          // 按文档划分图床目录：object key 前缀追加 docToken
+	CacheTTL      time.Duration // 上传缓存有效期，过期后重新上传，0 表示永不过期
+	UploadRetries int           // 单个图床上传失败后的重试次数，0 表示不重试
+	Concurrency   int           // 进程内同时进行的上传数上限，0 表示使用默认值
 }
 
 // NewConfig 使用提供的应用凭据和默认输出设置创建新配置
@@ -41,12 +125,21 @@ func NewConfig(appId, appSecret string) *Config {
 			AppId:     appId,
 			AppSecret: appSecret,
 		},
+		RateLimit: DefaultRateLimit(),
+		PicGo: PicGoConfig{
+			UploadRetries: 3, // 默认失败后重试 3 次
+		},
 		Output: OutputConfig{
-			OutputDir:       "./dist", // 默认输出目录
-			ImageDir:        "img",    // 默认图片目录
-			TitleAsFilename: true,     // 默认使用文档标题作为文件名
-			UseHTMLTags:     false,    // 默认使用markdown格式
-			SkipImgDownload: false,    // 默认下载图片
+			OutputDir:        "./dist", // 默认输出目录
+			ImageDir:         "img",    // 默认图片目录
+			TitleAsFilename:  true,     // 默认使用文档标题作为文件名
+			UseHTMLTags:      false,    // 默认使用markdown格式
+			SkipImgDownload:  false,    // 默认下载图片
+			DefaultCategory:  "未分类",    // 默认分类
+			AutoSpace:        true,     // 默认在中西文之间插入空格
+			MaxFileNameBytes: 200,      // 默认文件名上限 200 字节
+			ImageQuality:     DefaultImageQuality,
+			Timezone:         DefaultTimezone,
 		},
 	}
 }
@@ -64,6 +157,9 @@ func LoadConfig(appId, appSecret string) (*Config, error) {
 	if envAppSecret := os.Getenv("FEISHU_APP_SECRET"); envAppSecret != "" {
 		config.Feishu.AppSecret = envAppSecret
 	}
+	if token := os.Getenv("FEISHU_USER_ACCESS_TOKEN"); token != "" {
+		config.Feishu.UserAccessToken = token
+	}
 
 	// 使用CLI参数覆盖（最高优先级）
 	if appId != "" {
@@ -76,12 +172,105 @@ func LoadConfig(appId, appSecret string) (*Config, error) {
 	// 加载输出配置（从环境变量）
 	loadOutputConfig(config)
 
+	// 文件名字节上限（从环境变量）
+	maxFileNameBytes, err := positiveIntEnv("MAX_FILENAME_BYTES")
+	if err != nil {
+		return nil, err
+	}
+	if maxFileNameBytes > 0 {
+		config.Output.MaxFileNameBytes = maxFileNameBytes
+	}
+
+	// JPEG 重新编码质量（从环境变量）
+	imageQuality, err := positiveIntEnv("IMAGE_QUALITY")
+	if err != nil {
+		return nil, err
+	}
+	if imageQuality > 0 {
+		config.Output.ImageQuality = imageQuality
+	}
+
+	// 加载限流配置（从环境变量）
+	if err := loadRateLimitConfig(config); err != nil {
+		return nil, err
+	}
+
+	// 加载 Markdown 格式化选项（从环境变量）
+	if err := loadFormatConfig(config); err != nil {
+		return nil, err
+	}
+
 	// 加载 PicGo 配置（从环境变量）
-	loadPicGoConfig(config)
+	if err := loadPicGoConfig(config); err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
 
+// loadRateLimitConfig 从环境变量加载限流速率，未设置的项保持默认值
+// 只调整每秒上限时，突发数随之调整为同一值
+func loadRateLimitConfig(config *Config) error {
+	perSecond, err := positiveIntEnv("FEISHU_RATE_PER_SECOND")
+	if err != nil {
+		return err
+	}
+	if perSecond > 0 {
+		config.RateLimit.PerSecond = perSecond
+		config.RateLimit.Burst = perSecond
+	}
+	perMinute, err := positiveIntEnv("FEISHU_RATE_PER_MINUTE")
+	if err != nil {
+		return err
+	}
+	if perMinute > 0 {
+		config.RateLimit.PerMinute = perMinute
+	}
+	burst, err := positiveIntEnv("FEISHU_RATE_BURST")
+	if err != nil {
+		return err
+	}
+	if burst > 0 {
+		config.RateLimit.Burst = burst
+	}
+	return nil
+}
+
+// loadFormatConfig 从环境变量加载 Markdown 格式化选项，未设置的保持默认值
+func loadFormatConfig(config *Config) error {
+	if err := boolEnv("LUTE_AUTO_SPACE", &config.Output.AutoSpace); err != nil {
+		return err
+	}
+	return boolEnv("LUTE_FIX_TERM_TYPO", &config.Output.FixTermTypo)
+}
+
+// boolEnv 读取布尔环境变量（true/false/1/0 等），未设置时不修改 target，无法解析时报错
+func boolEnv(name string, target *bool) error {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf(utils.L("%s 必须为 true 或 false，当前为 %q", "%s must be true or false, got %q"), name, value)
+	}
+	*target = b
+	return nil
+}
+
+// positiveIntEnv 读取正整数环境变量，未设置时返回 0，非正数或无法解析时报错
+func positiveIntEnv(name string) (int, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf(utils.L("%s 必须为正整数，当前为 %q", "%s must be a positive integer, got %q"), name, value)
+	}
+	return n, nil
+}
+
 // loadOutputConfig 从环境变量加载输出配置
 func loadOutputConfig(config *Config) {
 	// 输出目录
@@ -92,12 +281,97 @@ func loadOutputConfig(config *Config) {
 	if imageDir := os.Getenv("IMAGE_DIR"); imageDir != "" {
 		config.Output.ImageDir = imageDir
 	}
+	// 图片按月归档
+	if byMonth := os.Getenv("IMAGE_BY_MONTH"); byMonth == "true" || byMonth == "1" {
+		config.Output.ImageByMonth = true
+	}
+	// 输出时区
+	if tz := os.Getenv("OUTPUT_TIMEZONE"); tz != "" {
+		config.Output.Timezone = tz
+	}
+	// 图片输出格式
+	if format := os.Getenv("IMAGE_FORMAT"); format != "" {
+		config.Output.ImageFormat = format
+	}
+	// 站点地图前缀
+	if sitemapBaseURL := os.Getenv("SITEMAP_BASE_URL"); sitemapBaseURL != "" {
+		config.Output.SitemapBaseURL = sitemapBaseURL
+	}
+	// 缺省分类（允许显式设置为空以不输出 categories）
+	if defaultCategory, ok := os.LookupEnv("DEFAULT_CATEGORY"); ok {
+		config.Output.DefaultCategory = defaultCategory
+	}
+	// 公式图片识别服务
+	if endpoint := os.Getenv("FORMULA_OCR_URL"); endpoint != "" {
+		config.Output.FormulaOCRURL = endpoint
+	}
+	// 自定义 frontmatter 模板
+	if path := os.Getenv("FRONTMATTER_TEMPLATE"); path != "" {
+		config.Output.FrontmatterTemplate = path
+	}
+	// 内置 frontmatter 风格
+	if flavor := os.Getenv("FRONTMATTER_FLAVOR"); flavor != "" {
+		config.Output.FrontmatterFlavor = flavor
+	}
+	// 跨进程共享限流文件
+	if path := os.Getenv("SHARED_RATE_LIMIT_FILE"); path != "" {
+		config.Output.SharedRateLimitFile = path
+	}
+	// 水印识别规则
+	if pattern := os.Getenv("WATERMARK_PATTERN"); pattern != "" {
+		config.Output.WatermarkPattern = pattern
+	}
+	// 草稿标题识别规则
+	if pattern := os.Getenv("DRAFT_PATTERN"); pattern != "" {
+		config.Output.DraftPattern = pattern
+	}
 }
 
 // loadPicGoConfig 从环境变量加载 PicGo 配置
-func loadPicGoConfig(config *Config) {
+func loadPicGoConfig(config *Config) error {
 	// 检查是否启用 PicGo
 	if enabled := os.Getenv("PICGO_ENABLED"); enabled == "true" || enabled == "1" {
 		config.PicGo.Enabled = true
 	}
+	// 按文档划分图床目录
+	if prefix := os.Getenv("PICGO_DOC_PREFIX"); prefix == "true" || prefix == "1" {
+		config.PicGo.DocKeyPrefix = true
+	}
+	// 图床 URL 协议
+	if scheme := os.Getenv("PICGO_URL_SCHEME"); scheme != "" {
+		config.PicGo.URLScheme = scheme
+	}
+	// 备用图床配置，逗号分隔的多个 picgo 配置文件路径
+	if backups := os.Getenv("PICGO_BACKUP_CONFIGS"); backups != "" {
+		for _, path := range strings.Split(backups, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				config.PicGo.BackupConfigs = append(config.PicGo.BackupConfigs, path)
+			}
+		}
+	}
+	// 上传失败重试次数
+	if retries := strings.TrimSpace(os.Getenv("PICGO_UPLOAD_RETRIES")); retries != "" {
+		n, err := strconv.Atoi(retries)
+		if err != nil || n < 0 {
+			return fmt.Errorf(utils.L("PICGO_UPLOAD_RETRIES 必须为非负整数，当前为 %q", "PICGO_UPLOAD_RETRIES must be a non-negative integer, got %q"), retries)
+		}
+		config.PicGo.UploadRetries = n
+	}
+	// 同时进行的上传数上限
+	if concurrency := strings.TrimSpace(os.Getenv("PICGO_CONCURRENCY")); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 0 {
+			return fmt.Errorf(utils.L("PICGO_CONCURRENCY 必须为非负整数，当前为 %q", "PICGO_CONCURRENCY must be a non-negative integer, got %q"), concurrency)
+		}
+		config.PicGo.Concurrency = n
+	}
+	// 上传缓存有效期，如 720h
+	if ttl := strings.TrimSpace(os.Getenv("PICGO_CACHE_TTL")); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d < 0 {
+			return fmt.Errorf(utils.L("PICGO_CACHE_TTL 必须为非负时长（如 720h），当前为 %q", "PICGO_CACHE_TTL must be a non-negative duration (e.g. 720h), got %q"), ttl)
+		}
+		config.PicGo.CacheTTL = d
+	}
+	return nil
 }
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/Perfecto23/feishu2md/utils"
 )
 
 // LoadEnvFile 从指定路径加载环境变量配置文件
@@ -13,13 +15,13 @@ import (
 func LoadEnvFile(filepath string) error {
 	// 检查文件是否存在
 	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		return fmt.Errorf("配置文件不存在: %s", filepath)
+		return fmt.Errorf(utils.L("配置文件不存在: %s", "config file not found: %s"), filepath)
 	}
 
 	// 打开文件
 	file, err := os.Open(filepath)
 	if err != nil {
-		return fmt.Errorf("无法打开配置文件: %w", err)
+		return fmt.Errorf(utils.L("无法打开配置文件: %w", "cannot open config file: %w"), err)
 	}
 	defer file.Close()
 
@@ -51,13 +53,13 @@ func LoadEnvFile(filepath string) error {
 		// 只有当环境变量未设置时才设置（命令行/系统环境变量优先）
 		if os.Getenv(key) == "" {
 			if err := os.Setenv(key, value); err != nil {
-				return fmt.Errorf("设置环境变量失败 %s: %w", key, err)
+				return fmt.Errorf(utils.L("设置环境变量失败 %s: %w", "failed to set environment variable %s: %w"), key, err)
 			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取配置文件失败: %w", err)
+		return fmt.Errorf(utils.L("读取配置文件失败: %w", "failed to read config file: %w"), err)
 	}
 
 	return nil
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// authPath 获取 tenant_access_token 的鉴权接口路径
+const authPath = "/open-apis/auth/v3/tenant_access_token/internal"
+
+// testRateLimit 测试中不需要限流等待
+var testRateLimit = RateLimitConfig{PerSecond: 1000, PerMinute: 100000, Burst: 1000}
+
+// newFakeFeishu 启动模拟的飞书开放平台，测试结束时关闭：handlers 按路径登记处理函数（"/" 匹配其余请求），
+// 未登记鉴权接口时获取 tenant_access_token 固定成功
+func newFakeFeishu(t *testing.T, handlers map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	if _, ok := handlers[authPath]; !ok {
+		mux.HandleFunc(authPath, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"code":0,"msg":"ok","tenant_access_token":"t-app","expire":7200}`)
+		})
+	}
+	for path, h := range handlers {
+		mux.HandleFunc(path, h)
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newFakeClient 返回指向模拟服务、不限流且重试间隔为 1ms 的客户端，opts 可覆盖这些默认值
+func newFakeClient(srv *httptest.Server, appID, appSecret string, opts ...ClientOption) *Client {
+	opts = append([]ClientOption{
+		WithOpenBaseURL(srv.URL),
+		WithRateLimit(testRateLimit),
+		WithRetry(3, time.Millisecond),
+	}, opts...)
+	return NewClient(appID, appSecret, opts...)
+}
+
+// newTestClient 鉴权固定成功、其余请求交给 handler 的客户端
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...ClientOption) *Client {
+	t.Helper()
+	return newFakeClient(newFakeFeishu(t, map[string]http.HandlerFunc{"/": handler}), "cli_test", "secret", opts...)
+}
@@ -0,0 +1,62 @@
+// Package core - 公式图片识别
+// 老文档中的公式常以图片形式插入，可选把这类图片交给 OCR/公式识别服务转为 LaTeX；
+// 识别失败或服务判定不是公式时保留原图片
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FormulaImageMaxHeight 高度不超过该值（px）的图片视为公式图片候选；
+// 行内、行间公式截图通常都很矮，正文插图很少低于此高度
+const FormulaImageMaxHeight = 120
+
+// formulaOCRTimeout 单次公式识别请求的最长耗时
+const formulaOCRTimeout = 30 * time.Second
+
+// formulaOCRResp 识别服务的响应，latex 为空表示图片不是公式
+type formulaOCRResp struct {
+	Latex string `json:"latex"`
+}
+
+// RecognizeFormula 把图片 POST 到识别服务 endpoint，返回识别出的 LaTeX
+// 请求体为图片原始字节，Content-Type 按内容嗅探；响应为 {"latex": "..."}
+// 服务判定不是公式时返回空字符串
+func RecognizeFormula(ctx context.Context, endpoint string, image []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, formulaOCRTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(image))
+	if err != nil {
+		return "", fmt.Errorf("公式识别服务地址无效: %v", err)
+	}
+	req.Header.Set("Content-Type", http.DetectContentType(image))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("公式识别请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("公式识别服务返回 %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取公式识别结果失败: %v", err)
+	}
+	var result formulaOCRResp
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("公式识别结果无法解析: %v", err)
+	}
+	return strings.TrimSpace(result.Latex), nil
+}
+
+// FormulaMarkdown 把 LaTeX 渲染为 Markdown 行间公式
+func FormulaMarkdown(latex string) string {
+	return "$$\n" + latex + "\n$$"
+}
@@ -0,0 +1,284 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// 图片输出格式（ImageOptions.Format）
+const (
+	ImageFormatKeep = "keep" // 保持原格式，仅压缩
+	ImageFormatPNG  = "png"
+	ImageFormatJPEG = "jpeg"
+	ImageFormatWebP = "webp" // 标准库没有 WebP 编码器，效果同 keep：WebP 保持原样，PNG/JPEG 按原格式压缩
+)
+
+// DefaultImageQuality 重新编码 JPEG 时的默认质量
+const DefaultImageQuality = 85
+
+// ImageOptions 控制图片下载后写盘前的处理方式
+type ImageOptions struct {
+	StripExif bool   // 移除 JPEG 中的 EXIF 信息
+	Quality   int    // 重新编码 JPEG 的质量（1-100），0 表示使用 DefaultImageQuality
+	Format    string // 输出格式：keep（默认）、png、jpeg 或 webp
+
+	// OnUnverified 图片格式无法通过魔数识别、按原扩展名保存时调用，可用于提示用户
+	OnUnverified func(srcName string)
+}
+
+// imageExtByMIME 将嗅探到的 MIME 类型映射为文件扩展名
+var imageExtByMIME = map[string]string{
+	"image/png":                ".png",
+	"image/jpeg":               ".jpg",
+	"image/gif":                ".gif",
+	"image/webp":               ".webp",
+	"image/bmp":                ".bmp",
+	"image/x-icon":             ".ico",
+	"image/vnd.microsoft.icon": ".ico",
+}
+
+// normalizeImageExt 统一扩展名写法，便于比较（如 .JPEG -> .jpg）
+func normalizeImageExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext == ".jpeg" {
+		return ".jpg"
+	}
+	return ext
+}
+
+// detectImageExt 根据内容魔数校验图片，返回保存时使用的扩展名
+// 识别为已知图片格式时返回与真实类型一致的扩展名（伪装的扩展名被纠正）；识别为其他类型（如 HTML 错误页）时返回错误；
+// 无法识别的格式（如 HEIC、TIFF）保留原扩展名，verified 为 false
+func detectImageExt(data []byte, ext string) (realExt string, verified bool, err error) {
+	mime := http.DetectContentType(data)
+	if realExt, ok := imageExtByMIME[mime]; ok {
+		if normalizeImageExt(ext) == realExt {
+			return ext, true, nil
+		}
+		return realExt, true, nil
+	}
+	// SVG 为文本格式，无法通过魔数识别，仅检查是否包含 <svg 标签
+	if strings.EqualFold(ext, ".svg") && bytes.Contains(bytes.ToLower(data), []byte("<svg")) {
+		return ext, true, nil
+	}
+	if mime == "application/octet-stream" || strings.HasPrefix(mime, "image/") {
+		return ext, false, nil
+	}
+	return "", false, fmt.Errorf(utils.L("内容为 %s", "content is %s"), mime)
+}
+
+// jpegExifHeader 是 APP1 段中 EXIF 数据的标识
+var jpegExifHeader = []byte("Exif\x00\x00")
+
+// stripJPEGExif 无损移除 JPEG 中携带 EXIF 的 APP1 段，不重新编码图像数据
+// 结构无法识别时原样返回，保证不会破坏图片
+func stripJPEGExif(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF || i+1 >= len(data) {
+			return data
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// 填充字节
+			out = append(out, data[i])
+			i++
+			continue
+		case marker == 0xD9 || marker == 0xDA:
+			// EOI 或 SOS：其后是图像数据，原样保留
+			return append(out, data[i:]...)
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// 无长度字段的独立标记
+			out = append(out, data[i:i+2]...)
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return data
+		}
+		segLen := int(data[i+2])<<8 | int(data[i+3])
+		end := i + 2 + segLen
+		if segLen < 2 || end > len(data) {
+			return data
+		}
+		if marker == 0xE1 && bytes.HasPrefix(data[i+4:end], jpegExifHeader) {
+			i = end
+			continue
+		}
+		out = append(out, data[i:end]...)
+		i = end
+	}
+	return out
+}
+
+// imageLink 返回图片相对文档目录的 Markdown 引用路径，如 ./img/xxx.png
+// imageDir 可以是多级目录，如 assets/img
+func imageLink(imageDir, file string) string {
+	return "./" + utils.ToPosixPath(filepath.Join(imageDir, file))
+}
+
+// saveImage 校验、按类型优化后将图片写入 docDir/imageDir/<name><ext>，返回用于 Markdown 引用的相对路径
+// srcName 仅用于错误提示
+func (c *Client) saveImage(data []byte, name, fileext, srcName, docDir, imageDir string) (string, error) {
+	outDir := filepath.Join(docDir, imageDir)
+	// 确保输出目录存在
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", fmt.Errorf(utils.L("创建目录失败: %v", "failed to create directory: %v"), err)
+	}
+
+	// 写盘前校验内容魔数，防止损坏或伪装的文件；扩展名与内容不符时按真实类型纠正
+	realExt, verified, err := detectImageExt(data, fileext)
+	if err != nil {
+		return "", fmt.Errorf(utils.L("图片内容校验失败: %s 不是有效的图片文件（%v）", "image check failed: %s is not a valid image file (%v)"), srcName, err)
+	}
+	if !verified && c.imageOpts.OnUnverified != nil {
+		c.imageOpts.OnUnverified(srcName)
+	}
+	fileext = realExt
+
+	// 按目标格式压缩或转换，扩展名随格式变化。若解码/编码失败则回退为原始字节写入。
+	data, fileext = c.optimizeImage(data, fileext)
+
+	// 构建完整的文件路径
+	filename := filepath.Join(outDir, fmt.Sprintf("%s%s", name, fileext))
+
+	// 按需移除 JPEG 的 EXIF 隐私信息（无损，不重新编码）
+	if c.imageOpts.StripExif && normalizeImageExt(fileext) == ".jpg" {
+		data = stripJPEGExif(data)
+	}
+
+	// 原子写入，避免中断时留下半截图片（下次运行会被当作已存在而复用）
+	if err := utils.WriteFileAtomic(filename, data, 0o666); err != nil {
+		return "", fmt.Errorf(utils.L("写入文件失败: %v", "failed to write file: %v"), err)
+	}
+
+	return imageLink(imageDir, name+fileext), nil
+}
+
+// optimizeImage 按 ImageOptions 压缩或转换 PNG/JPEG/WebP 图片，返回处理后的字节与扩展名
+// PNG 以 BestCompression 无损重新压缩，JPEG 按配置质量重新编码；格式不变且结果没有变小时保留原图。
+// 通过 image.Decode 解码，WebP 等未注册解码器的格式与其他解码/编码失败一样回退为原始字节
+func (c *Client) optimizeImage(data []byte, fileext string) ([]byte, string) {
+	ext := normalizeImageExt(fileext)
+	if ext != ".png" && ext != ".jpg" && ext != ".webp" {
+		// GIF（可能是动图）、SVG 等其他类型原样写入
+		return data, fileext
+	}
+	target := ext
+	switch c.imageOpts.Format {
+	case ImageFormatPNG:
+		target = ".png"
+	case ImageFormatJPEG:
+		target = ".jpg"
+	}
+	if target == ".webp" {
+		// 没有 WebP 编码器，WebP 图片保持原样
+		return data, fileext
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return data, fileext
+	}
+	var out bytes.Buffer
+	if target == ".png" {
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		err = enc.Encode(&out, img)
+	} else {
+		quality := c.imageOpts.Quality
+		if quality <= 0 {
+			quality = DefaultImageQuality
+		}
+		if ext != ".jpg" {
+			// JPEG 不支持透明度，透明区域铺白底，避免变成黑色
+			img = flattenOnWhite(img)
+		}
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return data, fileext
+	}
+	if target == ext {
+		if out.Len() >= len(data) {
+			return data, fileext
+		}
+		return out.Bytes(), fileext
+	}
+	return out.Bytes(), target
+}
+
+// flattenOnWhite 把图片绘制到白色背景上，去除透明度
+func flattenOnWhite(img image.Image) image.Image {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+	return dst
+}
+
+// externalImageTimeout 下载外链图片的超时时间
+const externalImageTimeout = 60 * time.Second
+
+// ExternalImageName 根据外链 URL 生成稳定的本地文件名（不含扩展名）
+// 同一 URL 始终得到同一文件名，便于复用本地文件与图床缓存
+func ExternalImageName(rawURL string) string {
+	return fmt.Sprintf("ext-%x", sha1.Sum([]byte(rawURL)))[:20]
+}
+
+// DownloadExternalImage 下载文档中引用的外链图片（非飞书 media）到 docDir/imageDir
+// 返回用于 Markdown 引用的相对路径；本地已存在同名文件时直接复用
+func (c *Client) DownloadExternalImage(ctx context.Context, rawURL, docDir, imageDir string) (string, error) {
+	name := ExternalImageName(rawURL)
+	if existingPath, ok := findExistingLocalImage(filepath.Join(docDir, imageDir), name); ok {
+		return imageLink(imageDir, filepath.Base(existingPath)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, externalImageTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf(utils.L("外链图片地址无效: %v", "invalid external image URL: %v"), err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf(utils.L("外链图片下载失败: %v", "failed to download external image: %v"), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(utils.L("外链图片下载失败: %s 返回 %s", "failed to download external image: %s returned %s"), rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf(utils.L("读取外链图片失败: %v", "failed to read external image: %v"), err)
+	}
+
+	// 扩展名优先取 URL 路径，缺失时由内容魔数决定
+	fileext := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		fileext = path.Ext(u.Path)
+	}
+	return c.saveImage(data, name, fileext, rawURL, docDir, imageDir)
+}
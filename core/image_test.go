@@ -0,0 +1,229 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testPNG 返回一张 1x1 的 PNG 图片
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectImageExt(t *testing.T) {
+	pngData := testPNG(t)
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x00\x00\x00\x00")
+	tests := []struct {
+		name     string
+		data     []byte
+		ext      string
+		want     string
+		verified bool
+		wantErr  bool
+	}{
+		{"matching extension", pngData, ".png", ".png", true, false},
+		{"disguised extension", pngData, ".jpg", ".png", true, false},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), ".svg", ".svg", true, false},
+		{"unrecognized format", tiff, ".tiff", ".tiff", false, false},
+		{"html error page", []byte("<!DOCTYPE html><html><body>403</body></html>"), ".png", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, verified, err := detectImageExt(tt.data, tt.ext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectImageExt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || verified != tt.verified {
+				t.Errorf("detectImageExt() = %q, %v, want %q, %v", got, verified, tt.want, tt.verified)
+			}
+		})
+	}
+}
+
+func TestSaveImageCorrectsDisguisedExtension(t *testing.T) {
+	dir := t.TempDir()
+	var unverified []string
+	c := &Client{imageOpts: ImageOptions{OnUnverified: func(name string) { unverified = append(unverified, name) }}}
+
+	link, err := c.saveImage(testPNG(t), "imgA", ".jpg", "photo.jpg", dir, "img")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "./img/imgA.png" {
+		t.Errorf("link = %q, want ./img/imgA.png", link)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "img", "imgA.png")); err != nil {
+		t.Errorf("corrected file not written: %v", err)
+	}
+
+	// 无法识别的格式按原扩展名保存并提示
+	link, err = c.saveImage([]byte("II*\x00\x08\x00\x00\x00\x00\x00\x00\x00"), "imgB", ".tiff", "scan.tiff", dir, "img")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "./img/imgB.tiff" || len(unverified) != 1 || unverified[0] != "scan.tiff" {
+		t.Errorf("link = %q, unverified = %v", link, unverified)
+	}
+}
+
+// testJPEGWithExif 返回在 SOI 之后插入 EXIF 段与 XMP 段的 JPEG 图片
+func testJPEGWithExif(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	segment := func(payload string) []byte {
+		n := len(payload) + 2
+		return append([]byte{0xFF, 0xE1, byte(n >> 8), byte(n)}, payload...)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment("Exif\x00\x00GPS 31.23N 121.47E")...)
+	out = append(out, segment("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")...)
+	return append(out, data[2:]...)
+}
+
+func TestStripJPEGExif(t *testing.T) {
+	data := testJPEGWithExif(t)
+	got := stripJPEGExif(data)
+	if bytes.Contains(got, []byte("Exif\x00\x00")) || bytes.Contains(got, []byte("GPS")) {
+		t.Error("EXIF segment was not removed")
+	}
+	if !bytes.Contains(got, []byte("<x:xmpmeta/>")) {
+		t.Error("non-EXIF APP1 segment was removed")
+	}
+	if len(data)-len(got) != len("Exif\x00\x00GPS 31.23N 121.47E")+4 {
+		t.Errorf("removed %d bytes, want only the EXIF segment", len(data)-len(got))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+		t.Errorf("stripped image does not decode: %v", err)
+	}
+
+	// 无法识别的结构原样返回
+	for _, bad := range [][]byte{testPNG(t), {0xFF, 0xD8, 0x00}} {
+		if got := stripJPEGExif(bad); !bytes.Equal(got, bad) {
+			t.Errorf("stripJPEGExif(%x) modified unrecognized data", bad)
+		}
+	}
+}
+
+func TestSaveImageStripsExif(t *testing.T) {
+	dir := t.TempDir()
+	c := &Client{imageOpts: ImageOptions{StripExif: true}}
+	link, err := c.saveImage(testJPEGWithExif(t), "imgA", ".jpg", "photo.jpg", dir, "img")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(link)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(saved, []byte("Exif\x00\x00")) {
+		t.Error("saved image still has EXIF")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(saved)); err != nil {
+		t.Errorf("saved image does not decode: %v", err)
+	}
+}
+
+// noisyPNG 返回 64x64 带噪点与透明左上角的 PNG，重新编码的大小随 JPEG 质量明显变化
+func noisyPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 37 % 256), G: uint8(y * 91 % 256), B: uint8((x*y + 13) % 256), A: 255})
+		}
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSaveImageFormatAndQuality(t *testing.T) {
+	dir := t.TempDir()
+	pngData := noisyPNG(t)
+	var jpegBuf bytes.Buffer
+	if err := jpeg.Encode(&jpegBuf, image.NewRGBA(image.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatal(err)
+	}
+	webpData := []byte("RIFF\x1a\x00\x00\x00WEBPVP8 \x0e\x00\x00\x00fake-webp-data")
+
+	tests := []struct {
+		name     string
+		opts     ImageOptions
+		data     []byte
+		ext      string
+		wantLink string
+		wantMIME string
+	}{
+		{"png to jpeg", ImageOptions{Format: ImageFormatJPEG}, pngData, ".png", "./img/png-to-jpeg.jpg", "image/jpeg"},
+		{"jpeg to png", ImageOptions{Format: ImageFormatPNG}, jpegBuf.Bytes(), ".jpg", "./img/jpeg-to-png.png", "image/png"},
+		{"webp keeps png", ImageOptions{Format: ImageFormatWebP}, pngData, ".png", "./img/webp-keeps-png.png", "image/png"},
+		{"webp untouched", ImageOptions{Format: ImageFormatJPEG}, webpData, ".webp", "./img/webp-untouched.webp", "image/webp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{imageOpts: tt.opts}
+			name := strings.ReplaceAll(tt.name, " ", "-")
+			link, err := c.saveImage(tt.data, name, tt.ext, name+tt.ext, dir, "img")
+			if err != nil || link != tt.wantLink {
+				t.Fatalf("saveImage() = %q, %v, want %q", link, err, tt.wantLink)
+			}
+			saved, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(link)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mime := http.DetectContentType(saved); mime != tt.wantMIME {
+				t.Errorf("saved %s, want %s", mime, tt.wantMIME)
+			}
+			if tt.wantMIME == "image/webp" && !bytes.Equal(saved, tt.data) {
+				t.Error("WebP image was modified")
+			}
+		})
+	}
+
+	// PNG 转 JPEG 时透明像素铺白底
+	saved, err := os.ReadFile(filepath.Join(dir, "img", "png-to-jpeg.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 < 200 || g>>8 < 200 || b>>8 < 200 {
+		t.Errorf("transparent pixel became (%d,%d,%d), want white", r>>8, g>>8, b>>8)
+	}
+
+	// 质量越低文件越小
+	sizes := make(map[int]int)
+	for _, quality := range []int{20, 95} {
+		c := &Client{imageOpts: ImageOptions{Format: ImageFormatJPEG, Quality: quality}}
+		out, ext := c.optimizeImage(pngData, ".png")
+		if ext != ".jpg" {
+			t.Fatalf("quality %d: ext = %q, want .jpg", quality, ext)
+		}
+		sizes[quality] = len(out)
+	}
+	if sizes[20] >= sizes[95] {
+		t.Errorf("quality 20 size %d >= quality 95 size %d", sizes[20], sizes[95])
+	}
+}
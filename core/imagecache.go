@@ -0,0 +1,111 @@
+// Package core - 图片命中缓存
+// 记录每个图片 token 已下载到的本地文件，同一图片再次出现在其他文档目录、
+// 或下一次运行时直接复制本地文件，省去媒体下载接口调用；缓存文件可跨运行持久化
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// ImageCache 并发安全地维护 图片 token -> 本地文件路径 的映射
+type ImageCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]string
+	loaded  bool
+	dirty   bool
+}
+
+// NewImageCache 创建持久化到 path 的图片命中缓存，首次使用时加载
+func NewImageCache(path string) *ImageCache {
+	return &ImageCache{path: path}
+}
+
+// load 懒加载缓存文件，文件不存在或损坏时视为空缓存，调用方需持有锁
+func (c *ImageCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = make(map[string]string)
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]string)
+	}
+}
+
+// Lookup 返回图片已下载到的本地文件；文件已被删除（如上传图床后清理）时移除记录并返回 false
+func (c *ImageCache) Lookup(imgToken string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	path, ok := c.entries[imgToken]
+	if !ok {
+		return "", false
+	}
+	if _, err := os.Stat(path); err != nil {
+		delete(c.entries, imgToken)
+		c.dirty = true
+		return "", false
+	}
+	return path, true
+}
+
+// Store 记录图片所在的本地文件
+func (c *ImageCache) Store(imgToken, path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	path = filepath.Clean(path)
+	if c.entries[imgToken] == path {
+		return
+	}
+	c.entries[imgToken] = path
+	c.dirty = true
+}
+
+// Flush 将有变更的缓存写回磁盘
+func (c *ImageCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(c.path, data, 0o644); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// copyCachedImage 把缓存命中的本地图片复制到 docDir/imageDir，返回用于 Markdown 引用的相对路径
+func copyCachedImage(src, docDir, imageDir string) (string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	outDir := filepath.Join(docDir, imageDir)
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", err
+	}
+	name := filepath.Base(src)
+	if err := utils.WriteFileAtomic(filepath.Join(outDir, name), data, 0o666); err != nil {
+		return "", err
+	}
+	return imageLink(imageDir, name), nil
+}
@@ -0,0 +1,54 @@
+// Package core - Markdown lint 自动修复
+// 修复 markdownlint 常见规则：标题前后空行（MD022）、无序列表标记统一为 -（MD004）、
+// 文件以单个换行结尾（MD047）；代码块内的内容保持原样
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+// atxHeadingPattern 匹配 ATX 标题行，如 "## 标题"
+var atxHeadingPattern = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+
+// bulletMarkerPattern 匹配以 * 或 + 作为标记的无序列表项，捕获缩进与标记
+var bulletMarkerPattern = regexp.MustCompile(`^(\s*)[*+](\s+)`)
+
+// thematicBreakPattern 匹配分隔线（如 * * * 或 ***），避免误当作列表项
+var thematicBreakPattern = regexp.MustCompile(`^\s*([*_-])(\s*[*_-]){2,}\s*$`)
+
+// LintFixMarkdown 按常见 markdownlint 规则修复 Markdown
+func LintFixMarkdown(markdown string) string {
+	lines := strings.Split(strings.TrimRight(markdown, "\n"), "\n")
+	out := make([]string, 0, len(lines)+8)
+	fence := "" // 当前所在代码块的围栏，为空表示不在代码块内
+	prevHeading := false
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" \t") == "" {
+				fence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+
+		blank := strings.TrimSpace(line) == ""
+		heading := atxHeadingPattern.MatchString(line)
+		// MD022：标题之后、标题之前都需要空行
+		if !blank && (heading || prevHeading) && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+			out = append(out, "")
+		}
+		prevHeading = heading
+
+		if f := codeFence(trimmed); f != "" {
+			fence = f
+		} else if !thematicBreakPattern.MatchString(line) {
+			// MD004：无序列表标记统一为 -
+			line = bulletMarkerPattern.ReplaceAllString(line, "$1-$2")
+		}
+		out = append(out, line)
+	}
+	// MD047：以单个换行结尾
+	return strings.Join(out, "\n") + "\n"
+}
@@ -3,7 +3,11 @@ package core
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Perfecto23/feishu2md/utils"
 	"github.com/chyroc/lark"
@@ -11,18 +15,45 @@ import (
 )
 
 type Parser struct {
-	useHTMLTags bool
-	noBodyTitle bool
-	ImgTokens   []string
-	blockMap    map[string]*lark.DocxBlock
+	useHTMLTags          bool
+	noBodyTitle          bool
+	stripCodeLineNumbers bool
+	gallery              bool           // HTML 模式下把连续图片包成画廊
+	fixHeadingLevels     bool           // 修复跳级的标题层级
+	cellMaxWidth         int            // 表格单元格可见字符上限，超出截断并加省略号，0 表示不限制
+	calloutStyle         string         // 高亮块渲染方式：alert（GFM alert）或 quote（带图标的引用块）
+	splitOnDivider       bool           // 顶层分割线输出为分章标记
+	headingLevels        map[string]int // 修复后的标题层级，键为 BlockID
+	tableDepth           int            // 正在解析的表格嵌套层数，大于 0 表示位于表格单元格内
+	watermarkPattern     *regexp.Regexp // 非 nil 时移除匹配的水印文本块
+	ImgTokens            []string
+	FormulaImgTokens     map[string]bool // 可能是公式截图的图片（高度较小），启用公式识别时尝试转为 LaTeX
+	blockMap             map[string]*lark.DocxBlock
 }
 
 func NewParser(config OutputConfig) *Parser {
+	var watermarkPattern *regexp.Regexp
+	if config.StripWatermark {
+		pattern := config.WatermarkPattern
+		if pattern == "" {
+			pattern = DefaultWatermarkPattern
+		}
+		// 规则已在加载配置时校验，这里编译失败则视为不启用
+		watermarkPattern, _ = regexp.Compile(pattern)
+	}
 	return &Parser{
-		useHTMLTags: config.UseHTMLTags,
-		noBodyTitle: config.NoBodyTitle,
-		ImgTokens:   make([]string, 0),
-		blockMap:    make(map[string]*lark.DocxBlock),
+		useHTMLTags:          config.UseHTMLTags,
+		noBodyTitle:          config.NoBodyTitle,
+		stripCodeLineNumbers: config.StripCodeLineNumbers,
+		gallery:              config.Gallery && config.UseHTMLTags,
+		fixHeadingLevels:     config.FixHeadingLevels,
+		cellMaxWidth:         config.CellMaxWidth,
+		calloutStyle:         config.CalloutStyle,
+		splitOnDivider:       config.SplitOnDivider,
+		watermarkPattern:     watermarkPattern,
+		ImgTokens:            make([]string, 0),
+		FormulaImgTokens:     make(map[string]bool),
+		blockMap:             make(map[string]*lark.DocxBlock),
 	}
 }
 
@@ -100,6 +131,113 @@ var DocxCodeLang2MdStr = map[lark.DocxCodeLanguage]string{
 	lark.DocxCodeLanguageYAML:         "yaml",
 }
 
+// codeLineNumberPattern 匹配代码行开头的行号前缀，如 "12 " 或 "  3\t"
+var codeLineNumberPattern = regexp.MustCompile(`^\s*(\d+)\s`)
+
+// StripCodeLineNumbers 去除从飞书复制代码时混入的行号前缀
+// 仅当所有非空行都带行号且行号连续递增时才去除，避免误删以数字开头的正常代码
+func StripCodeLineNumbers(code string) string {
+	lines := strings.Split(code, "\n")
+	stripped := make([]string, len(lines))
+	expected := -1
+	matched := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			stripped[i] = line
+			continue
+		}
+		m := codeLineNumberPattern.FindStringSubmatchIndex(line)
+		if m == nil {
+			return code
+		}
+		n, err := strconv.Atoi(line[m[2]:m[3]])
+		if err != nil || (expected >= 0 && n != expected) {
+			return code
+		}
+		expected = n + 1
+		matched++
+		stripped[i] = line[m[1]:]
+	}
+	// 单行代码无法判断是否为行号，保持原样
+	if matched < 2 {
+		return code
+	}
+	return strings.Join(stripped, "\n")
+}
+
+// CollapseBlankLines 把 3 个及以上的连续空行折叠为 1 个，代码块内的空行保持原样
+func CollapseBlankLines(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	out := make([]string, 0, len(lines))
+	fence := "" // 当前所在代码块的围栏，如 ``` 或 ~~~~，为空表示不在代码块内
+	blanks := 0 // 代码块外连续空行数
+	flush := func() {
+		if blanks >= 3 {
+			blanks = 1
+		}
+		for ; blanks > 0; blanks-- {
+			out = append(out, "")
+		}
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" \t") == "" {
+				fence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			blanks++
+			continue
+		}
+		flush()
+		if f := codeFence(trimmed); f != "" {
+			fence = f
+		}
+		out = append(out, line)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// PageBreakMarker 按分割线拆分章节时，正文顶层分割线在解析结果中的标记
+// 飞书文档没有分页符块，顶层分割线是作者划分章节最常用的方式
+const PageBreakMarker = "<!-- feishu2md:page-break -->"
+
+// SplitPages 按分章标记把 Markdown 拆分为多个章节，空章节丢弃；没有标记时返回原文
+func SplitPages(markdown string) []string {
+	var chapters []string
+	for _, part := range strings.Split(markdown, PageBreakMarker) {
+		if part = strings.TrimSpace(part); part != "" {
+			chapters = append(chapters, part+"\n")
+		}
+	}
+	if len(chapters) == 0 {
+		return []string{markdown}
+	}
+	return chapters
+}
+
+// isTopLevel 判断块是否直接位于文档根节点下（不在列表、表格、引用等容器内）
+func (p *Parser) isTopLevel(b *lark.DocxBlock) bool {
+	parent := p.blockMap[b.ParentID]
+	return parent != nil && parent.BlockType == lark.DocxBlockTypePage
+}
+
+// codeFence 返回代码块起始行的围栏（连续 3 个及以上的 ` 或 ~），不是起始行时返回空
+func codeFence(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// renderMarkdownTable 把二维单元格渲染为 Markdown 表格，第一行作为表头
 func renderMarkdownTable(data [][]string) string {
 	builder := &strings.Builder{}
 	table := tablewriter.NewWriter(builder)
@@ -124,10 +262,76 @@ func (p *Parser) ParseDocxContent(doc *lark.DocxDocument, blocks []*lark.DocxBlo
 	}
 
 	entryBlock := p.blockMap[doc.DocumentID]
+	if p.fixHeadingLevels {
+		p.headingLevels = normalizeHeadingLevels(p.headingSequence(entryBlock, nil))
+	}
 	return p.ParseDocxBlock(entryBlock, 0)
 }
 
+// headingRef 文档中一个标题块及其原始层级
+type headingRef struct {
+	BlockID string
+	Level   int
+}
+
+// headingSequence 按文档顺序收集所有标题块
+func (p *Parser) headingSequence(b *lark.DocxBlock, seq []headingRef) []headingRef {
+	if b == nil {
+		return seq
+	}
+	if b.BlockType >= lark.DocxBlockTypeHeading1 && b.BlockType <= lark.DocxBlockTypeHeading9 {
+		seq = append(seq, headingRef{BlockID: b.BlockID, Level: int(b.BlockType-lark.DocxBlockTypeHeading1) + 1})
+	}
+	for _, childID := range b.Children {
+		seq = p.headingSequence(p.blockMap[childID], seq)
+	}
+	return seq
+}
+
+// normalizeHeadingLevels 规范化跳级的标题层级，如 H1 下直接出现的 H3 提升为 H2
+// 每个标题的新层级为其最近的上级标题（原始层级更小者）的新层级加一，
+// 同级标题保持同级，文档中第一个层级的标题归为 1 级；返回 BlockID -> 新层级
+func normalizeHeadingLevels(seq []headingRef) map[string]int {
+	levels := make(map[string]int, len(seq))
+	type frame struct{ orig, fixed int }
+	var stack []frame
+	for _, h := range seq {
+		for len(stack) > 0 && stack[len(stack)-1].orig >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		fixed := 1
+		if len(stack) > 0 {
+			fixed = stack[len(stack)-1].fixed + 1
+		}
+		stack = append(stack, frame{orig: h.Level, fixed: fixed})
+		levels[h.BlockID] = fixed
+	}
+	return levels
+}
+
+// IsEmptyDocx 判断文档是否没有实质内容：除页面块外只有空白文本块
+// 常见于知识库中仅用作目录分组的空壳文档
+func IsEmptyDocx(doc *lark.DocxDocument, blocks []*lark.DocxBlock) bool {
+	for _, b := range blocks {
+		if b.BlockID == doc.DocumentID || b.BlockType == lark.DocxBlockTypePage {
+			continue
+		}
+		if b.BlockType != lark.DocxBlockTypeText || b.Text == nil {
+			return false
+		}
+		for _, e := range b.Text.Elements {
+			if e.TextRun == nil || strings.TrimSpace(e.TextRun.Content) != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (p *Parser) ParseDocxBlock(b *lark.DocxBlock, indentLevel int) string {
+	if p.isWatermarkBlock(b) {
+		return ""
+	}
 	buf := new(strings.Builder)
 	buf.WriteString(strings.Repeat("\t", indentLevel))
 	switch b.BlockType {
@@ -161,7 +365,11 @@ func (p *Parser) ParseDocxBlock(b *lark.DocxBlock, indentLevel int) string {
 		buf.WriteString(p.ParseDocxBlockOrdered(b, indentLevel))
 	case lark.DocxBlockTypeCode:
 		buf.WriteString("```" + DocxCodeLang2MdStr[b.Code.Style.Language] + "\n")
-		buf.WriteString(strings.TrimSpace(p.ParseDocxBlockText(b.Code)))
+		code := strings.TrimSpace(ParseDocxCodeText(b.Code))
+		if p.stripCodeLineNumbers {
+			code = StripCodeLineNumbers(code)
+		}
+		buf.WriteString(code)
 		buf.WriteString("\n```\n")
 	case lark.DocxBlockTypeQuote:
 		buf.WriteString("> ")
@@ -178,7 +386,11 @@ func (p *Parser) ParseDocxBlock(b *lark.DocxBlock, indentLevel int) string {
 		}
 		buf.WriteString(p.ParseDocxBlockText(b.Todo))
 	case lark.DocxBlockTypeDivider:
-		buf.WriteString("---\n")
+		if p.splitOnDivider && p.isTopLevel(b) {
+			buf.WriteString(PageBreakMarker + "\n")
+		} else {
+			buf.WriteString("---\n")
+		}
 	case lark.DocxBlockTypeImage:
 		buf.WriteString(p.ParseDocxBlockImage(b.Image))
 	case lark.DocxBlockTypeTableCell:
@@ -194,6 +406,21 @@ func (p *Parser) ParseDocxBlock(b *lark.DocxBlock, indentLevel int) string {
 	return buf.String()
 }
 
+// isWatermarkBlock 判断文本块是否为混入正文的水印
+func (p *Parser) isWatermarkBlock(b *lark.DocxBlock) bool {
+	if p.watermarkPattern == nil || b.BlockType != lark.DocxBlockTypeText || b.Text == nil {
+		return false
+	}
+	text := new(strings.Builder)
+	for _, e := range b.Text.Elements {
+		if e.TextRun != nil {
+			text.WriteString(e.TextRun.Content)
+		}
+	}
+	content := strings.TrimSpace(text.String())
+	return content != "" && p.watermarkPattern.MatchString(content)
+}
+
 func (p *Parser) ParseDocxBlockPage(b *lark.DocxBlock) string {
 	buf := new(strings.Builder)
 
@@ -204,8 +431,16 @@ func (p *Parser) ParseDocxBlockPage(b *lark.DocxBlock) string {
 		buf.WriteString("\n")
 	}
 
-	for _, childId := range b.Children {
-		childBlock := p.blockMap[childId]
+	for i := 0; i < len(b.Children); i++ {
+		if p.gallery {
+			if images := p.consecutiveImages(b.Children[i:]); len(images) > 1 {
+				buf.WriteString(p.ParseDocxGallery(images))
+				buf.WriteString("\n")
+				i += len(images) - 1
+				continue
+			}
+		}
+		childBlock := p.blockMap[b.Children[i]]
 		buf.WriteString(p.ParseDocxBlock(childBlock, 0))
 		buf.WriteString("\n")
 	}
@@ -213,6 +448,30 @@ func (p *Parser) ParseDocxBlockPage(b *lark.DocxBlock) string {
 	return buf.String()
 }
 
+// consecutiveImages 返回从 childIds 开头起连续的图片块
+func (p *Parser) consecutiveImages(childIds []string) []*lark.DocxBlockImage {
+	var images []*lark.DocxBlockImage
+	for _, id := range childIds {
+		b := p.blockMap[id]
+		if b == nil || b.BlockType != lark.DocxBlockTypeImage || b.Image == nil {
+			break
+		}
+		images = append(images, b.Image)
+	}
+	return images
+}
+
+// ParseDocxGallery 将连续的图片渲染为画廊 div
+func (p *Parser) ParseDocxGallery(images []*lark.DocxBlockImage) string {
+	buf := new(strings.Builder)
+	buf.WriteString("<div class=\"gallery\">\n")
+	for _, img := range images {
+		buf.WriteString(p.ParseDocxBlockImageHTML(img) + "\n")
+	}
+	buf.WriteString("</div>\n")
+	return buf.String()
+}
+
 func (p *Parser) ParseDocxBlockText(b *lark.DocxBlockText) string {
 	buf := new(strings.Builder)
 	numElem := len(b.Elements)
@@ -224,14 +483,94 @@ func (p *Parser) ParseDocxBlockText(b *lark.DocxBlockText) string {
 	return buf.String()
 }
 
-func (p *Parser) ParseDocxBlockCallout(b *lark.DocxBlock) string {
+// ParseDocxCodeText 提取代码块的纯代码文本：只拼接各文本片段的原始内容，
+// 忽略加粗、链接等样式以及评论、@提及、日期提醒等飞书附加元素，避免混入代码
+func ParseDocxCodeText(b *lark.DocxBlockText) string {
 	buf := new(strings.Builder)
+	for _, e := range b.Elements {
+		if e.TextRun != nil {
+			buf.WriteString(e.TextRun.Content)
+		}
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// 高亮块渲染方式
+const (
+	CalloutStyleAlert = "alert" // GFM alert：> [!WARNING]
+	CalloutStyleQuote = "quote" // 带图标的普通引用块：> ⚠️ ...
+)
+
+// calloutEmojiAlerts 按高亮块图标判断提示类型，优先于颜色
+var calloutEmojiAlerts = map[string]string{
+	"information_source": "NOTE",
+	"memo":               "NOTE",
+	"bulb":               "TIP",
+	"white_check_mark":   "TIP",
+	"heavy_check_mark":   "TIP",
+	"pushpin":            "IMPORTANT",
+	"exclamation":        "IMPORTANT",
+	"star":               "IMPORTANT",
+	"warning":            "WARNING",
+	"x":                  "CAUTION",
+	"no_entry":           "CAUTION",
+	"no_entry_sign":      "CAUTION",
+	"rotating_light":     "CAUTION",
+}
+
+// calloutColorAlerts 按色系判断提示类型，下标为飞书色值减一对 7 取余：红、橙、黄、绿、蓝、紫、灰
+var calloutColorAlerts = [7]string{"CAUTION", "WARNING", "WARNING", "TIP", "NOTE", "IMPORTANT", "NOTE"}
+
+// calloutAlertIcons quote 方式下各提示类型使用的图标
+var calloutAlertIcons = map[string]string{
+	"NOTE":      "ℹ️",
+	"TIP":       "💡",
+	"IMPORTANT": "❗",
+	"WARNING":   "⚠️",
+	"CAUTION":   "🚫",
+}
 
-	buf.WriteString(">[!TIP] \n")
+// CalloutAlertType 判断高亮块对应的 GFM alert 类型：先看图标，再看背景色、边框色，都无法判断时为 TIP
+func CalloutAlertType(c *lark.DocxBlockCallout) string {
+	if c == nil {
+		return "TIP"
+	}
+	if t, ok := calloutEmojiAlerts[c.EmojiID]; ok {
+		return t
+	}
+	if c.BackgroundColor > 0 {
+		return calloutColorAlerts[(c.BackgroundColor-1)%7]
+	}
+	if c.BorderColor > 0 {
+		return calloutColorAlerts[(c.BorderColor-1)%7]
+	}
+	return "TIP"
+}
 
+func (p *Parser) ParseDocxBlockCallout(b *lark.DocxBlock) string {
+	content := new(strings.Builder)
 	for _, childId := range b.Children {
 		childBlock := p.blockMap[childId]
-		buf.WriteString(p.ParseDocxBlock(childBlock, 0))
+		content.WriteString(p.ParseDocxBlock(childBlock, 0))
+		content.WriteString("\n")
+	}
+
+	alertType := CalloutAlertType(b.Callout)
+	lines := strings.Split(strings.TrimRight(content.String(), "\n"), "\n")
+
+	buf := new(strings.Builder)
+	if p.calloutStyle == CalloutStyleQuote {
+		lines[0] = calloutAlertIcons[alertType] + " " + lines[0]
+	} else {
+		buf.WriteString("> [!" + alertType + "]\n")
+	}
+	for _, line := range lines {
+		if line == "" {
+			buf.WriteString(">\n")
+			continue
+		}
+		buf.WriteString("> " + line + "\n")
 	}
 
 	return buf.String()
@@ -248,6 +587,9 @@ func (p *Parser) ParseDocxTextElement(e *lark.DocxTextElement, inline bool) stri
 		buf.WriteString(
 			fmt.Sprintf("[%s](%s)", e.MentionDoc.Title, utils.UnescapeURL(e.MentionDoc.URL)))
 	}
+	if e.Reminder != nil {
+		buf.WriteString(FormatReminder(e.Reminder))
+	}
 	if e.Equation != nil {
 		symbol := "$$"
 		if inline {
@@ -258,6 +600,28 @@ func (p *Parser) ParseDocxTextElement(e *lark.DocxTextElement, inline bool) stri
 	return buf.String()
 }
 
+// reminderLocation 日期提醒与评论时间的显示时区，默认东八区，与 frontmatter 时间保持一致
+var reminderLocation = time.FixedZone("CST", 8*3600)
+
+// SetDisplayLocation 设置日期提醒与评论时间的显示时区，需在开始解析前调用
+func SetDisplayLocation(loc *time.Location) {
+	reminderLocation = loc
+}
+
+// FormatReminder 将 @日期 提醒渲染为可读日期：全天提醒为 2006-01-02，整点提醒附带时间
+// 时间戳无法解析时返回空字符串
+func FormatReminder(r *lark.DocxTextElementReminder) string {
+	ms, err := strconv.ParseInt(r.ExpireTime, 10, 64)
+	if err != nil || ms <= 0 {
+		return ""
+	}
+	t := time.UnixMilli(ms).In(reminderLocation)
+	if r.IsWholeDay {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
 func (p *Parser) ParseDocxTextElementTextRun(tr *lark.DocxTextElementTextRun) string {
 	buf := new(strings.Builder)
 	postWrite := ""
@@ -305,7 +669,11 @@ func (p *Parser) ParseDocxTextElementTextRun(tr *lark.DocxTextElementTextRun) st
 func (p *Parser) ParseDocxBlockHeading(b *lark.DocxBlock, headingLevel int) string {
 	buf := new(strings.Builder)
 
-	buf.WriteString(strings.Repeat("#", headingLevel))
+	outputLevel := headingLevel
+	if fixed, ok := p.headingLevels[b.BlockID]; ok {
+		outputLevel = fixed
+	}
+	buf.WriteString(strings.Repeat("#", outputLevel))
 	buf.WriteString(" ")
 
 	headingText := reflect.ValueOf(b).Elem().FieldByName(fmt.Sprintf("Heading%d", headingLevel))
@@ -321,12 +689,43 @@ func (p *Parser) ParseDocxBlockHeading(b *lark.DocxBlock, headingLevel int) stri
 
 func (p *Parser) ParseDocxBlockImage(img *lark.DocxBlockImage) string {
 	buf := new(strings.Builder)
-	buf.WriteString(fmt.Sprintf("![](%s)", img.Token))
+	buf.WriteString(fmt.Sprintf("![](%s)", ImagePlaceholder(img.Token)))
 	buf.WriteString("\n")
 	p.ImgTokens = append(p.ImgTokens, img.Token)
+	if img.Height > 0 && img.Height <= FormulaImageMaxHeight {
+		p.FormulaImgTokens[img.Token] = true
+	}
 	return buf.String()
 }
 
+// ParseDocxBlockImageHTML 将图片渲染为 <img> 标签，用于 HTML 上下文（表格、画廊）
+// 同样收集图片 token，以便后续统一下载并替换为最终链接
+func (p *Parser) ParseDocxBlockImageHTML(img *lark.DocxBlockImage) string {
+	p.ImgTokens = append(p.ImgTokens, img.Token)
+	return fmt.Sprintf("<img src=\"%s\" />", ImagePlaceholder(img.Token))
+}
+
+// imagePlaceholderPattern 匹配解析结果中的图片占位标记，捕获图片 token
+var imagePlaceholderPattern = regexp.MustCompile(`\{\{feishu2md-img:([^{}\s]+)\}\}`)
+
+// ImagePlaceholder 返回图片 token 在解析结果中的占位标记
+// 替换链接时只匹配完整的占位标记，避免 token 恰好是正文或其他 token 的子串时被误替换
+func ImagePlaceholder(token string) string {
+	return "{{feishu2md-img:" + token + "}}"
+}
+
+// ReplaceImagePlaceholders 把图片占位标记替换为 links 中的链接；
+// 没有对应链接的图片（下载失败或跳过下载）还原为原始 token，替换后不再残留占位标记
+func ReplaceImagePlaceholders(markdown string, links map[string]string) string {
+	return imagePlaceholderPattern.ReplaceAllStringFunc(markdown, func(m string) string {
+		token := imagePlaceholderPattern.FindStringSubmatch(m)[1]
+		if link, ok := links[token]; ok {
+			return link
+		}
+		return token
+	})
+}
+
 func (p *Parser) ParseDocxWhatever(body *lark.DocBody) string {
 	buf := new(strings.Builder)
 
@@ -382,6 +781,14 @@ func (p *Parser) ParseDocxBlockTableCell(b *lark.DocxBlock) string {
 
 	for _, child := range b.Children {
 		block := p.blockMap[child]
+		if block == nil {
+			continue
+		}
+		// 表格可能渲染为 HTML，单元格内的 Markdown 图片语法不会被解析，统一改用 <img> 标签
+		if block.BlockType == lark.DocxBlockTypeImage && block.Image != nil {
+			buf.WriteString(p.ParseDocxBlockImageHTML(block.Image) + "<br/>")
+			continue
+		}
 		content := p.ParseDocxBlock(block, 0)
 		buf.WriteString(content + "<br/>")
 	}
@@ -389,6 +796,40 @@ func (p *Parser) ParseDocxBlockTableCell(b *lark.DocxBlock) string {
 	return buf.String()
 }
 
+// TruncateCell 将单元格内容截断到 maxWidth 个可见字符并追加省略号
+// 只统计 HTML 标签之外的字符；截断点之后的文本与图片被丢弃，其余标签保留，保证标签仍然成对闭合
+func TruncateCell(content string, maxWidth int) string {
+	buf := new(strings.Builder)
+	visible := 0
+	truncated := false
+	for i := 0; i < len(content); {
+		if content[i] == '<' {
+			end := strings.IndexByte(content[i:], '>')
+			if end >= 0 {
+				tag := content[i : i+end+1]
+				if !truncated || !strings.HasPrefix(strings.ToLower(tag), "<img") {
+					buf.WriteString(tag)
+				}
+				i += end + 1
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(content[i:])
+		i += size
+		if truncated {
+			continue
+		}
+		if visible == maxWidth {
+			buf.WriteString("…")
+			truncated = true
+			continue
+		}
+		buf.WriteRune(r)
+		visible++
+	}
+	return buf.String()
+}
+
 func (p *Parser) ParseDocxBlockTable(t *lark.DocxBlockTable) string {
 	var rows [][]string
 	mergeInfoMap := map[int64]map[int64]*lark.DocxBlockTablePropertyMergeInfo{}
@@ -405,12 +846,22 @@ func (p *Parser) ParseDocxBlockTable(t *lark.DocxBlockTable) string {
 		}
 	}
 
-	// 构建表格内容
-
+	// 构建表格内容；单元格内的表格无法用 Markdown 表示，解析时标记嵌套层数
+	nested := false
+	p.tableDepth++
 	for i, blockId := range t.Cells {
 		block := p.blockMap[blockId]
+		if block == nil {
+			continue
+		}
 		cellContent := p.ParseDocxBlock(block, 0)
 		cellContent = strings.ReplaceAll(cellContent, "\n", "")
+		if strings.Contains(cellContent, "<table>") {
+			nested = true
+		}
+		if p.cellMaxWidth > 0 {
+			cellContent = TruncateCell(cellContent, p.cellMaxWidth)
+		}
 		rowIndex := int64(i) / t.Property.ColumnSize
 		colIndex := int64(i) % t.Property.ColumnSize
 
@@ -424,6 +875,13 @@ func (p *Parser) ParseDocxBlockTable(t *lark.DocxBlockTable) string {
 		// 设置单元格内容
 		rows[rowIndex][colIndex] = cellContent
 	}
+	p.tableDepth--
+
+	// 嵌套在单元格内的表格只能用 HTML；含合并单元格或嵌套表格的复杂表格在 HTML 模式下用 <table> 保留结构，
+	// 其余情况输出 Markdown 表格
+	if p.tableDepth == 0 && !(p.useHTMLTags && (nested || hasMergedCells(mergeInfoMap))) {
+		return markdownTableFromDocx(rows, mergeInfoMap)
+	}
 
 	// 渲染为 HTML 表格
 	buf := new(strings.Builder)
@@ -476,6 +934,49 @@ func (p *Parser) ParseDocxBlockTable(t *lark.DocxBlockTable) string {
 	return buf.String()
 }
 
+// hasMergedCells 判断表格是否含有跨行或跨列的合并单元格
+func hasMergedCells(mergeInfoMap map[int64]map[int64]*lark.DocxBlockTablePropertyMergeInfo) bool {
+	for _, cols := range mergeInfoMap {
+		for _, merge := range cols {
+			if merge != nil && (merge.RowSpan > 1 || merge.ColSpan > 1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markdownTableFromDocx 把文档表格渲染为 Markdown 表格：第一行作为表头，
+// 合并范围内被覆盖的单元格重复左上角的内容，单元格内的分段以 <br/> 分隔
+func markdownTableFromDocx(rows [][]string, mergeInfoMap map[int64]map[int64]*lark.DocxBlockTablePropertyMergeInfo) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	for rowIndex, cols := range mergeInfoMap {
+		for colIndex, merge := range cols {
+			if merge == nil || (merge.RowSpan <= 1 && merge.ColSpan <= 1) {
+				continue
+			}
+			if int(rowIndex) >= len(rows) || int(colIndex) >= len(rows[rowIndex]) {
+				continue
+			}
+			content := rows[rowIndex][colIndex]
+			for r := rowIndex; r < rowIndex+merge.RowSpan && int(r) < len(rows); r++ {
+				for c := colIndex; c < colIndex+merge.ColSpan && int(c) < len(rows[r]); c++ {
+					rows[r][c] = content
+				}
+			}
+		}
+	}
+	for _, row := range rows {
+		for j, cell := range row {
+			cell = strings.TrimSuffix(cell, "<br/>")
+			row[j] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+	}
+	return renderMarkdownTable(rows)
+}
+
 func (p *Parser) ParseDocxBlockQuoteContainer(b *lark.DocxBlock) string {
 	buf := new(strings.Builder)
 
@@ -0,0 +1,412 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chyroc/lark"
+)
+
+// parseBlocks 解析 block JSON 样例，入口为 block_id 为 "doc" 的页面块
+func parseBlocks(t *testing.T, config OutputConfig, blocksJSON string) string {
+	t.Helper()
+	var blocks []*lark.DocxBlock
+	if err := json.Unmarshal([]byte(blocksJSON), &blocks); err != nil {
+		t.Fatal(err)
+	}
+	return NewParser(config).ParseDocxContent(&lark.DocxDocument{DocumentID: "doc"}, blocks)
+}
+
+// mergedTableBlocks 2x2 表格：首行两个单元格合并为表头，表头单元格含两个段落，第二行单元格含竖线
+const mergedTableBlocks = `[
+	{"block_id":"doc","block_type":1,"page":{"elements":[{"text_run":{"content":"表格"}}]},"children":["tbl"]},
+	{"block_id":"tbl","parent_id":"doc","block_type":31,"children":["c1","c2","c3","c4"],"table":{
+		"cells":["c1","c2","c3","c4"],
+		"property":{"row_size":2,"column_size":2,"merge_info":[
+			{"row_span":1,"col_span":2},{"row_span":1,"col_span":1},
+			{"row_span":1,"col_span":1},{"row_span":1,"col_span":1}]}}},
+	{"block_id":"c1","parent_id":"tbl","block_type":32,"children":["p1","p2"],"table_cell":{}},
+	{"block_id":"c2","parent_id":"tbl","block_type":32,"children":[],"table_cell":{}},
+	{"block_id":"c3","parent_id":"tbl","block_type":32,"children":["p3"],"table_cell":{}},
+	{"block_id":"c4","parent_id":"tbl","block_type":32,"children":["p4"],"table_cell":{}},
+	{"block_id":"p1","parent_id":"c1","block_type":2,"text":{"elements":[{"text_run":{"content":"合并表头"}}]}},
+	{"block_id":"p2","parent_id":"c1","block_type":2,"text":{"elements":[{"text_run":{"content":"第二段"}}]}},
+	{"block_id":"p3","parent_id":"c3","block_type":2,"text":{"elements":[{"text_run":{"content":"甲"}}]}},
+	{"block_id":"p4","parent_id":"c4","block_type":2,"text":{"elements":[{"text_run":{"content":"a|b"}}]}}
+]`
+
+func TestParseMergedTableMarkdown(t *testing.T) {
+	got := parseBlocks(t, OutputConfig{}, mergedTableBlocks)
+	// 合并的表头单元格在被覆盖的位置重复内容，段落之间以 <br/> 分隔，竖线被转义
+	for _, want := range []string{
+		"| 合并表头<br/>第二段 | 合并表头<br/>第二段 |",
+		"|---------------------|---------------------|",
+		"| 甲                  | a\\|b                |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown table missing %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<table>") {
+		t.Errorf("markdown mode rendered an HTML table:\n%s", got)
+	}
+}
+
+func TestParseMergedTableHTML(t *testing.T) {
+	// HTML 模式下含合并单元格的表格改用 <table> 保留结构
+	got := parseBlocks(t, OutputConfig{UseHTMLTags: true}, mergedTableBlocks)
+	for _, want := range []string{
+		"<table>",
+		`<td colspan="2">合并表头<br/>第二段<br/></td></tr>`,
+		"<td>甲<br/></td><td>a|b<br/></td></tr>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML table missing %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestStripCodeLineNumbers(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"consecutive numbers", "1 package main\n2 \n3 func main() {}", "package main\n\nfunc main() {}"},
+		{"tab separated", "  9\tfoo()\n 10\tbar()", "foo()\nbar()"},
+		{"blank lines kept", "1 a\n\n2 b", "a\n\nb"},
+		{"non-consecutive numbers", "1 a\n3 b", "1 a\n3 b"},
+		{"line without number", "1 a\nb", "1 a\nb"},
+		{"single line", "200 OK", "200 OK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripCodeLineNumbers(tt.code); got != tt.want {
+				t.Errorf("StripCodeLineNumbers(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+// galleryBlocks 两张连续图片、一段文字、一张单独的图片
+const galleryBlocks = `[
+	{"block_id":"doc","block_type":1,"page":{"elements":[{"text_run":{"content":"相册"}}]},"children":["i1","i2","t1","i3"]},
+	{"block_id":"i1","parent_id":"doc","block_type":27,"image":{"token":"imgA","width":800,"height":600}},
+	{"block_id":"i2","parent_id":"doc","block_type":27,"image":{"token":"imgB","width":800,"height":600}},
+	{"block_id":"t1","parent_id":"doc","block_type":2,"text":{"elements":[{"text_run":{"content":"说明"}}]}},
+	{"block_id":"i3","parent_id":"doc","block_type":27,"image":{"token":"imgC","width":800,"height":600}}
+]`
+
+func TestParseGallery(t *testing.T) {
+	got := parseBlocks(t, OutputConfig{UseHTMLTags: true, Gallery: true}, galleryBlocks)
+	want := "<div class=\"gallery\">\n" +
+		"<img src=\"" + ImagePlaceholder("imgA") + "\" />\n" +
+		"<img src=\"" + ImagePlaceholder("imgB") + "\" />\n" +
+		"</div>\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("gallery missing, got:\n%s", got)
+	}
+	// 单独的图片不包成画廊
+	if strings.Count(got, "gallery") != 1 || !strings.Contains(got, "![]("+ImagePlaceholder("imgC")+")") {
+		t.Errorf("single image should stay a Markdown image, got:\n%s", got)
+	}
+
+	// 画廊只在 HTML 模式下生效
+	if got := parseBlocks(t, OutputConfig{Gallery: true}, galleryBlocks); strings.Contains(got, "gallery") {
+		t.Errorf("gallery rendered without --html:\n%s", got)
+	}
+}
+
+func TestFormatReminder(t *testing.T) {
+	defer SetDisplayLocation(reminderLocation)
+	SetDisplayLocation(time.FixedZone("CST", 8*3600))
+
+	// 1704067200000 毫秒为 2024-01-01T00:00:00Z，即东八区 08:00
+	tests := []struct {
+		name     string
+		reminder lark.DocxTextElementReminder
+		want     string
+	}{
+		{"whole day", lark.DocxTextElementReminder{ExpireTime: "1704067200000", IsWholeDay: true}, "2024-01-01"},
+		{"with time", lark.DocxTextElementReminder{ExpireTime: "1704067200000"}, "2024-01-01 08:00"},
+		{"invalid timestamp", lark.DocxTextElementReminder{ExpireTime: "abc"}, ""},
+		{"zero timestamp", lark.DocxTextElementReminder{ExpireTime: "0"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatReminder(&tt.reminder); got != tt.want {
+				t.Errorf("FormatReminder() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// 显示时区可配置
+	SetDisplayLocation(time.UTC)
+	if got := FormatReminder(&lark.DocxTextElementReminder{ExpireTime: "1704067200000"}); got != "2024-01-01 00:00" {
+		t.Errorf("FormatReminder() in UTC = %q, want 2024-01-01 00:00", got)
+	}
+}
+
+func TestParseTableCellImage(t *testing.T) {
+	blocks := `[
+		{"block_id":"doc","block_type":1,"page":{"elements":[{"text_run":{"content":"图表"}}]},"children":["tbl"]},
+		{"block_id":"tbl","parent_id":"doc","block_type":31,"children":["c1","c2"],"table":{
+			"cells":["c1","c2"],"property":{"row_size":1,"column_size":2}}},
+		{"block_id":"c1","parent_id":"tbl","block_type":32,"children":["p1"],"table_cell":{}},
+		{"block_id":"c2","parent_id":"tbl","block_type":32,"children":["img"],"table_cell":{}},
+		{"block_id":"p1","parent_id":"c1","block_type":2,"text":{"elements":[{"text_run":{"content":"截图"}}]}},
+		{"block_id":"img","parent_id":"c2","block_type":27,"image":{"token":"imgT","width":800,"height":600}}
+	]`
+	p := NewParser(OutputConfig{})
+	var parsed []*lark.DocxBlock
+	if err := json.Unmarshal([]byte(blocks), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	got := p.ParseDocxContent(&lark.DocxDocument{DocumentID: "doc"}, parsed)
+
+	// 表格可能渲染为 HTML，单元格内的图片统一用 <img> 标签
+	want := `<img src="` + ImagePlaceholder("imgT") + `" />`
+	if !strings.Contains(got, want) {
+		t.Errorf("table cell image missing %q, got:\n%s", want, got)
+	}
+	if strings.Contains(got, "![](") {
+		t.Errorf("table cell image rendered as Markdown image:\n%s", got)
+	}
+	// 图片 token 仍被收集，以便下载后替换
+	if len(p.ImgTokens) != 1 || p.ImgTokens[0] != "imgT" {
+		t.Errorf("ImgTokens = %v, want [imgT]", p.ImgTokens)
+	}
+}
+
+func TestNormalizeHeadingLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []int
+		want   []int
+	}{
+		{"already valid", []int{1, 2, 3, 2}, []int{1, 2, 3, 2}},
+		{"skipped level", []int{1, 3, 3, 2}, []int{1, 2, 2, 2}},
+		{"starts below h1", []int{2, 4, 2}, []int{1, 2, 1}},
+		{"deep jump then back", []int{1, 4, 6, 2, 3}, []int{1, 2, 3, 2, 3}},
+		{"empty", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq := make([]headingRef, len(tt.levels))
+			for i, level := range tt.levels {
+				seq[i] = headingRef{BlockID: fmt.Sprintf("h%d", i), Level: level}
+			}
+			fixed := normalizeHeadingLevels(seq)
+			for i, want := range tt.want {
+				if got := fixed[seq[i].BlockID]; got != want {
+					t.Errorf("heading %d (H%d) = H%d, want H%d", i, tt.levels[i], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFixHeadingLevels(t *testing.T) {
+	blocks := `[
+		{"block_id":"doc","block_type":1,"page":{"elements":[{"text_run":{"content":"标题"}}]},"children":["h1","h3"]},
+		{"block_id":"h1","parent_id":"doc","block_type":3,"heading1":{"elements":[{"text_run":{"content":"概述"}}]}},
+		{"block_id":"h3","parent_id":"doc","block_type":5,"heading3":{"elements":[{"text_run":{"content":"细节"}}]}}
+	]`
+	if got := parseBlocks(t, OutputConfig{FixHeadingLevels: true}, blocks); !strings.Contains(got, "\n## 细节") {
+		t.Errorf("H3 under H1 was not promoted to H2:\n%s", got)
+	}
+	if got := parseBlocks(t, OutputConfig{}, blocks); !strings.Contains(got, "\n### 细节") {
+		t.Errorf("heading levels changed without --fix-heading-levels:\n%s", got)
+	}
+}
+
+func TestParseCodeBlockIgnoresStylesAndAnnotations(t *testing.T) {
+	blocks := `[
+		{"block_id":"doc","block_type":1,"page":{"elements":[{"text_run":{"content":"代码"}}]},"children":["code"]},
+		{"block_id":"code","parent_id":"doc","block_type":14,"code":{"style":{"language":22},"elements":[
+			{"text_run":{"content":"x := 1","text_element_style":{"bold":true,"comment_ids":["cmt1"]}}},
+			{"mention_user":{"user_id":"ou_1"}},
+			{"text_run":{"content":" // 注释\ny := 2","text_element_style":{"link":{"url":"https%3A%2F%2Fexample.com"}}}},
+			{"reminder":{"expire_time":"1704067200000","is_whole_day":true}}]}}
+	]`
+	got := parseBlocks(t, OutputConfig{}, blocks)
+	want := "```go\nx := 1 // 注释\ny := 2\n```"
+	if !strings.Contains(got, want) {
+		t.Errorf("code block = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestTruncateCell(t *testing.T) {
+	img := `<img src="x" />`
+	tests := []struct {
+		name     string
+		content  string
+		maxWidth int
+		want     string
+	}{
+		{"short", "飞书", 5, "飞书"},
+		{"exact width", "飞书文档", 4, "飞书文档"},
+		{"truncated", "飞书文档导出", 4, "飞书文档…"},
+		{"tags not counted", "<b>ab</b>cd", 3, "<b>ab</b>c…"},
+		{"line breaks kept", "ab<br/>cd", 3, "ab<br/>c…"},
+		{"image after cut dropped", "abcd" + img, 2, "ab…"},
+		{"image before cut kept", img + "abcd", 2, img + "ab…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateCell(tt.content, tt.maxWidth); got != tt.want {
+				t.Errorf("TruncateCell(%q, %d) = %q, want %q", tt.content, tt.maxWidth, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalloutAlertType(t *testing.T) {
+	tests := []struct {
+		name    string
+		callout *lark.DocxBlockCallout
+		want    string
+	}{
+		{"nil", nil, "TIP"},
+		{"emoji wins over color", &lark.DocxBlockCallout{EmojiID: "warning", BackgroundColor: 4}, "WARNING"},
+		{"red background", &lark.DocxBlockCallout{BackgroundColor: 1}, "CAUTION"},
+		{"blue background", &lark.DocxBlockCallout{BackgroundColor: 5}, "NOTE"},
+		{"light red background", &lark.DocxBlockCallout{BackgroundColor: 8}, "CAUTION"},
+		{"border color only", &lark.DocxBlockCallout{BorderColor: 4}, "TIP"},
+		{"unknown emoji and no color", &lark.DocxBlockCallout{EmojiID: "smile"}, "TIP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalloutAlertType(tt.callout); got != tt.want {
+				t.Errorf("CalloutAlertType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCalloutStyles(t *testing.T) {
+	blocks := `[
+		{"block_id":"doc","block_type":1,"page":{"elements":[{"text_run":{"content":"提示"}}]},"children":["co"]},
+		{"block_id":"co","parent_id":"doc","block_type":19,"children":["p1"],"callout":{"emoji_id":"warning"}},
+		{"block_id":"p1","parent_id":"co","block_type":2,"text":{"elements":[{"text_run":{"content":"注意备份"}}]}}
+	]`
+	if got := parseBlocks(t, OutputConfig{CalloutStyle: CalloutStyleAlert}, blocks); !strings.Contains(got, "> [!WARNING]\n> 注意备份\n") {
+		t.Errorf("alert style = %q", got)
+	}
+	if got := parseBlocks(t, OutputConfig{CalloutStyle: CalloutStyleQuote}, blocks); !strings.Contains(got, "> ⚠️ 注意备份\n") {
+		t.Errorf("quote style = %q", got)
+	}
+}
+
+func TestCollapseBlankLines(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"three blanks collapsed", "a\n\n\n\nb", "a\n\nb"},
+		{"two blanks kept", "a\n\n\nb", "a\n\n\nb"},
+		{"whitespace-only lines count as blank", "a\n \n\t\n  \nb", "a\n\nb"},
+		{"code block untouched", "```\nx\n\n\n\ny\n```\n\n\n\nz", "```\nx\n\n\n\ny\n```\n\nz"},
+		{"longer fence", "````md\n```\n\n\n\n```\n````", "````md\n```\n\n\n\n```\n````"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CollapseBlankLines(tt.in); got != tt.want {
+				t.Errorf("CollapseBlankLines(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceImagePlaceholders(t *testing.T) {
+	// token imgA 是 imgAB 的前缀，正文中也出现了 imgA 字样
+	markdown := "imgA 的说明\n![](" + ImagePlaceholder("imgA") + ")\n![](" + ImagePlaceholder("imgAB") + ")\n" +
+		`<img src="` + ImagePlaceholder("imgC") + `" />`
+	links := map[string]string{
+		"imgA":  "./img/imgA.png",
+		"imgAB": "https://cdn.example.com/imgAB.png",
+	}
+	want := "imgA 的说明\n![](./img/imgA.png)\n![](https://cdn.example.com/imgAB.png)\n" +
+		`<img src="imgC" />`
+	if got := ReplaceImagePlaceholders(markdown, links); got != want {
+		t.Errorf("ReplaceImagePlaceholders() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSplitPages(t *testing.T) {
+	md := "# 标题\n\n序言\n" + PageBreakMarker + "\n## 第一章\n" + PageBreakMarker + "\n\n" + PageBreakMarker + "\n## 第二章\n"
+	got := SplitPages(md)
+	want := []string{"# 标题\n\n序言\n", "## 第一章\n", "## 第二章\n"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("SplitPages() = %q, want %q", got, want)
+	}
+	// 没有分章标记时原样返回
+	if got := SplitPages("正文\n"); len(got) != 1 || got[0] != "正文\n" {
+		t.Errorf("SplitPages(no marker) = %q", got)
+	}
+}
+
+func TestParseSplitOnDivider(t *testing.T) {
+	blocks := `[
+		{"block_id":"doc","block_type":1,"page":{"elements":[{"text_run":{"content":"手册"}}]},"children":["p1","d1","q1"]},
+		{"block_id":"p1","parent_id":"doc","block_type":2,"text":{"elements":[{"text_run":{"content":"第一章"}}]}},
+		{"block_id":"d1","parent_id":"doc","block_type":22,"divider":{}},
+		{"block_id":"q1","parent_id":"doc","block_type":34,"children":["d2"],"quote_container":{}},
+		{"block_id":"d2","parent_id":"q1","block_type":22,"divider":{}}
+	]`
+	// 只有顶层分割线作为分章标记，容器内的分割线保持原样
+	got := parseBlocks(t, OutputConfig{SplitOnDivider: true}, blocks)
+	if strings.Count(got, PageBreakMarker) != 1 {
+		t.Errorf("want exactly one page break marker, got:\n%s", got)
+	}
+	if got := parseBlocks(t, OutputConfig{}, blocks); strings.Contains(got, PageBreakMarker) {
+		t.Errorf("page break marker without --split-divider:\n%s", got)
+	}
+}
+
+// mergedSheetList 3x3 工作表：A1:B1 横向合并为表头，A2:A3 纵向合并；另有一个隐藏工作表
+const mergedSheetList = `{"code":0,"data":{"sheets":[
+	{"sheet_id":"s1","title":"汇总","index":0,"grid_properties":{"row_count":3,"column_count":3},"merges":[
+		{"start_row_index":0,"end_row_index":0,"start_column_index":0,"end_column_index":1},
+		{"start_row_index":1,"end_row_index":2,"start_column_index":0,"end_column_index":0}]},
+	{"sheet_id":"s2","title":"草稿","index":1,"hidden":true,"grid_properties":{"row_count":1,"column_count":1}}]}}`
+
+// mergedSheetValues 合并区域内被覆盖的单元格也带有值，导出时应只保留左上角
+const mergedSheetValues = `{"code":0,"data":{"valueRange":{"values":[
+	["季度汇总","季度汇总","备注"],
+	["华东","Q1","a|b"],
+	["华东",2,null]]}}}`
+
+func TestRenderSpreadsheetMergedCells(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/open-apis/sheets/v3/spreadsheets/shtMerged":
+			fmt.Fprint(w, `{"code":0,"data":{"spreadsheet":{"title":"销售表","token":"shtMerged"}}}`)
+		case r.URL.Path == "/open-apis/sheets/v3/spreadsheets/shtMerged/sheets/query":
+			fmt.Fprint(w, mergedSheetList)
+		case strings.HasPrefix(r.URL.Path, "/open-apis/sheets/v2/spreadsheets/shtMerged/values/s1!"):
+			fmt.Fprint(w, mergedSheetValues)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ss, err := client.GetSheetContent(context.Background(), "shtMerged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# 销售表\n\n## 汇总\n\n" +
+		"| 季度汇总 |  | 备注 |\n" +
+		"| --- | --- | --- |\n" +
+		"| 华东 | Q1 | a\\|b |\n" +
+		"|  | 2 |  |\n\n"
+	if got := RenderSpreadsheet(ss, false); got != want {
+		t.Errorf("RenderSpreadsheet() =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -12,29 +13,69 @@ import (
 type FeishuRateLimiter struct {
 	perSecond *rate.Limiter // 5次/秒限制
 	perMinute *rate.Limiter // 100次/分钟限制
+	waited    int64         // 累计等待时间（纳秒），原子访问
+	shared    *SharedBudget // 跨进程共享预算，nil 表示不启用
+	config    RateLimitConfig
 }
 
-// NewFeishuRateLimiter 创建飞书API限流器
+// NewFeishuRateLimiter 按飞书默认配额创建API限流器
 func NewFeishuRateLimiter() *FeishuRateLimiter {
+	return NewFeishuRateLimiterWithConfig(DefaultRateLimit())
+}
+
+// NewFeishuRateLimiterWithConfig 按指定速率创建API限流器
+func NewFeishuRateLimiterWithConfig(cfg RateLimitConfig) *FeishuRateLimiter {
+	// 分钟级 burst 取配额的十分之一（默认 100次/分钟 时为 10）允许初始突发
+	minuteBurst := cfg.PerMinute / 10
+	if minuteBurst < 1 {
+		minuteBurst = 1
+	}
 	return &FeishuRateLimiter{
-		// 5次/秒，burst设为5允许短时突发
-		perSecond: rate.NewLimiter(rate.Limit(5), 5),
-		
-		// 100次/分钟 = 1.67次/秒，burst设为10允许初始突发
-		perMinute: rate.NewLimiter(rate.Every(time.Minute/100), 10),
+		// 默认 5次/秒，burst 5 允许短时突发
+		perSecond: rate.NewLimiter(rate.Limit(cfg.PerSecond), cfg.Burst),
+
+		// 默认 100次/分钟 = 1.67次/秒
+		perMinute: rate.NewLimiter(rate.Every(time.Minute/time.Duration(cfg.PerMinute)), minuteBurst),
+		config:    cfg,
 	}
 }
 
 // Wait 等待直到可以执行飞书API请求
 // 必须同时满足两个限流器的条件
 func (l *FeishuRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	defer func() { atomic.AddInt64(&l.waited, int64(time.Since(start))) }()
+
 	// 先检查秒级限流
 	if err := l.perSecond.Wait(ctx); err != nil {
 		return err
 	}
 	
 	// 再检查分钟级限流
-	return l.perMinute.Wait(ctx)
+	if err := l.perMinute.Wait(ctx); err != nil {
+		return err
+	}
+
+	// 最后向其他进程共享的预算申请
+	if l.shared != nil {
+		return l.shared.Acquire(ctx)
+	}
+	return nil
+}
+
+// SetShared 启用跨进程共享预算，使同一机器上的多个进程合计不超过飞书配额
+func (l *FeishuRateLimiter) SetShared(b *SharedBudget) {
+	l.shared = b
+}
+
+// Config 返回限流器使用的速率配置
+func (l *FeishuRateLimiter) Config() RateLimitConfig {
+	return l.config
+}
+
+// TotalWait 返回累计在限流器上等待的时间
+func (l *FeishuRateLimiter) TotalWait() time.Duration {
+	return time.Duration(atomic.LoadInt64(&l.waited))
 }
 
 // WaitN 等待N个令牌
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// 跨进程共享预算的锁参数
+const (
+	sharedLockRetry = 20 * time.Millisecond // 抢锁失败后的重试间隔
+	sharedLockStale = 10 * time.Second      // 锁文件超过该时间未释放视为持有进程已退出
+)
+
+// SharedBudget 基于本地文件的跨进程限流预算
+// 预算文件记录最近一分钟内所有进程的调用时间戳（每行一个 UnixNano），
+// 读写通过独占创建的 <path>.lock 锁文件串行化，多个 feishu2md 进程指向同一文件即可共享配额
+type SharedBudget struct {
+	path      string
+	perSecond int // 所有进程合计每秒调用次数
+	perMinute int // 所有进程合计每分钟调用次数
+}
+
+// NewSharedBudget 创建使用 path 作为预算文件的共享限流，速率与单进程限流配置一致
+func NewSharedBudget(path string, cfg RateLimitConfig) *SharedBudget {
+	return &SharedBudget{path: path, perSecond: cfg.PerSecond, perMinute: cfg.PerMinute}
+}
+
+// Acquire 等待直到所有进程合计的调用频率允许再发起一次请求，并登记本次调用
+func (b *SharedBudget) Acquire(ctx context.Context) error {
+	for {
+		wait, err := b.tryAcquire(ctx, time.Now())
+		if err != nil || wait <= 0 {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tryAcquire 在持有锁的情况下检查预算：有余量时登记 now 并返回 0，否则返回需要等待的时间
+func (b *SharedBudget) tryAcquire(ctx context.Context, now time.Time) (time.Duration, error) {
+	unlock, err := b.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	stamps, err := b.read(now.Add(-time.Minute))
+	if err != nil {
+		return 0, err
+	}
+
+	// 秒级窗口：最近一秒内已满时，等到其中最早的一次滑出窗口
+	var inSecond []time.Time
+	for _, t := range stamps {
+		if now.Sub(t) < time.Second {
+			inSecond = append(inSecond, t)
+		}
+	}
+	if len(inSecond) >= b.perSecond {
+		return inSecond[len(inSecond)-b.perSecond].Add(time.Second).Sub(now), nil
+	}
+	if len(stamps) >= b.perMinute {
+		return stamps[len(stamps)-b.perMinute].Add(time.Minute).Sub(now), nil
+	}
+
+	stamps = append(stamps, now)
+	return 0, b.write(stamps)
+}
+
+// read 读取预算文件中晚于 since 的时间戳（按时间升序）
+func (b *SharedBudget) read(since time.Time) ([]time.Time, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(utils.L("读取共享限流文件失败: %w", "failed to read shared rate limit file: %w"), err)
+	}
+	var stamps []time.Time
+	for _, line := range strings.Split(string(data), "\n") {
+		ns, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+		if err != nil {
+			continue
+		}
+		if t := time.Unix(0, ns); t.After(since) {
+			stamps = append(stamps, t)
+		}
+	}
+	return stamps, nil
+}
+
+// write 覆盖写入时间戳；已持有锁，无需原子替换
+func (b *SharedBudget) write(stamps []time.Time) error {
+	var sb strings.Builder
+	for _, t := range stamps {
+		sb.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+		sb.WriteByte('\n')
+	}
+	if err := os.WriteFile(b.path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf(utils.L("写入共享限流文件失败: %w", "failed to write shared rate limit file: %w"), err)
+	}
+	return nil
+}
+
+// lock 通过独占创建锁文件获得跨进程互斥，返回释放函数
+// 锁文件存在过久（持有者异常退出）时会被清除后重新抢占
+func (b *SharedBudget) lock(ctx context.Context) (func(), error) {
+	lockPath := b.path + ".lock"
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf(utils.L("创建共享限流锁失败: %w", "failed to create shared rate limit lock: %w"), err)
+		}
+		if info, serr := os.Stat(lockPath); serr == nil && time.Since(info.ModTime()) > sharedLockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(sharedLockRetry):
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharedBudgetSeesOtherInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budget")
+	cfg := RateLimitConfig{PerSecond: 2, PerMinute: 100}
+	a, b := NewSharedBudget(path, cfg), NewSharedBudget(path, cfg)
+	now := time.Now()
+
+	// a 用完本秒的配额后，b 需要等到最早的一次滑出窗口
+	for i := 0; i < 2; i++ {
+		if wait, err := a.tryAcquire(context.Background(), now.Add(time.Duration(i)*100*time.Millisecond)); err != nil || wait != 0 {
+			t.Fatalf("a.tryAcquire #%d = %v, %v", i, wait, err)
+		}
+	}
+	wait, err := b.tryAcquire(context.Background(), now.Add(200*time.Millisecond))
+	if err != nil || wait != 800*time.Millisecond {
+		t.Errorf("b.tryAcquire = %v, %v, want 800ms", wait, err)
+	}
+	if wait, err := b.tryAcquire(context.Background(), now.Add(time.Second)); err != nil || wait != 0 {
+		t.Errorf("b.tryAcquire after the window = %v, %v, want 0", wait, err)
+	}
+}
+
+func TestSharedBudgetLimitsCombinedRate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits about one second")
+	}
+	path := filepath.Join(t.TempDir(), "budget")
+	cfg := RateLimitConfig{PerSecond: 4, PerMinute: 100}
+
+	// 三个实例只通过预算文件共享状态，相当于三个进程
+	start := time.Now()
+	var wg sync.WaitGroup
+	for p := 0; p < 3; p++ {
+		budget := NewSharedBudget(path, cfg)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 3; i++ {
+				if err := budget.Acquire(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// 9 次调用、每秒 4 次：至少要跨过两个完整的一秒窗口
+	if elapsed := time.Since(start); elapsed < 1900*time.Millisecond {
+		t.Errorf("9 calls at 4/s finished in %v, want about 2s", elapsed)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stamps []int64
+	for _, line := range strings.Fields(string(data)) {
+		ns, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stamps = append(stamps, ns)
+	}
+	sort.Slice(stamps, func(i, j int) bool { return stamps[i] < stamps[j] })
+	if len(stamps) != 9 {
+		t.Fatalf("recorded %d calls, want 9", len(stamps))
+	}
+	// 任意一秒的滑动窗口内合计不超过每秒配额
+	for i := cfg.PerSecond; i < len(stamps); i++ {
+		if gap := time.Duration(stamps[i] - stamps[i-cfg.PerSecond]); gap < time.Second {
+			t.Errorf("%d calls within %v", cfg.PerSecond+1, gap)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Package core - 电子表格（sheet）下载与渲染
+// 拉取电子表格各工作表的单元格，渲染为 Markdown 表格，多个工作表以二级标题分隔
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Perfecto23/feishu2md/utils"
+	"github.com/chyroc/lark"
+)
+
+// Spreadsheet 电子表格及其全部工作表
+type Spreadsheet struct {
+	Token  string
+	Title  string
+	Sheets []*Sheet
+}
+
+// Sheet 单个工作表，Rows 为纯文本单元格，每行列数相同
+type Sheet struct {
+	SheetID string
+	Title   string
+	Rows    [][]string
+}
+
+// feishuOpenBaseURL 默认的飞书开放平台地址，与 SDK 默认值一致
+const feishuOpenBaseURL = "https://open.feishu.cn"
+
+// sheetValueReq 读取单个范围的请求参数
+type sheetValueReq struct {
+	SpreadSheetToken  string `path:"spreadsheetToken" json:"-"`
+	Range             string `path:"range" json:"-"`
+	ValueRenderOption string `query:"valueRenderOption" json:"-"`
+}
+
+// sheetValueResp 单元格保持原始 JSON：SDK 的 SheetContent 无法解析负数、小数与布尔值
+type sheetValueResp struct {
+	Code int64  `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data *struct {
+		ValueRange *struct {
+			Values [][]json.RawMessage `json:"values,omitempty"`
+		} `json:"valueRange,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// GetSheetContent 获取电子表格标题与各工作表（隐藏工作表除外）的单元格内容
+// 合并单元格只保留左上角的值，其余位置为空字符串
+func (c *Client) GetSheetContent(ctx context.Context, sheetToken string) (*Spreadsheet, error) {
+	meta, err := doWithRetry(ctx, c, func() (*lark.GetSpreadsheetResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetSpreadsheet(ctx, &lark.GetSpreadsheetReq{
+			SpreadSheetToken: sheetToken,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	list, err := doWithRetry(ctx, c, func() (*lark.GetSheetListResp, *lark.Response, error) {
+		return c.larkClient.Drive.GetSheetList(ctx, &lark.GetSheetListReq{
+			SpreadSheetToken: sheetToken,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ss := &Spreadsheet{Token: sheetToken}
+	if meta.Spreadsheet != nil {
+		ss.Title = meta.Spreadsheet.Title
+	}
+	sheets := list.Sheets
+	sort.SliceStable(sheets, func(i, j int) bool { return sheets[i].Index < sheets[j].Index })
+	for _, s := range sheets {
+		if s.Hidden || s.GridProperties == nil || s.GridProperties.RowCount == 0 || s.GridProperties.ColumnCount == 0 {
+			continue
+		}
+		rows, err := c.getSheetValues(ctx, sheetToken, s.SheetID,
+			int(s.GridProperties.RowCount), int(s.GridProperties.ColumnCount))
+		if err != nil {
+			return nil, fmt.Errorf(utils.L("读取工作表 %s 失败: %w", "failed to read sheet %s: %w"), s.Title, err)
+		}
+		for _, m := range s.Merges {
+			clearMergedCells(rows, m)
+		}
+		ss.Sheets = append(ss.Sheets, &Sheet{SheetID: s.SheetID, Title: s.Title, Rows: rows})
+	}
+	return ss, nil
+}
+
+// getSheetValues 读取工作表 rowCount×colCount 范围内的单元格，结果按范围补齐为矩形
+func (c *Client) getSheetValues(ctx context.Context, sheetToken, sheetID string, rowCount, colCount int) ([][]string, error) {
+	resp, err := doWithRetry(ctx, c, func() (*sheetValueResp, *lark.Response, error) {
+		resp := new(sheetValueResp)
+		response, err := c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+			Scope:  "Drive",
+			API:    "GetSheetValue",
+			Method: "GET",
+			URL:    c.baseURL + "/open-apis/sheets/v2/spreadsheets/:spreadsheetToken/values/:range",
+			Body: &sheetValueReq{
+				SpreadSheetToken:  sheetToken,
+				Range:             fmt.Sprintf("%s!A1:%s%d", sheetID, SheetColumnName(colCount), rowCount),
+				ValueRenderOption: "ToString",
+			},
+			NeedTenantAccessToken: true,
+		}, resp)
+		return resp, response, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, rowCount)
+	for i := range rows {
+		rows[i] = make([]string, colCount)
+	}
+	if resp.Data == nil || resp.Data.ValueRange == nil {
+		return rows, nil
+	}
+	for i, values := range resp.Data.ValueRange.Values {
+		if i >= rowCount {
+			break
+		}
+		for j, raw := range values {
+			if j >= colCount {
+				break
+			}
+			rows[i][j] = SheetCellText(raw)
+		}
+	}
+	return rows, nil
+}
+
+// clearMergedCells 清空合并区域内除左上角外的单元格；区域越界的部分忽略
+func clearMergedCells(rows [][]string, m *lark.GetSheetListRespSheetMerge) {
+	if m == nil {
+		return
+	}
+	for r := m.StartRowIndex; r <= m.EndRowIndex && r < int64(len(rows)); r++ {
+		if r < 0 {
+			continue
+		}
+		for col := m.StartColumnIndex; col <= m.EndColumnIndex && col < int64(len(rows[r])); col++ {
+			if col < 0 || (r == m.StartRowIndex && col == m.StartColumnIndex) {
+				continue
+			}
+			rows[r][col] = ""
+		}
+	}
+}
+
+// SheetColumnName 把从 1 开始的列号转为列名：1 -> A，27 -> AA
+func SheetColumnName(n int) string {
+	name := ""
+	for n > 0 {
+		n--
+		name = string(rune('A'+n%26)) + name
+		n /= 26
+	}
+	return name
+}
+
+// SheetCellText 把单元格的原始 JSON 转为纯文本：
+// 字符串、数字、布尔值原样输出；链接渲染为 Markdown 链接；
+// @人、@文档、公式取其文本；下拉多选以逗号连接；富文本分段依次拼接
+func SheetCellText(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	switch raw[0] {
+	case '"':
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s
+		}
+	case '[':
+		var parts []json.RawMessage
+		if json.Unmarshal(raw, &parts) == nil {
+			buf := new(strings.Builder)
+			for _, p := range parts {
+				buf.WriteString(SheetCellText(p))
+			}
+			return buf.String()
+		}
+	case '{':
+		var obj struct {
+			Type   string        `json:"type"`
+			Text   string        `json:"text"`
+			Link   string        `json:"link"`
+			Values []interface{} `json:"values"`
+		}
+		if json.Unmarshal(raw, &obj) != nil {
+			return ""
+		}
+		switch {
+		case obj.Type == "url" && obj.Link != "":
+			text := obj.Text
+			if text == "" {
+				text = obj.Link
+			}
+			return fmt.Sprintf("[%s](%s)", text, obj.Link)
+		case obj.Type == "multipleValue":
+			values := make([]string, 0, len(obj.Values))
+			for _, v := range obj.Values {
+				values = append(values, fmt.Sprint(v))
+			}
+			return strings.Join(values, ",")
+		default:
+			return obj.Text
+		}
+	default:
+		// 数字、布尔值
+		return string(raw)
+	}
+	return ""
+}
+
+// RenderSpreadsheet 把电子表格渲染为 Markdown：每个工作表一个二级标题加一张表格，
+// 表格首行作为表头，去掉末尾的空行与空列；noBodyTitle 为 true 时不输出一级标题
+func RenderSpreadsheet(ss *Spreadsheet, noBodyTitle bool) string {
+	buf := new(strings.Builder)
+	if !noBodyTitle {
+		buf.WriteString("# " + ss.Title + "\n\n")
+	}
+	for _, s := range ss.Sheets {
+		buf.WriteString("## " + s.Title + "\n\n")
+		if table := renderSheetTable(s.Rows); table != "" {
+			buf.WriteString(table + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// renderSheetTable 把单元格渲染为 GFM 表格，全空时返回空字符串
+func renderSheetTable(rows [][]string) string {
+	// 去掉末尾的空行与空列
+	rowCount, colCount := 0, 0
+	for i, row := range rows {
+		for j, cell := range row {
+			if strings.TrimSpace(cell) != "" {
+				rowCount = i + 1
+				if j+1 > colCount {
+					colCount = j + 1
+				}
+			}
+		}
+	}
+	if rowCount == 0 {
+		return ""
+	}
+
+	buf := new(strings.Builder)
+	writeRow := func(row []string) {
+		buf.WriteString("|")
+		for j := 0; j < colCount; j++ {
+			cell := ""
+			if j < len(row) {
+				cell = escapeSheetCell(row[j])
+			}
+			buf.WriteString(" " + cell + " |")
+		}
+		buf.WriteString("\n")
+	}
+	writeRow(rows[0])
+	buf.WriteString("|" + strings.Repeat(" --- |", colCount) + "\n")
+	for _, row := range rows[1:rowCount] {
+		writeRow(row)
+	}
+	return buf.String()
+}
+
+// escapeSheetCell 转义表格分隔符，单元格内换行改为 <br>
+func escapeSheetCell(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// srcsetJPEGQuality 缩略图重新编码 JPEG 时的质量
+const srcsetJPEGQuality = 85
+
+// ParseSrcsetWidths 解析逗号分隔的宽度列表（如 "480,960"），去重并升序返回
+func ParseSrcsetWidths(s string) ([]int, error) {
+	seen := make(map[int]bool)
+	var widths []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := strconv.Atoi(part)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf(utils.L("无效的图片宽度: %q", "invalid image width: %q"), part)
+		}
+		if !seen[w] {
+			seen[w] = true
+			widths = append(widths, w)
+		}
+	}
+	sort.Ints(widths)
+	return widths, nil
+}
+
+// srcsetVariantName 返回缩略图文件名，如 xxx.png -> xxx-480w.png
+// 不使用 "<token>." 前缀，避免被当作原图复用
+func srcsetVariantName(file string, width int) string {
+	ext := filepath.Ext(file)
+	return fmt.Sprintf("%s-%dw%s", strings.TrimSuffix(file, ext), width, ext)
+}
+
+// GenerateSrcset 为 docDir 下相对路径为 link 的本地图片生成小于原图宽度的缩略图，
+// 返回 <img srcset> 属性值（包含原图）；仅支持 JPEG 与 PNG，其余格式返回空字符串
+func GenerateSrcset(docDir, link string, widths []int) (string, error) {
+	ext := normalizeImageExt(filepath.Ext(link))
+	if ext != ".jpg" && ext != ".png" {
+		return "", nil
+	}
+	srcPath := filepath.Join(docDir, filepath.FromSlash(link))
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf(utils.L("解码图片失败 %s: %v", "failed to decode image %s: %v"), link, err)
+	}
+	origWidth := img.Bounds().Dx()
+
+	var candidates []string
+	for _, w := range widths {
+		if w >= origWidth {
+			break
+		}
+		variant := srcsetVariantName(link, w)
+		var out bytes.Buffer
+		resized := resizeImage(img, w)
+		if ext == ".png" {
+			err = png.Encode(&out, resized)
+		} else {
+			err = jpeg.Encode(&out, resized, &jpeg.Options{Quality: srcsetJPEGQuality})
+		}
+		if err != nil {
+			return "", fmt.Errorf(utils.L("编码缩略图失败 %s: %v", "failed to encode thumbnail %s: %v"), variant, err)
+		}
+		if err := utils.WriteFileAtomic(filepath.Join(docDir, filepath.FromSlash(variant)), out.Bytes(), 0o666); err != nil {
+			return "", fmt.Errorf(utils.L("写入缩略图失败 %s: %v", "failed to write thumbnail %s: %v"), variant, err)
+		}
+		candidates = append(candidates, fmt.Sprintf("%s %dw", variant, w))
+	}
+	if len(candidates) == 0 {
+		return "", nil
+	}
+	candidates = append(candidates, fmt.Sprintf("%s %dw", link, origWidth))
+	return strings.Join(candidates, ", "), nil
+}
+
+// resizeImage 按宽度等比缩小图片，每个目标像素取其覆盖的源像素区域的平均值（盒式滤波）
+func resizeImage(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	height := srcH * width / srcW
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*srcH/height
+		y1 := b.Min.Y + (y+1)*srcH/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*srcW/width
+			x1 := b.Min.X + (x+1)*srcW/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
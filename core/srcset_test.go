@@ -0,0 +1,67 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSrcsetWidths(t *testing.T) {
+	got, err := ParseSrcsetWidths(" 960, 480,,960 ")
+	if err != nil || len(got) != 2 || got[0] != 480 || got[1] != 960 {
+		t.Errorf("ParseSrcsetWidths() = %v, %v, want [480 960]", got, err)
+	}
+	for _, bad := range []string{"480,abc", "0", "-320"} {
+		if _, err := ParseSrcsetWidths(bad); err == nil {
+			t.Errorf("ParseSrcsetWidths(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestGenerateSrcset(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "img"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 800, 400))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "img", "imgA.png"), buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不小于原图宽度的尺寸不生成缩略图
+	got, err := GenerateSrcset(dir, "./img/imgA.png", []int{200, 400, 800, 1600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "./img/imgA-200w.png 200w, ./img/imgA-400w.png 400w, ./img/imgA.png 800w"; got != want {
+		t.Errorf("GenerateSrcset() = %q, want %q", got, want)
+	}
+	for width, height := range map[int]int{200: 100, 400: 200} {
+		f, err := os.Open(filepath.Join(dir, "img", srcsetVariantName("imgA.png", width)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := png.DecodeConfig(f)
+		f.Close()
+		if err != nil || cfg.Width != width || cfg.Height != height {
+			t.Errorf("%dw thumbnail = %dx%d, %v, want %dx%d", width, cfg.Width, cfg.Height, err, width, height)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "img", "imgA-800w.png")); !os.IsNotExist(err) {
+		t.Errorf("thumbnail as wide as the original was written: %v", err)
+	}
+
+	// 所有尺寸都不小于原图或格式不支持时不输出 srcset
+	if got, err := GenerateSrcset(dir, "./img/imgA.png", []int{1600}); err != nil || got != "" {
+		t.Errorf("GenerateSrcset(too wide) = %q, %v", got, err)
+	}
+	if got, err := GenerateSrcset(dir, "./img/anim.gif", []int{200}); err != nil || got != "" {
+		t.Errorf("GenerateSrcset(gif) = %q, %v", got, err)
+	}
+}
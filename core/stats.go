@@ -0,0 +1,71 @@
+package core
+
+import (
+	"unicode"
+
+	"github.com/chyroc/lark"
+)
+
+// DocStats 单篇文档的内容统计，用于内容盘点报告
+type DocStats struct {
+	Blocks int `json:"blocks"` // 正文块数（不含文档根块）
+	Words  int `json:"words"`  // 字数：每个汉字计 1，连续的字母数字计 1
+	Images int `json:"images"` // 图片块数（包括表格单元格中的图片）
+}
+
+// CountDocxStats 统计文档的块数、字数与图片数
+func CountDocxStats(doc *lark.DocxDocument, blocks []*lark.DocxBlock) DocStats {
+	var s DocStats
+	for _, b := range blocks {
+		if b == nil || b.BlockID == doc.DocumentID || b.BlockType == lark.DocxBlockTypePage {
+			continue
+		}
+		s.Blocks++
+		if b.BlockType == lark.DocxBlockTypeImage {
+			s.Images++
+		}
+		if text := blockText(b); text != nil {
+			for _, e := range text.Elements {
+				if e.TextRun != nil {
+					s.Words += countWords(e.TextRun.Content)
+				}
+			}
+		}
+	}
+	return s
+}
+
+// blockText 返回文本类块的文本内容，非文本类块返回 nil
+func blockText(b *lark.DocxBlock) *lark.DocxBlockText {
+	for _, t := range []*lark.DocxBlockText{
+		b.Text, b.Heading1, b.Heading2, b.Heading3, b.Heading4, b.Heading5,
+		b.Heading6, b.Heading7, b.Heading8, b.Heading9, b.Bullet, b.Ordered,
+		b.Code, b.Quote, b.Todo,
+	} {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// countWords 按中文习惯统计字数：汉字逐字计数，英文单词与数字按连续片段计数
+func countWords(s string) int {
+	n := 0
+	inWord := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			n++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				n++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
@@ -5,12 +5,15 @@ go 1.21
 require (
 	github.com/88250/lute v1.7.3
 	github.com/chyroc/lark v0.0.98-0.20220914014759-f9ad5a16e595
+	github.com/mozillazg/go-pinyin v0.20.0
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/urfave/cli/v2 v2.6.0
 )
 
 require (
 	github.com/pkg/errors v0.9.1
+	golang.org/x/sync v0.7.0
+	golang.org/x/text v0.15.0
 	golang.org/x/time v0.0.0-20220722155302-e5dcc9cfc0b9
 )
 
@@ -25,5 +28,4 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/stretchr/testify v1.9.0 // indirect
 	golang.org/x/sys v0.20.0 // indirect
-	golang.org/x/text v0.15.0 // indirect
 )
@@ -1,15 +1,42 @@
 // Package picgo - 上传缓存管理
-// 维护 token -> URL 的映射，避免重复上传
+// 维护 token -> 上传记录（URL、内容哈希、上传时间）的映射，避免重复上传；
+// 设置有效期后，过期的记录视为未命中并重新上传，避免图床图片被删除或替换后仍引用失效 URL
 // 缓存存储在当前工作目录的 .feishu2md/ 下，便于跟随仓库提交
 package picgo
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
 )
 
+// CacheEntry 一张图片的上传记录
+type CacheEntry struct {
+	URL        string    `json:"url"`
+	ContentMD5 string    `json:"content_md5,omitempty"` // 上传内容的 MD5，旧格式迁移的记录为空
+	UploadedAt time.Time `json:"uploaded_at"`           // 上传时间，旧格式迁移的记录为零值
+}
+
+// cacheTTL 缓存有效期，0 表示永不过期
+var cacheTTL time.Duration
+
+// SetCacheTTL 设置缓存有效期，超过有效期（或没有上传时间）的记录视为未命中，0 表示永不过期
+func SetCacheTTL(ttl time.Duration) {
+	cacheTTL = ttl
+}
+
+// expired 判断记录是否已超过有效期
+func (e CacheEntry) expired(now time.Time) bool {
+	return cacheTTL > 0 && (e.UploadedAt.IsZero() || now.Sub(e.UploadedAt) > cacheTTL)
+}
+
 // 缓存文件路径（相对于当前工作目录）
 var (
 	cacheDir  string
@@ -19,9 +46,11 @@ var (
 
 // cache 内存缓存
 var (
-	cache   = make(map[string]string)
-	cacheMu sync.RWMutex
-	loaded  bool
+	cache     = make(map[string]CacheEntry)
+	cacheMu   sync.RWMutex
+	loaded    bool           // 是否已从文件加载，受 cacheMu 保护
+	persistMu sync.Mutex     // 串行化落盘，避免异步持久化与 FlushCache 交错写文件
+	persistWG sync.WaitGroup // 进行中的异步落盘，FlushCache 等待其完成
 )
 
 // initCachePath 初始化缓存路径
@@ -40,33 +69,77 @@ func initCachePath() {
 
 // loadCache 从文件加载缓存
 func loadCache() {
-	if loaded {
+	if cacheLoaded() {
 		return
 	}
 
 	initCachePath()
 
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if loaded {
+		return
+	}
+	loaded = true
+
 	data, err := os.ReadFile(cacheFile)
 	if err != nil {
 		// 文件不存在是正常的
-		loaded = true
 		return
 	}
+	cache = parseCache(data)
+}
 
-	cacheMu.Lock()
-	defer cacheMu.Unlock()
+// cacheLoaded 返回缓存是否已从文件加载
+func cacheLoaded() bool {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	return loaded
+}
 
-	if err := json.Unmarshal(data, &cache); err != nil {
-		// JSON 解析失败，忽略
-		cache = make(map[string]string)
+// parseCache 解析缓存文件，兼容旧的 token -> URL 字符串格式（迁移为没有哈希与时间的记录），
+// 下次落盘时即写为新格式；JSON 解析失败时返回空缓存
+func parseCache(data []byte) map[string]CacheEntry {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return make(map[string]CacheEntry)
 	}
-	loaded = true
+	entries := make(map[string]CacheEntry, len(raw))
+	for token, value := range raw {
+		var url string
+		if err := json.Unmarshal(value, &url); err == nil {
+			entries[token] = CacheEntry{URL: url}
+			continue
+		}
+		var entry CacheEntry
+		if err := json.Unmarshal(value, &entry); err == nil && entry.URL != "" {
+			entries[token] = entry
+		}
+	}
+	return entries
+}
+
+// fileMD5 计算文件内容的 MD5，读取失败时返回空字符串
+func fileMD5(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // saveCache 保存缓存到文件
 func persistCache() error {
 	initCachePath()
 
+	persistMu.Lock()
+	defer persistMu.Unlock()
+
 	// 确保目录存在
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return err
@@ -80,30 +153,36 @@ func persistCache() error {
 		return err
 	}
 
-	return os.WriteFile(cacheFile, data, 0644)
+	return utils.WriteFileAtomic(cacheFile, data, 0644)
 }
 
-// GetCached 获取缓存的 URL
+// GetCached 获取缓存的 URL，记录不存在或已过期时返回 false
 func GetCached(token string) (string, bool) {
 	loadCache()
 
 	cacheMu.RLock()
 	defer cacheMu.RUnlock()
 
-	url, ok := cache[token]
-	return url, ok
+	entry, ok := cache[token]
+	if !ok || entry.expired(time.Now()) {
+		return "", false
+	}
+	// 缓存中可能是切换协议前的 URL，读取时按当前设置改写
+	return ApplyURLScheme(entry.URL), true
 }
 
-// SaveCache 保存到缓存
-func SaveCache(token, url string) {
+// SaveCache 保存到缓存，记录内容哈希与上传时间
+func SaveCache(token, url, contentMD5 string) {
 	loadCache()
 
 	cacheMu.Lock()
-	cache[token] = url
+	cache[token] = CacheEntry{URL: url, ContentMD5: contentMD5, UploadedAt: time.Now()}
 	cacheMu.Unlock()
 
 	// 异步持久化，不阻塞主流程
+	persistWG.Add(1)
 	go func() {
+		defer persistWG.Done()
 		if err := persistCache(); err != nil {
 			// 持久化失败不影响主流程，仅打印警告
 			// fmt.Printf("⚠️  缓存持久化失败: %v\n", err)
@@ -111,14 +190,44 @@ func SaveCache(token, url string) {
 	}()
 }
 
+// FlushCache 等待进行中的异步落盘后同步持久化缓存并清理派生的临时配置，用于退出前确保缓存落盘
+// 缓存未加载过（本次运行未使用图床）时不做任何事
+func FlushCache() error {
+	persistWG.Wait()
+	removePrefixedConfigs("")
+	return PersistCache()
+}
+
+// PersistCache 同步持久化上传缓存与 key 索引，不影响进行中的上传，可在下载过程中周期性调用
+func PersistCache() error {
+	if !cacheLoaded() {
+		return nil
+	}
+	if err := persistCache(); err != nil {
+		return err
+	}
+	keyIndexMu.Lock()
+	keysUsed := keyLoaded
+	keyIndexMu.Unlock()
+	if !keysUsed {
+		return nil
+	}
+	return persistKeyIndex()
+}
+
 // ClearCache 清空缓存（用于测试或重置）
 func ClearCache() {
 	cacheMu.Lock()
-	cache = make(map[string]string)
+	cache = make(map[string]CacheEntry)
 	cacheMu.Unlock()
 
+	keyIndexMu.Lock()
+	keyIndex = make(map[string]keyEntry)
+	keyIndexMu.Unlock()
+
 	initCachePath()
 	os.Remove(cacheFile)
+	os.Remove(keyIndexFile())
 }
 
 // CacheSize 返回缓存条目数
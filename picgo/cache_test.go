@@ -0,0 +1,106 @@
+package picgo
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useTempCache 让上传缓存与 key 索引使用临时目录并清空内存状态，返回缓存文件路径
+func useTempCache(t *testing.T) string {
+	t.Helper()
+	initCachePath()
+	origDir, origFile := cacheDir, cacheFile
+	cacheDir = filepath.Join(t.TempDir(), ".feishu2md")
+	cacheFile = filepath.Join(cacheDir, "upload-cache.json")
+	reset := func() {
+		cacheMu.Lock()
+		cache, loaded = make(map[string]CacheEntry), false
+		cacheMu.Unlock()
+		keyIndexMu.Lock()
+		keyIndex, keyLoaded = make(map[string]keyEntry), false
+		keyIndexMu.Unlock()
+	}
+	reset()
+	t.Cleanup(func() {
+		persistWG.Wait()
+		reset()
+		cacheDir, cacheFile = origDir, origFile
+		SetCacheTTL(0)
+	})
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return cacheFile
+}
+
+func TestLoadCacheMigratesOldFormat(t *testing.T) {
+	path := useTempCache(t)
+	old := `{"imgOld":"https://img.example.com/old.png",
+		"imgNew":{"url":"https://img.example.com/new.png","content_md5":"abc","uploaded_at":"2024-01-01T00:00:00Z"}}`
+	if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for token, want := range map[string]string{"imgOld": "https://img.example.com/old.png", "imgNew": "https://img.example.com/new.png"} {
+		if got, ok := GetCached(token); !ok || got != want {
+			t.Errorf("GetCached(%s) = %q, %v, want %q", token, got, ok, want)
+		}
+	}
+
+	// 落盘后旧格式的字符串记录改写为结构体
+	if err := PersistCache(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries map[string]CacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("cache file is not in the new format: %v\n%s", err, data)
+	}
+	if e := entries["imgOld"]; e.URL != "https://img.example.com/old.png" || !e.UploadedAt.IsZero() {
+		t.Errorf("migrated entry = %+v", e)
+	}
+	if e := entries["imgNew"]; e.ContentMD5 != "abc" || !e.UploadedAt.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("new-format entry = %+v", e)
+	}
+
+	// 设置有效期后，没有上传时间的迁移记录视为过期
+	SetCacheTTL(time.Hour)
+	if _, ok := GetCached("imgOld"); ok {
+		t.Error("GetCached(migrated entry) hit with a TTL, want miss")
+	}
+}
+
+func TestExpiredCacheEntryIsReuploaded(t *testing.T) {
+	useTempCache(t)
+	calls := fakePicgo(t, `echo "https://img.example.com/fresh-$N.png"`)
+	SetCacheTTL(time.Hour)
+
+	img := filepath.Join(t.TempDir(), "imgA.png")
+	if err := os.WriteFile(img, []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cacheMu.Lock()
+	loaded = true
+	cache["imgA"] = CacheEntry{URL: "https://img.example.com/stale.png", UploadedAt: time.Now().Add(-2 * time.Hour)}
+	cacheMu.Unlock()
+
+	url, ok := uploadCached(context.Background(), img)
+	if !ok || url != "https://img.example.com/fresh-1.png" {
+		t.Fatalf("uploadCached(expired) = %q, %v, want a fresh upload", url, ok)
+	}
+	// 重新上传后记录刷新，有效期内再次使用直接命中缓存
+	url, ok = uploadCached(context.Background(), img)
+	if !ok || url != "https://img.example.com/fresh-1.png" {
+		t.Errorf("uploadCached(fresh) = %q, %v, want the cached URL", url, ok)
+	}
+	if got := callCount(t, calls); got != 1 {
+		t.Errorf("picgo calls = %d, want 1", got)
+	}
+}
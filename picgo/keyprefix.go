@@ -0,0 +1,145 @@
+// Package picgo - 按文档划分图床目录
+// 通过上下文携带 object key 前缀（通常为 docToken），上传时基于原 picgo 配置
+// 派生一份把前缀追加到图床路径字段的临时配置，使同一文档的图片位于同一目录下
+package picgo
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// keyPrefixCtxKey 上下文中 object key 前缀的键
+type keyPrefixCtxKey struct{}
+
+// WithKeyPrefix 返回携带 object key 前缀的上下文，经其上传的图片位于图床的 prefix/ 目录下
+func WithKeyPrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, keyPrefixCtxKey{}, strings.Trim(prefix, "/"))
+}
+
+// keyPrefixFrom 取出上下文中的 object key 前缀，未设置时为空
+func keyPrefixFrom(ctx context.Context) string {
+	prefix, _ := ctx.Value(keyPrefixCtxKey{}).(string)
+	return prefix
+}
+
+// prefixPathFields 各图床配置中决定 object key 目录的字段
+// aws-s3 的 uploadPath 是完整的 key 模板（如 {year}/{md5}.{extName}），其余为目录前缀
+var prefixPathFields = map[string]string{
+	"github": "path",
+	"aliyun": "path",
+	"tcyun":  "path",
+	"qiniu":  "path",
+	"upyun":  "path",
+	"aws-s3": "uploadPath",
+}
+
+// joinKeyPrefix 把前缀拼入图床路径字段的原值
+func joinKeyPrefix(uploader, base, prefix string) string {
+	if uploader == "aws-s3" {
+		if base == "" {
+			base = "{fileName}.{extName}"
+		}
+		return prefix + "/" + strings.TrimPrefix(base, "/")
+	}
+	if base = strings.TrimSuffix(base, "/"); base == "" {
+		return prefix + "/"
+	}
+	return base + "/" + prefix + "/"
+}
+
+// 已派生的临时配置，键为 原配置路径 + 前缀
+var (
+	prefixedConfigs   = make(map[string]string)
+	prefixedConfigsMu sync.Mutex
+	prefixWarnOnce    sync.Once
+)
+
+// prefixedConfig 返回在 configPath（为空时为 picgo 默认配置）基础上追加 prefix 的配置文件路径
+// 临时配置写在原配置同目录下：picgo 从配置文件所在目录加载插件，换目录会找不到图床插件
+func prefixedConfig(configPath, prefix string) (string, error) {
+	basePath := configPath
+	if basePath == "" {
+		basePath = DefaultConfigPath()
+	}
+	cacheKey := basePath + "\x00" + prefix
+
+	prefixedConfigsMu.Lock()
+	defer prefixedConfigsMu.Unlock()
+	if path, ok := prefixedConfigs[cacheKey]; ok {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(basePath)
+	if err != nil {
+		return "", err
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", err
+	}
+	picBed, _ := cfg["picBed"].(map[string]interface{})
+	uploader, _ := picBed["uploader"].(string)
+	if uploader == "" {
+		uploader, _ = picBed["current"].(string)
+	}
+	field, ok := prefixPathFields[uploader]
+	settings, _ := picBed[uploader].(map[string]interface{})
+	if !ok || settings == nil {
+		return "", fmt.Errorf(utils.L("图床 %q 不支持自定义上传目录", "image host %q does not support a custom upload directory"), uploader)
+	}
+	base, _ := settings[field].(string)
+	settings[field] = joinKeyPrefix(uploader, base, prefix)
+
+	out, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(cacheKey))
+	path := filepath.Join(filepath.Dir(basePath), fmt.Sprintf(".feishu2md-prefix-%x.json", sum[:6]))
+	if err := os.WriteFile(path, out, 0600); err != nil {
+		return "", err
+	}
+	prefixedConfigs[cacheKey] = path
+	return path, nil
+}
+
+// resolveUploadConfig 根据上下文中的前缀选择实际使用的配置；无法派生时提示一次并退回原配置
+func resolveUploadConfig(ctx context.Context, configPath string) string {
+	prefix := keyPrefixFrom(ctx)
+	if prefix == "" {
+		return configPath
+	}
+	path, err := prefixedConfig(configPath, prefix)
+	if err != nil {
+		prefixWarnOnce.Do(func() {
+			fmt.Printf(utils.L("⚠️  无法划分图床目录，使用原配置上传: %v\n", "⚠️  Cannot add a directory to image host keys, uploading with the original config: %v\n"), err)
+		})
+		return configPath
+	}
+	return path
+}
+
+// ReleaseKeyPrefix 删除为 prefix 派生的临时配置，文档的图片上传完成后调用
+func ReleaseKeyPrefix(prefix string) {
+	removePrefixedConfigs("\x00" + strings.Trim(prefix, "/"))
+}
+
+// removePrefixedConfigs 删除键以 suffix 结尾的派生临时配置，suffix 为空时全部删除
+func removePrefixedConfigs(suffix string) {
+	prefixedConfigsMu.Lock()
+	defer prefixedConfigsMu.Unlock()
+	for key, path := range prefixedConfigs {
+		if strings.HasSuffix(key, suffix) {
+			os.Remove(path)
+			delete(prefixedConfigs, key)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package picgo - 图床 object key 冲突检测
+// 图床通常以文件名作为 object key，不同文档中的同名图片会互相覆盖。
+// 这里记录每个 key 已上传内容的哈希，上传前比对：内容相同直接复用 URL，
+// 内容不同则在文件名后追加哈希后缀换一个 key 再上传
+package picgo
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// keyHashLen 冲突换名时追加的哈希后缀长度
+const keyHashLen = 8
+
+// keyEntry 某个 object key 上已上传内容的哈希与 URL
+type keyEntry struct {
+	Hash string `json:"hash"`
+	URL  string `json:"url,omitempty"` // 上传中尚未拿到 URL 时为空
+}
+
+// keyIndex object key -> 已上传内容，持久化在 .feishu2md/upload-keys.json
+var (
+	keyIndex     = make(map[string]keyEntry)
+	keyIndexMu   sync.Mutex
+	keyLoaded    bool
+	keyPersistMu sync.Mutex
+)
+
+// keyIndexFile 返回 key 索引文件路径，与上传缓存位于同一目录
+func keyIndexFile() string {
+	initCachePath()
+	return filepath.Join(cacheDir, "upload-keys.json")
+}
+
+// loadKeyIndex 从文件加载 key 索引，调用方需持有 keyIndexMu
+func loadKeyIndex() {
+	if keyLoaded {
+		return
+	}
+	keyLoaded = true
+	data, err := os.ReadFile(keyIndexFile())
+	if err != nil {
+		// 文件不存在是正常的
+		return
+	}
+	if err := json.Unmarshal(data, &keyIndex); err != nil {
+		// JSON 解析失败，忽略
+		keyIndex = make(map[string]keyEntry)
+	}
+}
+
+// persistKeyIndex 保存 key 索引到文件
+func persistKeyIndex() error {
+	initCachePath()
+
+	keyPersistMu.Lock()
+	defer keyPersistMu.Unlock()
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+
+	keyIndexMu.Lock()
+	data, err := json.MarshalIndent(keyIndex, "", "  ")
+	keyIndexMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(keyIndexFile(), data, 0644)
+}
+
+// fileHash 计算文件内容的 sha1
+func fileHash(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashedKey 在文件名（扩展名之前）追加哈希后缀，如 image.png -> image-1a2b3c4d.png
+func hashedKey(key, hash string) string {
+	ext := filepath.Ext(key)
+	return strings.TrimSuffix(key, ext) + "-" + hash[:keyHashLen] + ext
+}
+
+// reserveKey 为内容为 hash 的图片选定 object key：
+//   - 原 key 未被占用，或已上传的是相同内容：沿用原 key
+//   - 原 key 已被不同内容占用：换成带哈希后缀的 key
+//
+// 返回选定的 key，以及该内容此前已上传得到的 URL（有则无需再上传）。
+// 选定的 key 会立即登记，防止并发上传的同名图片选中同一个 key
+func reserveKey(key, hash string) (string, string) {
+	keyIndexMu.Lock()
+	defer keyIndexMu.Unlock()
+	loadKeyIndex()
+
+	if entry, ok := keyIndex[key]; ok && entry.Hash != hash {
+		key = hashedKey(key, hash)
+	}
+	if entry, ok := keyIndex[key]; ok && entry.Hash == hash {
+		return key, entry.URL
+	}
+	keyIndex[key] = keyEntry{Hash: hash}
+	return key, ""
+}
+
+// commitKey 上传结束后更新 key 登记：成功记录 URL，失败释放占用
+func commitKey(key, hash, url string) {
+	keyIndexMu.Lock()
+	if url == "" {
+		if entry, ok := keyIndex[key]; ok && entry.Hash == hash && entry.URL == "" {
+			delete(keyIndex, key)
+		}
+	} else {
+		keyIndex[key] = keyEntry{Hash: hash, URL: url}
+	}
+	keyIndexMu.Unlock()
+}
+
+// uploadWithUniqueKey 按内容哈希避开 object key 冲突后上传。
+// 需要换 key 时把图片复制到临时目录下的新文件名再上传，不改动本地文件
+func uploadWithUniqueKey(ctx context.Context, filePath string) (string, error) {
+	hash, err := fileHash(filePath)
+	if err != nil {
+		return UploadWithContext(ctx, filePath)
+	}
+
+	origKey := filepath.Base(filePath)
+	if prefix := keyPrefixFrom(ctx); prefix != "" {
+		origKey = prefix + "/" + origKey
+	}
+	key, url := reserveKey(origKey, hash)
+	if url != "" {
+		return ApplyURLScheme(url), nil
+	}
+
+	uploadPath := filePath
+	if key != origKey {
+		tmpDir, err := os.MkdirTemp("", "feishu2md-upload-")
+		if err != nil {
+			commitKey(key, hash, "")
+			return "", err
+		}
+		defer os.RemoveAll(tmpDir)
+		uploadPath = filepath.Join(tmpDir, filepath.Base(key))
+		if err := copyFile(filePath, uploadPath); err != nil {
+			commitKey(key, hash, "")
+			return "", err
+		}
+		fmt.Printf(utils.L("🔀 图床 key 冲突，内容不同，改用 %s 上传: %s\n", "🔀 Image host key conflict with different content, uploading as %s: %s\n"), key, filePath)
+	}
+
+	url, err = UploadWithContext(ctx, uploadPath)
+	commitKey(key, hash, url)
+	if err == nil {
+		persistWG.Add(1)
+		go func() {
+			defer persistWG.Done()
+			persistKeyIndex()
+		}()
+	}
+	return url, err
+}
+
+// copyFile 复制文件内容
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -4,24 +4,101 @@ package picgo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Perfecto23/feishu2md/utils"
 )
 
 // 默认配置
 const (
-	DefaultTimeout   = 120 * time.Second // 单张图片上传超时
-	MaxUploadRetries = 2                 // 最大重试次数
-	BatchConcurrency = 10                // 批量上传并发数
+	DefaultTimeout       = 120 * time.Second // 单张图片上传超时
+	DefaultUploadRetries = 3                 // 单个图床上传失败后的默认重试次数
+	BatchConcurrency     = 10                // 批量上传并发数
 )
 
+// uploadRetries 单个图床上传失败后的重试次数，重试间隔从 1 秒起逐次翻倍
+var uploadRetries = DefaultUploadRetries
+
+// uploadRetryBaseDelay 首次重试前的等待时间
+var uploadRetryBaseDelay = time.Second
+
+// uploadRateLimitDelay 图床返回限流时重试前至少等待的时间：
+// SM.MS、GitHub、七牛等基于 HTTP API 的图床按 token 限制上传频率，按普通间隔重试仍会被拒
+var uploadRateLimitDelay = 30 * time.Second
+
+// rateLimitPattern picgo 输出中表示图床限流的特征：HTTP 429、七牛的 573、SM.MS 的上传频率限制、GitHub 的 rate limit
+var rateLimitPattern = regexp.MustCompile(`(?i)\b(429|573)\b|too many requests|rate limit|frequency limit`)
+
+// authFailurePattern picgo 输出中表示图床鉴权失败（token 无效、过期或密钥错误）的特征，重试无意义
+var authFailurePattern = regexp.MustCompile(`(?i)\b401\b|unauthorized|bad credentials|bad token|invalid token`)
+
+// SetUploadRetries 设置单个图床上传失败后的重试次数，0 表示不重试
+func SetUploadRetries(n int) {
+	if n < 0 {
+		n = 0
+	}
+	uploadRetries = n
+}
+
+// uploadSlots 进程内同时进行的上传数上限，所有文档的流水线共用；
+// 槽位占满时流水线 worker 等待，Submit 随之阻塞，下载暂停，待上传的图片不会在内存或磁盘上无限堆积
+var uploadSlots = make(chan struct{}, BatchConcurrency)
+
+// SetUploadConcurrency 设置进程内同时进行的上传数上限，需在开始上传前调用；n <= 0 时使用 BatchConcurrency
+func SetUploadConcurrency(n int) {
+	if n <= 0 {
+		n = BatchConcurrency
+	}
+	uploadSlots = make(chan struct{}, n)
+}
+
 // urlPattern 用于从 picgo 输出中提取 URL
 var urlPattern = regexp.MustCompile(`https?://[^\s"'<>]+`)
 
+// backupConfigs 备用图床的 picgo 配置文件路径，主图床上传失败时按顺序尝试
+var backupConfigs []string
+
+// SetBackupConfigs 设置备用图床配置（picgo 的 -c 配置文件路径），为空表示不启用主备切换
+func SetBackupConfigs(paths []string) {
+	backupConfigs = paths
+}
+
+// urlScheme 输出图床 URL 时使用的协议："https"、"http" 或 "//"（相对协议），为空表示保持原样
+var urlScheme string
+
+// SetURLScheme 设置输出图床 URL 的协议，用于避免站点与图床协议不一致导致的混合内容
+func SetURLScheme(scheme string) {
+	urlScheme = scheme
+}
+
+// ApplyURLScheme 按设置的协议改写 URL；非 http(s) 或相对协议的 URL 原样返回
+func ApplyURLScheme(url string) string {
+	if urlScheme == "" {
+		return url
+	}
+	var rest string
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		rest = strings.TrimPrefix(url, "https://")
+	case strings.HasPrefix(url, "http://"):
+		rest = strings.TrimPrefix(url, "http://")
+	case strings.HasPrefix(url, "//"):
+		rest = strings.TrimPrefix(url, "//")
+	default:
+		return url
+	}
+	if urlScheme == "//" {
+		return "//" + rest
+	}
+	return urlScheme + "://" + rest
+}
+
 // IsAvailable 检测 picgo CLI 是否可用
 func IsAvailable() bool {
 	_, err := exec.LookPath("picgo")
@@ -46,22 +123,78 @@ func Upload(filePath string) (string, error) {
 }
 
 // UploadWithContext 带上下文的上传
+// 每个图床失败后先重试，主图床（picgo 默认配置）仍失败时，依次使用备用图床配置
 func UploadWithContext(ctx context.Context, filePath string) (string, error) {
+	url, err := uploadWithRetry(ctx, "", filePath)
+	if err == nil {
+		return ApplyURLScheme(url), nil
+	}
+
+	errs := []string{fmt.Sprintf(utils.L("主图床: %v", "primary image host: %v"), err)}
+	for _, configPath := range backupConfigs {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Printf(utils.L("⚠️  图床上传失败，切换备用图床 %s: %s\n", "⚠️  Upload failed, switching to backup image host %s: %s\n"), configPath, filePath)
+		url, berr := uploadWithRetry(ctx, configPath, filePath)
+		if berr == nil {
+			return ApplyURLScheme(url), nil
+		}
+		errs = append(errs, fmt.Sprintf(utils.L("备用图床 %s: %v", "backup image host %s: %v"), configPath, berr))
+	}
+	if len(errs) == 1 {
+		return "", err
+	}
+	return "", fmt.Errorf(utils.L("所有图床均上传失败:\n%s", "upload failed on every image host:\n%s"), strings.Join(errs, "\n"))
+}
+
+// uploadWithRetry 使用指定配置上传，失败后按 uploadRetries 重试，间隔逐次翻倍；
+// 图床限流时至少等待 uploadRateLimitDelay，鉴权失败时不再重试
+func uploadWithRetry(ctx context.Context, configPath, filePath string) (string, error) {
+	delay := uploadRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		url, err := uploadWithConfig(ctx, configPath, filePath)
+		if err != nil && authFailurePattern.MatchString(err.Error()) {
+			return "", fmt.Errorf(utils.L("图床鉴权失败，请检查 picgo 配置中的 token 或密钥: %w", "image host authentication failed, check the token or keys in the picgo config: %w"), err)
+		}
+		if err == nil || attempt >= uploadRetries || ctx.Err() != nil {
+			return url, err
+		}
+		wait := delay
+		if rateLimitPattern.MatchString(err.Error()) && wait < uploadRateLimitDelay {
+			wait = uploadRateLimitDelay
+			fmt.Printf(utils.L("⏳ 图床限流，%v 后重试: %s\n", "⏳ Image host rate limited, retrying in %v: %s\n"), wait, filePath)
+		}
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// uploadWithConfig 使用指定的 picgo 配置文件上传，configPath 为空时使用 picgo 默认配置
+func uploadWithConfig(ctx context.Context, configPath, filePath string) (string, error) {
 	// 创建带超时的上下文
 	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
 
 	// 执行 picgo 命令（不使用静默模式，以便获取完整输出）
-	cmd := exec.CommandContext(ctx, "picgo", "u", filePath)
+	args := []string{"u", filePath}
+	if configPath = resolveUploadConfig(ctx, configPath); configPath != "" {
+		args = append([]string{"-c", configPath}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "picgo", args...)
 	output, err := cmd.CombinedOutput()
 	outputStr := strings.TrimSpace(string(output))
 
 	if err != nil {
 		// 检查是否超时
 		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("上传超时（%v）: %s", DefaultTimeout, filePath)
+			return "", fmt.Errorf(utils.L("上传超时（%v）: %s", "upload timed out (%v): %s"), DefaultTimeout, filePath)
 		}
-		return "", fmt.Errorf("picgo 上传失败: %v\n输出: %s", err, outputStr)
+		return "", fmt.Errorf(utils.L("picgo 上传失败: %v\n输出: %s", "picgo upload failed: %v\noutput: %s"), err, outputStr)
 	}
 
 	// 解析 URL
@@ -69,9 +202,9 @@ func UploadWithContext(ctx context.Context, filePath string) (string, error) {
 	if url == "" {
 		// 输出更详细的调试信息
 		if outputStr == "" {
-			return "", fmt.Errorf("picgo 无输出，请检查配置: picgo config")
+			return "", errors.New(utils.L("picgo 无输出，请检查配置: picgo config", "picgo printed nothing, check its config: picgo config"))
 		}
-		return "", fmt.Errorf("未能从输出中解析 URL，picgo 输出:\n%s", outputStr)
+		return "", fmt.Errorf(utils.L("未能从输出中解析 URL，picgo 输出:\n%s", "no URL found in the picgo output:\n%s"), outputStr)
 	}
 
 	return url, nil
@@ -98,58 +231,80 @@ type BatchUploadResult struct {
 // BatchUpload 批量上传图片
 // 返回 localPath -> URL 的映射（仅包含成功的）
 func BatchUpload(ctx context.Context, filePaths []string) map[string]string {
-	if len(filePaths) == 0 {
-		return make(map[string]string)
+	p := NewPipeline(ctx)
+	for _, path := range filePaths {
+		p.Submit(path)
 	}
+	return p.Wait()
+}
 
-	results := make(map[string]string, len(filePaths))
-	var mu sync.Mutex
-
-	// 并发控制
-	semaphore := make(chan struct{}, BatchConcurrency)
-	var wg sync.WaitGroup
+// Pipeline 流水线上传：图片下载完成后立即投递，后台以 BatchConcurrency 个 worker 上传，
+// 无需等待整篇文档的图片全部下载完；worker 还需占用进程级上传槽位（uploadSlots），
+// 多篇文档并发时总上传数同样有界，投递在 worker 全忙时阻塞，对下载形成背压
+type Pipeline struct {
+	ctx     context.Context
+	jobs    chan string
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	results map[string]string
+}
 
-	for _, path := range filePaths {
-		wg.Add(1)
-		semaphore <- struct{}{}
-
-		go func(filePath string) {
-			defer func() {
-				<-semaphore
-				wg.Done()
-			}()
-
-			// 先检查缓存
-			token := extractTokenFromPath(filePath)
-			if token != "" {
-				if cachedURL, ok := GetCached(token); ok {
-					mu.Lock()
-					results[filePath] = cachedURL
-					mu.Unlock()
-					return
+// NewPipeline 创建流水线并启动上传 worker，使用完毕必须调用 Wait
+func NewPipeline(ctx context.Context) *Pipeline {
+	p := &Pipeline{
+		ctx:     ctx,
+		jobs:    make(chan string),
+		results: make(map[string]string),
+	}
+	p.wg.Add(BatchConcurrency)
+	for i := 0; i < BatchConcurrency; i++ {
+		go func() {
+			defer p.wg.Done()
+			for filePath := range p.jobs {
+				uploadSlots <- struct{}{}
+				url, ok := uploadCached(p.ctx, filePath)
+				<-uploadSlots
+				if ok {
+					p.mu.Lock()
+					p.results[filePath] = url
+					p.mu.Unlock()
 				}
 			}
+		}()
+	}
+	return p
+}
 
-			// 上传
-			url, err := UploadWithContext(ctx, filePath)
-			if err != nil {
-				fmt.Printf("⚠️  上传失败 %s: %v\n", filePath, err)
-				return
-			}
+// Submit 投递一张待上传的本地图片，可被多个 goroutine 并发调用
+func (p *Pipeline) Submit(filePath string) {
+	p.jobs <- filePath
+}
 
-			mu.Lock()
-			results[filePath] = url
-			mu.Unlock()
+// Wait 停止接收新图片，等待已投递的全部上传完成，返回 filePath -> url
+func (p *Pipeline) Wait() map[string]string {
+	close(p.jobs)
+	p.wg.Wait()
+	return p.results
+}
 
-			// 保存缓存
-			if token != "" {
-				SaveCache(token, url)
-			}
-		}(path)
+// uploadCached 上传单张图片：先查缓存，未命中则上传并写入缓存
+func uploadCached(ctx context.Context, filePath string) (string, bool) {
+	token := extractTokenFromPath(filePath)
+	if token != "" {
+		if cachedURL, ok := GetCached(token); ok {
+			return cachedURL, true
+		}
 	}
 
-	wg.Wait()
-	return results
+	url, err := uploadWithUniqueKey(ctx, filePath)
+	if err != nil {
+		fmt.Printf(utils.L("⚠️  上传失败，保留本地图片 %s: %v\n", "⚠️  Upload failed, keeping the local image %s: %v\n"), filePath, err)
+		return "", false
+	}
+	if token != "" {
+		SaveCache(token, url, fileMD5(filePath))
+	}
+	return url, true
 }
 
 // extractTokenFromPath 从文件路径中提取 token（文件名不含扩展名）
@@ -0,0 +1,98 @@
+package picgo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePicgo 在 PATH 最前面放置一个名为 picgo 的 shell 脚本；脚本每次运行先向 calls 文件追加一行，
+// 可用 $N（本次为第几次调用）决定输出，返回 calls 文件路径
+func fakePicgo(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake picgo script requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	content := "#!/bin/sh\necho >> " + calls + "\nN=$(wc -l < " + calls + ")\n" + script + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "picgo"), []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	retries, base, rateLimit := uploadRetries, uploadRetryBaseDelay, uploadRateLimitDelay
+	uploadRetryBaseDelay = time.Millisecond
+	t.Cleanup(func() { uploadRetries, uploadRetryBaseDelay, uploadRateLimitDelay = retries, base, rateLimit })
+	return calls
+}
+
+// callCount 返回假 picgo 被调用的次数
+func callCount(t *testing.T, calls string) int {
+	t.Helper()
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func TestUploadWaitsOutRateLimit(t *testing.T) {
+	calls := fakePicgo(t, `if [ "$N" -eq 1 ]; then echo "[PicGo ERROR]: Upload file frequency limit."; exit 1; fi
+echo "https://i.loli.net/2024/01/01/a.png"`)
+	uploadRateLimitDelay = 50 * time.Millisecond
+
+	start := time.Now()
+	url, err := uploadWithRetry(context.Background(), "", "a.png")
+	if err != nil || url != "https://i.loli.net/2024/01/01/a.png" {
+		t.Fatalf("uploadWithRetry() = %q, %v", url, err)
+	}
+	if elapsed := time.Since(start); elapsed < uploadRateLimitDelay {
+		t.Errorf("retried after %v, want at least %v", elapsed, uploadRateLimitDelay)
+	}
+	if got := callCount(t, calls); got != 2 {
+		t.Errorf("picgo calls = %d, want 2", got)
+	}
+}
+
+func TestUploadDoesNotRetryAuthFailure(t *testing.T) {
+	calls := fakePicgo(t, `echo "[PicGo ERROR]: HttpError: Bad credentials"; exit 1`)
+	uploadRetries = 3
+
+	_, err := uploadWithRetry(context.Background(), "", "a.png")
+	if err == nil || !strings.Contains(err.Error(), "token") {
+		t.Fatalf("uploadWithRetry() error = %v, want an authentication hint", err)
+	}
+	if got := callCount(t, calls); got != 1 {
+		t.Errorf("picgo calls = %d, want 1 (no retry on auth failure)", got)
+	}
+}
+
+func TestUploadFallsBackToBackupConfig(t *testing.T) {
+	// 主图床（无 -c 参数）总是失败，备用配置 backup-2.json 上传成功
+	calls := fakePicgo(t, `if [ "$1" = "-c" ] && [ "$2" = "backup-2.json" ]; then echo "https://backup.example.com/a.png"; exit 0; fi
+echo "[PicGo ERROR]: upload failed"; exit 1`)
+	uploadRetries = 1
+	t.Cleanup(func() { SetBackupConfigs(nil) })
+	SetBackupConfigs([]string{"backup-1.json", "backup-2.json"})
+
+	url, err := UploadWithContext(context.Background(), "a.png")
+	if err != nil || url != "https://backup.example.com/a.png" {
+		t.Fatalf("UploadWithContext() = %q, %v", url, err)
+	}
+	// 主图床与第一个备用图床各上传 1 次并重试 1 次，第二个备用图床一次成功
+	if got := callCount(t, calls); got != 5 {
+		t.Errorf("picgo calls = %d, want 5", got)
+	}
+
+	// 全部失败时错误中列出每个图床的原因
+	SetBackupConfigs([]string{"backup-1.json"})
+	_, err = UploadWithContext(context.Background(), "a.png")
+	if err == nil || !strings.Contains(err.Error(), "backup-1.json") {
+		t.Errorf("UploadWithContext() error = %v, want it to name the backup config", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package picgo - 图床配置校验
+// 在下载开始前检查 picgo CLI 与图床配置是否齐全，避免等到第一张图片上传时才失败
+package picgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// picgoConfigFile picgo 配置文件中与图床相关的部分
+type picgoConfigFile struct {
+	PicBed map[string]json.RawMessage `json:"picBed"`
+}
+
+// requiredUploaderKeys 部分图床缺少即无法上传的配置项，按图床名列出
+// aws-s3 由 picgo-plugin-s3 提供，同时用于 AWS S3 与 MinIO 等 S3 兼容存储；
+// smms、github、qiniu 为 picgo 内置的 HTTP API 图床，需要鉴权 token 或密钥
+var requiredUploaderKeys = map[string][]string{
+	"aws-s3": {"accessKeyID", "secretAccessKey", "bucketName"},
+	"smms":   {"token"},
+	"github": {"repo", "branch", "token"},
+	"qiniu":  {"accessKey", "secretKey", "bucket", "url", "area"},
+}
+
+// DefaultConfigPath 返回 picgo 默认配置文件路径（~/.picgo/config.json）
+func DefaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".picgo", "config.json")
+	}
+	return filepath.Join(home, ".picgo", "config.json")
+}
+
+// Validate 校验 picgo CLI、默认配置与备用图床配置，返回包含全部缺失项的错误
+func Validate() error {
+	var problems []string
+	if !IsAvailable() {
+		problems = append(problems, utils.L("未找到 picgo 命令，请先安装: npm install picgo -g", "picgo command not found, install it with: npm install picgo -g"))
+	}
+	if err := validateConfigFile(DefaultConfigPath()); err != nil {
+		problems = append(problems, fmt.Sprintf(utils.L("主图床: %v", "primary image host: %v"), err))
+	}
+	for _, path := range backupConfigs {
+		if err := validateConfigFile(path); err != nil {
+			problems = append(problems, fmt.Sprintf(utils.L("备用图床 %s: %v", "backup image host %s: %v"), path, err))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf(utils.L("图床配置不完整:\n  - %s", "incomplete image host config:\n  - %s"), strings.Join(problems, "\n  - "))
+}
+
+// validateConfigFile 检查配置文件存在、已选择当前图床且该图床有配置项
+func validateConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf(utils.L("配置文件 %s 不存在，请运行 picgo set uploader <图床名>", "config file %s does not exist, run picgo set uploader <name>"), path)
+		}
+		return fmt.Errorf(utils.L("读取配置文件 %s 失败: %v", "failed to read config file %s: %v"), path, err)
+	}
+	var cfg picgoConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf(utils.L("配置文件 %s 不是有效的 JSON: %v", "config file %s is not valid JSON: %v"), path, err)
+	}
+
+	uploader := stringField(cfg.PicBed["uploader"])
+	if uploader == "" {
+		uploader = stringField(cfg.PicBed["current"])
+	}
+	if uploader == "" {
+		return errors.New(utils.L("未设置当前图床（picBed.uploader），请运行 picgo use uploader", "no current image host (picBed.uploader), run picgo use uploader"))
+	}
+	var settings map[string]interface{}
+	if raw, ok := cfg.PicBed[uploader]; !ok || json.Unmarshal(raw, &settings) != nil || len(settings) == 0 {
+		return fmt.Errorf(utils.L("图床 %s 缺少配置（picBed.%s），请运行 picgo set uploader %s", "image host %s has no config (picBed.%s), run picgo set uploader %s"), uploader, uploader, uploader)
+	}
+	var missing []string
+	for _, key := range requiredUploaderKeys[uploader] {
+		if v, ok := settings[key].(string); !ok || strings.TrimSpace(v) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf(utils.L("图床 %s 缺少配置项 %s，请运行 picgo set uploader %s", "image host %s is missing %s, run picgo set uploader %s"), uploader, strings.Join(missing, utils.L("、", ", ")), uploader)
+	}
+	return nil
+}
+
+// stringField 解析 JSON 字符串字段，非字符串或缺失时返回空字符串
+func stringField(raw json.RawMessage) string {
+	var s string
+	if len(raw) == 0 || json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,67 @@
+package picgo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Perfecto23/feishu2md/utils"
+)
+
+// writePicgoConfig 在临时目录写入 picgo 配置文件，返回其路径
+func writePicgoConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateConfigFileLocalized(t *testing.T) {
+	t.Cleanup(func() { utils.SetLang("zh") })
+	path := writePicgoConfig(t, `{"picBed":{"uploader":"aws-s3","aws-s3":{"bucketName":"b"}}}`)
+
+	for lang, want := range map[string]string{
+		"zh": "图床 aws-s3 缺少配置项 accessKeyID、secretAccessKey，请运行 picgo set uploader aws-s3",
+		"en": "image host aws-s3 is missing accessKeyID, secretAccessKey, run picgo set uploader aws-s3",
+	} {
+		if err := utils.SetLang(lang); err != nil {
+			t.Fatal(err)
+		}
+		err := validateConfigFile(path)
+		if err == nil || err.Error() != want {
+			t.Errorf("lang %s: validateConfigFile() = %v, want %q", lang, err, want)
+		}
+	}
+}
+
+func TestValidateConfigFileRequiredKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		missing string // 为空表示校验通过
+	}{
+		{"smms ok", `{"picBed":{"uploader":"smms","smms":{"token":"t"}}}`, ""},
+		{"smms without token", `{"picBed":{"uploader":"smms","smms":{"backupDomain":"smms.app"}}}`, "token"},
+		{"github ok", `{"picBed":{"uploader":"github","github":{"repo":"u/r","branch":"main","token":"t"}}}`, ""},
+		{"github without token", `{"picBed":{"uploader":"github","github":{"repo":"u/r","branch":"main","token":" "}}}`, "token"},
+		{"qiniu ok", `{"picBed":{"uploader":"qiniu","qiniu":{"accessKey":"a","secretKey":"s","bucket":"b","url":"https://cdn.example.com","area":"z0"}}}`, ""},
+		{"qiniu without url and area", `{"picBed":{"uploader":"qiniu","qiniu":{"accessKey":"a","secretKey":"s","bucket":"b"}}}`, "url、area"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfigFile(writePicgoConfig(t, tt.config))
+			if tt.missing == "" {
+				if err != nil {
+					t.Errorf("validateConfigFile() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "缺少配置项 "+tt.missing+"，") {
+				t.Errorf("validateConfigFile() = %v, want missing %s", err, tt.missing)
+			}
+		})
+	}
+}
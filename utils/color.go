@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// decorate 用户可见输出是否保留 emoji 与 ANSI 颜色码，默认保留
+var decorate = true
+
+// SetColor 设置终端装饰模式：always 始终保留 emoji 与颜色码，never 全部去掉，
+// auto 仅在标准输出是终端、且未设置 NO_COLOR、TERM 不为 dumb 时保留
+func SetColor(mode string) error {
+	switch mode {
+	case "always":
+		decorate = true
+	case "never":
+		decorate = false
+	case "auto":
+		decorate = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	default:
+		return fmt.Errorf("unsupported color mode %q (auto|always|never)", mode)
+	}
+	return nil
+}
+
+// isTerminal 判断文件是否为字符设备（终端）；CI 日志、管道与重定向均不是
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ansiEscapeReg 匹配 ANSI 颜色等控制序列
+var ansiEscapeReg = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// StripDecorations 去掉文案中的 ANSI 控制序列与 emoji，emoji 后紧跟的空格一并去掉
+func StripDecorations(s string) string {
+	s = ansiEscapeReg.ReplaceAllString(s, "")
+	var b strings.Builder
+	b.Grow(len(s))
+	skipSpace := false
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		switch {
+		case isEmoji(r):
+			skipSpace = true
+			continue
+		case skipSpace && r == ' ':
+			continue
+		}
+		skipSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isEmoji 判断字符是否属于输出中使用的 emoji 及其组合字符（变体选择符、零宽连接符）
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // 表情、符号与象形文字
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // 杂项符号与装饰符号，如 ⚠ ✅ ❌
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // 杂项技术符号，如 ⏭ ⏩ ⏹
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // 杂项符号与箭头，如 ⭐
+		return true
+	case r == 0xFE0F || r == 0x200D:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestColorNeverStripsOnlyDecorations(t *testing.T) {
+	t.Cleanup(func() { SetColor("always") })
+	tests := []struct {
+		in, never string
+	}{
+		{"✅ 下载完成: a.md", "下载完成: a.md"},
+		{"⚠️  图片下载失败", "图片下载失败"},
+		{"\x1b[32m成功\x1b[0m 3 个", "成功 3 个"},
+		// 不含 emoji 与颜色码的文案保持原样
+		{"错误: --since 取值无效（2024-13-01）", "错误: --since 取值无效（2024-13-01）"},
+		{"- 文档/a.md  [新增]  | 图片: +2 / 命中0", "- 文档/a.md  [新增]  | 图片: +2 / 命中0"},
+	}
+	for _, tt := range tests {
+		if err := SetColor("never"); err != nil {
+			t.Fatal(err)
+		}
+		if got := L(tt.in, tt.in); got != tt.never {
+			t.Errorf("never: L(%q) = %q, want %q", tt.in, got, tt.never)
+		}
+		SetColor("always")
+		if got := L(tt.in, tt.in); got != tt.in {
+			t.Errorf("always: L(%q) = %q, want unchanged", tt.in, got)
+		}
+	}
+}
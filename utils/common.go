@@ -3,8 +3,16 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
 )
 
 var StopWhenErr = true
@@ -25,11 +33,45 @@ func CheckErr(e error) error {
 	return e
 }
 
+// ToPosixPath 把路径分隔符统一为 /，用于写入 Markdown 链接与站点 URL。
+// filepath.ToSlash 只转换当前系统的分隔符，这里额外转换 Windows 风格的 \，
+// 使在任意系统上配置的 assets\img 也能得到可在网页中打开的链接
+func ToPosixPath(p string) string {
+	return strings.ReplaceAll(filepath.ToSlash(p), "\\", "/")
+}
+
 func PrettyPrint(i interface{}) string {
 	s, _ := json.MarshalIndent(i, "", "  ")
 	return string(s)
 }
 
+// DefaultMaxFileNameBytes 文件名（不含扩展名）默认的字节上限，给扩展名与去重后缀留出余量，
+// 避免超过常见文件系统 255 字节的限制
+const DefaultMaxFileNameBytes = 200
+
+// maxFileNameBytes SanitizeFileName 截断文件名使用的字节上限
+var maxFileNameBytes = DefaultMaxFileNameBytes
+
+// SetMaxFileNameBytes 设置文件名字节上限，n <= 0 时恢复默认值
+func SetMaxFileNameBytes(n int) {
+	if n <= 0 {
+		n = DefaultMaxFileNameBytes
+	}
+	maxFileNameBytes = n
+}
+
+// TruncateUTF8 把 s 截断到不超过 n 字节，不会切断多字节字符
+func TruncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// SanitizeFileName 把标题转为可用的文件名：替换非法字符，并按字节上限截断
 func SanitizeFileName(title string) string {
 	// 特殊字符的智能替换规则
 	replacements := map[string]string{
@@ -49,8 +91,9 @@ func SanitizeFileName(title string) string {
 		title = strings.ReplaceAll(title, invalid, replacement)
 	}
 
-	// 移除首尾空白字符
+	// 移除首尾空白字符，超长时截断（截断后可能露出新的尾部空白）
 	title = strings.TrimSpace(title)
+	title = strings.TrimSpace(TruncateUTF8(title, maxFileNameBytes))
 
 	// 如果文件名为空或只包含点，使用默认名称
 	if title == "" || title == "." || title == ".." {
@@ -59,3 +102,105 @@ func SanitizeFileName(title string) string {
 
 	return title
 }
+
+// isEmojiRune 判断字符是否属于 emoji 序列：图形符号、变体选择符、零宽连接符、肤色修饰、旗帜字母与键帽组合符
+func isEmojiRune(r rune) bool {
+	switch {
+	case r == 0x200D, r == 0x20E3, r == 0xFE0E, r == 0xFE0F:
+		return true
+	case r >= 0x1F1E6 && r <= 0x1F1FF: // 区域指示符（旗帜）
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF: // 肤色修饰
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // 标签序列（子区域旗帜）
+		return true
+	}
+	return unicode.Is(unicode.So, r)
+}
+
+// StripLeadingEmoji 去掉标题开头的 emoji 及其后的空白，如 "🚀 发布计划" -> "发布计划"
+// 全部由 emoji 组成的标题原样返回，避免得到空文件名
+func StripLeadingEmoji(title string) string {
+	rest := strings.TrimLeftFunc(title, func(r rune) bool {
+		return isEmojiRune(r) || unicode.IsSpace(r)
+	})
+	if rest == "" {
+		return title
+	}
+	return rest
+}
+
+// pinyinArgs Slugify 使用的拼音转写配置：不带声调，多音字取第一个读音
+var pinyinArgs = pinyin.NewArgs()
+
+// Slugify 将标题转换为 URL 友好的 ASCII slug（小写字母、数字与连字符）
+// 汉字转写为不带声调的拼音（每个字一个词），带重音的拉丁字母会被还原为基本字母；
+// 其余无法转写的字符被丢弃
+// 结果可能为空，调用方需自行回退（例如使用文档 token）
+func Slugify(title string) string {
+	var b strings.Builder
+	lastDash := true // 避免开头出现连字符
+	for _, r := range norm.NFKD.String(title) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			pys := pinyin.SinglePinyin(r, pinyinArgs)
+			if len(pys) == 0 {
+				break
+			}
+			if !lastDash {
+				b.WriteByte('-')
+			}
+			b.WriteString(pys[0])
+			b.WriteByte('-')
+			lastDash = true
+		case unicode.Is(unicode.Mn, r):
+			// 分解后的重音符号，直接丢弃
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(unicode.ToLower(r))
+			lastDash = false
+		default:
+			if !lastDash {
+				b.WriteByte('-')
+				lastDash = true
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// WriteFileAtomic 先写入同目录下的临时文件再重命名，避免中断时留下写了一半的文件
+// 与 os.WriteFile 一样，perm 受 umask 约束
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := createTempFile(filepath.Dir(path), perm)
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// createTempFile 在 dir 下以 perm 新建临时文件；os.CreateTemp 固定使用 0600，
+// 事后 Chmod 会绕过 umask，这里直接以 perm 创建，由系统套用 umask
+func createTempFile(dir string, perm os.FileMode) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, ".feishu2md-"+strconv.FormatUint(rand.Uint64(), 36)+".tmp")
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) && i < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
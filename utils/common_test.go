@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"Café Crème", "cafe-creme"},
+		{"飞书文档", "fei-shu-wen-dang"},
+		{"飞书 API 指南", "fei-shu-api-zhi-nan"},
+		{"第2章：安装", "di-2-zhang-an-zhuang"},
+		{"🚀 —", ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.title); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	// "飞书" 每个汉字 3 字节
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"abc", 5, "abc"},
+		{"abcdef", 3, "abc"},
+		{"飞书", 6, "飞书"},
+		{"飞书", 5, "飞"},
+		{"飞书", 3, "飞"},
+		{"飞书", 2, ""},
+		{"a飞书", 4, "a飞"},
+	}
+	for _, tt := range tests {
+		if got := TruncateUTF8(tt.s, tt.n); got != tt.want {
+			t.Errorf("TruncateUTF8(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	if got := SanitizeFileName(long); len(got) != DefaultMaxFileNameBytes {
+		t.Errorf("SanitizeFileName(300 bytes) has %d bytes, want %d", len(got), DefaultMaxFileNameBytes)
+	}
+
+	// 全中文标题：按字节截断且不切断汉字
+	chinese := strings.Repeat("飞书文档", 30) // 360 字节
+	got := SanitizeFileName(chinese)
+	if len(got) > DefaultMaxFileNameBytes || !utf8.ValidString(got) || !strings.HasPrefix(chinese, got) {
+		t.Errorf("SanitizeFileName(all Chinese) = %q (%d bytes), want a valid prefix within %d bytes", got, len(got), DefaultMaxFileNameBytes)
+	}
+	if len(got) != DefaultMaxFileNameBytes/3*3 {
+		t.Errorf("SanitizeFileName(all Chinese) has %d bytes, want %d", len(got), DefaultMaxFileNameBytes/3*3)
+	}
+
+	tests := map[string]string{
+		"JavaScript/TypeScript": "JavaScript-TypeScript",
+		"  标题  ":                "标题",
+		"..":                    "untitled",
+		"问题?":                   "问题？",
+	}
+	for title, want := range tests {
+		if got := SanitizeFileName(title); got != want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestWriteFileAtomicRespectsUmask(t *testing.T) {
+	dir := t.TempDir()
+	// 与 os.WriteFile 创建的文件权限一致，即同样套用了 umask
+	ref := filepath.Join(dir, "ref")
+	if err := os.WriteFile(ref, []byte("x"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "atomic")
+	if err := WriteFileAtomic(path, []byte("data"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.Stat(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Mode().Perm() != want.Mode().Perm() {
+		t.Errorf("mode = %v, want %v (same as os.WriteFile)", got.Mode().Perm(), want.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "data" {
+		t.Errorf("content = %q, want data", data)
+	}
+	// 不留下临时文件
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("dir has %d entries, want 2", len(entries))
+	}
+}
+
+func TestValidateDocumentURLShareLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		wantType  string
+		wantToken string
+	}{
+		{"标准链接", "https://x.feishu.cn/docx/doxcnAbc123", "docx", "doxcnAbc123"},
+		{"带 from 参数", "https://x.feishu.cn/docx/doxcnAbc123?from=from_copylink", "docx", "doxcnAbc123"},
+		{"token 参数", "https://x.feishu.cn/docx?token=doxcnAbc123", "docx", "doxcnAbc123"},
+		{"doc_token 参数", "https://x.feishu.cn/wiki/?doc_token=wikcnAbc123&from=share", "wiki", "wikcnAbc123"},
+		{"obj_token 参数", "https://x.larksuite.com/sheets?obj_token=shtcnAbc123", "sheets", "shtcnAbc123"},
+		{"applink 跳转", "https://applink.feishu.cn/client/docs/open?url=" + url.QueryEscape("https://x.feishu.cn/docx/doxcnAbc123?from=share"), "docx", "doxcnAbc123"},
+		{"applink 套 token 参数", "https://applink.feishu.cn/client/docs/open?url=" + url.QueryEscape("https://x.feishu.cn/docx?token=doxcnAbc123"), "docx", "doxcnAbc123"},
+		{"首尾空白", "  https://x.feishu.cn/docx?token=doxcnAbc123\n", "docx", "doxcnAbc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docType, docToken, err := ValidateDocumentURL(tt.url)
+			if err != nil || docType != tt.wantType || docToken != tt.wantToken {
+				t.Errorf("ValidateDocumentURL(%q) = %q, %q, %v, want %q, %q", tt.url, docType, docToken, err, tt.wantType, tt.wantToken)
+			}
+		})
+	}
+
+	// 分享链接形式的文件夹与知识库
+	if token, err := ValidateFolderURL("https://x.feishu.cn/drive/folder?token=fldcnAbc123"); err != nil || token != "fldcnAbc123" {
+		t.Errorf("ValidateFolderURL(share link) = %q, %v", token, err)
+	}
+	if prefix, token, err := ValidateWikiURL("https://x.feishu.cn/wiki?token=wikcnAbc123"); err != nil || prefix != "https://x.feishu.cn" || token != "wikcnAbc123" {
+		t.Errorf("ValidateWikiURL(share link) = %q, %q, %v", prefix, token, err)
+	}
+
+	// 无法识别的分享参数保持原样，仍判定为非法链接
+	for _, bad := range []string{
+		"https://x.feishu.cn/docx?token=",
+		"https://x.feishu.cn/docx?token=../etc",
+		"https://x.feishu.cn/base?token=doxcnAbc123",
+		"https://applink.feishu.cn/client/docs/open",
+	} {
+		if _, _, err := ValidateDocumentURL(bad); err == nil {
+			t.Errorf("ValidateDocumentURL(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestBareTokenInput(t *testing.T) {
+	tests := []struct {
+		input     string
+		tokenType string
+		wantBare  bool
+		wantURL   string
+	}{
+		{"doxcnAbc123", "docx", true, "https://feishu.cn/docx/doxcnAbc123"},
+		{" wikcnAbc123\n", "wiki", true, "https://feishu.cn/wiki/wikcnAbc123"},
+		{"shtcnAbc123", "sheet", true, "https://feishu.cn/sheets/shtcnAbc123"},
+		{"fldcnAbc123", "folder", true, "https://feishu.cn/drive/folder/fldcnAbc123"},
+		{"abc123", "docx", false, ""},
+		{"dox-cn_Abc123", "docx", false, ""},
+		{"https://x.feishu.cn/docx/doxcnAbc123", "docx", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := IsBareToken(tt.input); got != tt.wantBare {
+				t.Fatalf("IsBareToken(%q) = %v, want %v", tt.input, got, tt.wantBare)
+			}
+			if !tt.wantBare {
+				return
+			}
+			if got := TokenToURL(tt.input, tt.tokenType); got != tt.wantURL {
+				t.Errorf("TokenToURL(%q, %q) = %q, want %q", tt.input, tt.tokenType, got, tt.wantURL)
+			}
+		})
+	}
+
+	// 补全后的 URL 能通过对应的校验并取回原 token
+	if docType, token, err := ValidateDocumentURL(TokenToURL("doxcnAbc123", "docx")); err != nil || docType != "docx" || token != "doxcnAbc123" {
+		t.Errorf("ValidateDocumentURL(docx token) = %q, %q, %v", docType, token, err)
+	}
+	if _, token, err := ValidateWikiURL(TokenToURL("wikcnAbc123", "wiki")); err != nil || token != "wikcnAbc123" {
+		t.Errorf("ValidateWikiURL(wiki token) = %q, %v", token, err)
+	}
+	if token, err := ValidateFolderURL(TokenToURL("fldcnAbc123", "folder")); err != nil || token != "fldcnAbc123" {
+		t.Errorf("ValidateFolderURL(folder token) = %q, %v", token, err)
+	}
+}
+
+func TestStripLeadingEmoji(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"🚀 发布计划", "发布计划"},
+		{"🚀🔥发布计划", "发布计划"},
+		{"👨‍👩‍👧 家庭相册", "家庭相册"}, // ZWJ 组合
+		{"👍🏽 点赞", "点赞"},        // 肤色修饰
+		{"🇨🇳 国旗", "国旗"},        // 区域指示符
+		{"❤️ 心愿单", "心愿单"},      // 变体选择符
+		{"发布计划 🚀", "发布计划 🚀"},   // 只去掉开头
+		{"1️⃣ 第一步", "1️⃣ 第一步"}, // 数字键帽以数字开头，不视为 emoji 前缀
+		{"🚀🔥", "🚀🔥"},           // 全部为 emoji 时原样返回
+		{"普通标题", "普通标题"},
+	}
+	for _, tt := range tests {
+		if got := StripLeadingEmoji(tt.title); got != tt.want {
+			t.Errorf("StripLeadingEmoji(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
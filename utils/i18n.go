@@ -0,0 +1,43 @@
+package utils
+
+import "fmt"
+
+// lang 用户可见输出的语言，默认中文
+var lang = "zh"
+
+// SetLang 设置用户可见输出的语言，仅支持 zh 与 en
+func SetLang(l string) error {
+	switch l {
+	case "zh", "en":
+		lang = l
+		return nil
+	default:
+		return fmt.Errorf("unsupported language %q (zh|en)", l)
+	}
+}
+
+// L 按当前语言在中文与英文文案之间选择；关闭终端装饰时去掉其中的 emoji 与颜色码
+func L(zh, en string) string {
+	s := zh
+	if lang == "en" {
+		s = en
+	}
+	if !decorate {
+		return StripDecorations(s)
+	}
+	return s
+}
+
+// localizedError 在输出时才按当前语言选择文案的错误，可作为 errors.Is 比较的哨兵错误
+type localizedError struct {
+	zh, en string
+}
+
+func (e *localizedError) Error() string {
+	return L(e.zh, e.en)
+}
+
+// NewError 创建中英文文案随 --lang 切换的错误；与 errors.New 一样，每次调用得到不同的错误值
+func NewError(zh, en string) error {
+	return &localizedError{zh: zh, en: en}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"net/url"
 	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -14,8 +15,69 @@ func UnescapeURL(rawURL string) string {
 	return rawURL
 }
 
+// shareTokenParams 分享链接中可能携带文档 token 的查询参数
+var shareTokenParams = []string{"token", "doc_token", "obj_token"}
+
+var (
+	sharePathReg  = regexp.MustCompile(`^/(docs|docx|wiki|sheets|drive/folder)/?$`)
+	shareTokenReg = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+)
+
+// NormalizeShareURL 将分享链接转换为标准的文档 URL：
+//  1. applink 跳转链接（https://applink.feishu.cn/client/docs/open?url=...）取出其中的 url 参数
+//  2. 通过查询参数携带 token 的链接（https://xxx/docx?token=...）改写为 https://xxx/docx/<token>
+//
+// 其余 URL（包括带 from、share_token 等参数的普通链接）原样返回
+func NormalizeShareURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	query := u.Query()
+
+	if strings.HasPrefix(u.Host, "applink.") {
+		if inner := query.Get("url"); inner != "" && inner != rawURL {
+			return NormalizeShareURL(inner)
+		}
+		return rawURL
+	}
+
+	if m := sharePathReg.FindStringSubmatch(u.Path); m != nil {
+		for _, param := range shareTokenParams {
+			if token := query.Get(param); shareTokenReg.MatchString(token) {
+				return "https://" + u.Host + "/" + m[1] + "/" + token
+			}
+		}
+	}
+	return rawURL
+}
+
+// bareTokenReg 匹配不含域名的裸 token
+var bareTokenReg = regexp.MustCompile(`^[a-zA-Z0-9]{8,}$`)
+
+// IsBareToken 判断输入是否为只粘贴了 token、不含域名的形式
+func IsBareToken(input string) bool {
+	return bareTokenReg.MatchString(strings.TrimSpace(input))
+}
+
+// TokenToURL 将裸 token 按类型（docx、wiki、sheet、folder）补全为标准 URL
+// 下载流程只依赖 URL 中的 token，域名仅作占位
+func TokenToURL(token, tokenType string) string {
+	token = strings.TrimSpace(token)
+	if tokenType == "folder" {
+		return "https://feishu.cn/drive/folder/" + token
+	}
+	if tokenType == "sheet" {
+		// 云空间文件类型为 sheet，URL 路径为 sheets
+		tokenType = "sheets"
+	}
+	return "https://feishu.cn/" + tokenType + "/" + token
+}
+
 func ValidateDocumentURL(url string) (string, string, error) {
-	reg := regexp.MustCompile("^https://[\\w-.]+/(docs|docx|wiki)/([a-zA-Z0-9]+)")
+	url = NormalizeShareURL(url)
+	reg := regexp.MustCompile("^https://[\\w-.]+/(docs|docx|wiki|sheets)/([a-zA-Z0-9]+)")
 	matchResult := reg.FindStringSubmatch(url)
 	if matchResult == nil || len(matchResult) != 3 {
 		return "", "", errors.Errorf("Invalid feishu/larksuite document URL pattern")
@@ -26,6 +88,7 @@ func ValidateDocumentURL(url string) (string, string, error) {
 }
 
 func ValidateFolderURL(url string) (string, error) {
+	url = NormalizeShareURL(url)
 	reg := regexp.MustCompile("^https://[\\w-.]+/drive/folder/([a-zA-Z0-9]+)")
 	matchResult := reg.FindStringSubmatch(url)
 	if matchResult == nil || len(matchResult) != 2 {
@@ -36,6 +99,7 @@ func ValidateFolderURL(url string) (string, error) {
 }
 
 func ValidateWikiURL(url string) (string, string, error) {
+	url = NormalizeShareURL(url)
 	// 支持两种知识库URL格式：
 	// 1. 知识库设置页面：https://xxx/wiki/settings/[token]
 	// 2. 知识库页面：https://xxx/wiki/[token]